@@ -1,7 +1,7 @@
 #!/bin/bash
 
 mountDir="/host"
-path="/usr/sbin:/usr/bin:/sbin:/bin"
+path="/usr/sbin:/usr/bin:/sbin:/bin:/usr/lpp/mmfs/bin"
 cmd=`basename "$0"`
 
 if [ ! -d "${mountDir}" ]; then
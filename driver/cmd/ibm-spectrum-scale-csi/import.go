@@ -0,0 +1,69 @@
+/**
+ * Copyright 2024 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	driver "github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin"
+)
+
+// importFilesetCommand adopts an existing fileset as a PV and a PVC bound to
+// it, through a ScaleFilesetImport reconciled by the operator. It runs in a
+// node plugin pod of the driver, whose service account can create the
+// ScaleFilesetImport in the namespace of the driver, e.g.
+// kubectl exec <node plugin pod> -c ibm-spectrum-scale-csi -- /ibm-spectrum-scale-csi import-fileset
+// --filesystem fs1 --fileset fset1 --namespace ns1 --pvc pvc1
+const importFilesetCommand = "import-fileset"
+
+func importFileset(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet(importFilesetCommand, flag.ContinueOnError)
+	clusterID := flags.String("clusterid", "", "ID of the cluster owning the filesystem, primary cluster if not set")
+	filesystem := flags.String("filesystem", "", "name of the filesystem of the fileset")
+	fileset := flags.String("fileset", "", "name of the fileset to import")
+	namespace := flags.String("namespace", "default", "namespace of the PVC")
+	pvcName := flags.String("pvc", "", "name of the PVC")
+	pvName := flags.String("pv", "", "name of the PV, <namespace>-<pvc> if not set")
+	storageClass := flags.String("storageclass", "", "storageClassName of the PV and the PVC")
+	accessMode := flags.String("accessmode", driver.DefaultImportAccessMode, "access mode of the PV and the PVC")
+	size := flags.String("size", "", "capacity of the PV, block quota of the fileset if not set")
+	timeout := flags.Duration("timeout", 5*time.Minute, "time to wait for the import to complete")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	req := driver.ImportFilesetRequest{
+		ClusterID:        *clusterID,
+		Filesystem:       *filesystem,
+		Fileset:          *fileset,
+		PVName:           *pvName,
+		PVCNamespace:     *namespace,
+		PVCName:          *pvcName,
+		StorageClassName: *storageClass,
+		AccessMode:       *accessMode,
+		Size:             *size,
+	}
+	imported, err := driver.ImportFileset(ctx, req, *timeout)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", imported.Message)
+	return nil
+}
@@ -20,15 +20,18 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	driver "github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin"
 	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/settings"
 	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/utils"
 	"github.com/natefinch/lumberjack"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"k8s.io/klog/v2"
 )
 
@@ -42,11 +45,21 @@ var (
 	driverName     = flag.String("drivername", "spectrumscale.csi.ibm.com", "name of the driver")
 	nodeID         = flag.String("nodeid", "", "node id")
 	kubeletRootDir = flag.String("kubeletRootDirPath", "/var/lib/kubelet", "kubelet root directory path")
+	metricsAddress = flag.String("metricsAddress", "", "address of the Prometheus metrics endpoint, e.g. :9822, disabled if empty")
 	vendorVersion  = "2.12.0"
 )
 
 func main() {
 	klog.InitFlags(nil)
+	if len(os.Args) > 1 && os.Args[1] == importFilesetCommand {
+		if err := importFileset(setContext(), os.Args[2:]); err != nil {
+			klog.Errorf("Failed to import fileset: %v", err)
+			klog.Flush()
+			os.Exit(1)
+		}
+		klog.Flush()
+		os.Exit(0)
+	}
 	if val, ok := os.LookupEnv(utils.LogLevel); ok {
 		klog.Infof("[%s] found in the env : %s", utils.LogLevel, val)
 	}
@@ -111,9 +124,28 @@ func handle(ctx context.Context) {
 	}
 	newDriver := driver
 	newDriver.PrintDriverInit(ctx)
+	if *metricsAddress != "" {
+		go serveMetrics(ctx, *metricsAddress)
+	}
 	driver.Run(ctx, *endpoint)
 }
 
+// serveMetrics serves the Prometheus metrics of the driver on /metrics.
+func serveMetrics(ctx context.Context, address string) {
+	loggerId := utils.GetLoggerId(ctx)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	server := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	klog.Infof("[%s] serving metrics on [%s]", loggerId, address)
+	if err := server.ListenAndServe(); err != nil {
+		klog.Errorf("[%s] metrics server on [%s] failed: %v", loggerId, address, err)
+	}
+}
+
 func createPersistentStorage(persistentStoragePath string) error {
 	if _, err := os.Stat(persistentStoragePath); os.IsNotExist(err) {
 		if err := os.MkdirAll(persistentStoragePath, os.FileMode(0644)); err != nil {
@@ -0,0 +1,134 @@
+/**
+ * Copyright 2024 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scale
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/connectors"
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/utils"
+)
+
+// aclTemplateConfigMapKey is the key of the ACL template in the ConfigMap
+// referred by the aclTemplateConfigMap parameter of a storageClass, which is
+// in the namespace of the driver
+const aclTemplateConfigMapKey = "acl"
+
+// aclTemplateVars are the variables of an ACL template
+type aclTemplateVars struct {
+	PVCNamespace string
+	PVCName      string
+	PVName       string
+}
+
+// validateConfigMapName checks the name of a ConfigMap of the namespace of
+// the driver
+func validateConfigMapName(name string) error {
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("invalid ConfigMap name [%s], it must be the name of a ConfigMap in the namespace of the driver: %s", name, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// getACLTemplate returns the ACL template of a volume, either inline in
+// the storageClass or from the key "acl" of the referred ConfigMap of the
+// namespace of the driver.
+func getACLTemplate(ctx context.Context, scVol *scaleVolume) (string, error) {
+	if scVol.ACLTemplate != "" {
+		return scVol.ACLTemplate, nil
+	}
+
+	client, err := getKubeClient()
+	if err != nil {
+		return "", fmt.Errorf("unable to create Kubernetes client: %v", err)
+	}
+	namespace, err := os.ReadFile(serviceAccountNamespacePath)
+	if err != nil {
+		return "", fmt.Errorf("unable to get the namespace of the driver: %v", err)
+	}
+	configMap, err := client.CoreV1().ConfigMaps(strings.TrimSpace(string(namespace))).Get(ctx, scVol.ACLTemplateConfigMap, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("unable to get ConfigMap [%s]: %v", scVol.ACLTemplateConfigMap, err)
+	}
+	aclTemplate, ok := configMap.Data[aclTemplateConfigMapKey]
+	if !ok {
+		return "", fmt.Errorf("ConfigMap [%s] has no key [%s]", scVol.ACLTemplateConfigMap, aclTemplateConfigMapKey)
+	}
+	return aclTemplate, nil
+}
+
+// renderACLTemplate renders an ACL template into the NFSv4 ACL to set. The
+// template is a JSON list of ACL entries, each with a type (allow or deny),
+// who (special:owner@, user:<name> or group:<name>), permissions and
+// optional flags, e.g. FileInherit:DirInherit. The template can refer to
+// {{ .PVCNamespace }}, {{ .PVCName }} and {{ .PVName }}.
+func renderACLTemplate(aclTemplate string, vars aclTemplateVars) (connectors.SetAclRequest, error) {
+	acl := connectors.SetAclRequest{Type: connectors.AclTypeNFSv4}
+
+	tmpl, err := template.New("acl").Option("missingkey=error").Parse(aclTemplate)
+	if err != nil {
+		return acl, fmt.Errorf("invalid ACL template: %v", err)
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, vars); err != nil {
+		return acl, fmt.Errorf("invalid ACL template: %v", err)
+	}
+
+	if err := json.Unmarshal([]byte(out.String()), &acl.Entries); err != nil {
+		return acl, fmt.Errorf("invalid ACL template, it must be a JSON list of ACL entries: %v", err)
+	}
+	if len(acl.Entries) == 0 {
+		return acl, fmt.Errorf("invalid ACL template, it has no ACL entries")
+	}
+	for _, entry := range acl.Entries {
+		if entry.Type != "allow" && entry.Type != "deny" {
+			return acl, fmt.Errorf("invalid type [%s] of ACL entry for [%s], it must be allow or deny", entry.Type, entry.Who)
+		}
+		if entry.Who == "" || entry.Permissions == "" {
+			return acl, fmt.Errorf("invalid ACL entry %+v, who and permissions are required", entry)
+		}
+	}
+	return acl, nil
+}
+
+// getVolumeACL returns the NFSv4 ACL rendered from the ACL template of a
+// volume.
+func getVolumeACL(ctx context.Context, scVol *scaleVolume, vars aclTemplateVars) (connectors.SetAclRequest, error) {
+	aclTemplate, err := getACLTemplate(ctx, scVol)
+	if err != nil {
+		return connectors.SetAclRequest{}, err
+	}
+	return renderACLTemplate(aclTemplate, vars)
+}
+
+// setVolumeACL sets the NFSv4 ACL of a volume on its root directory.
+func setVolumeACL(ctx context.Context, scVol *scaleVolume, acl connectors.SetAclRequest, targetPath string) error {
+	klog.Infof("[%s] volume:[%v] - setting NFSv4 ACL on path [%v] of filesystem [%v]", utils.GetLoggerId(ctx), scVol.VolName, targetPath, scVol.VolBackendFs)
+	if err := scVol.Connector.SetAcl(ctx, scVol.VolBackendFs, targetPath, acl); err != nil {
+		return fmt.Errorf("unable to set ACL on path [%v] of filesystem [%v]: %v", targetPath, scVol.VolBackendFs, err)
+	}
+	return nil
+}
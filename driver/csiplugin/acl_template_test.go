@@ -0,0 +1,130 @@
+/**
+ * Copyright 2024 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scale
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/connectors"
+)
+
+func TestRenderACLTemplate(t *testing.T) {
+	vars := aclTemplateVars{PVCNamespace: "ns1", PVCName: "pvc1", PVName: "pvc-1234"}
+
+	tests := []struct {
+		name     string
+		template string
+		want     []connectors.AclEntry
+		wantErr  bool
+	}{
+		{
+			name:     "owner entry",
+			template: `[{"type": "allow", "who": "special:owner@", "permissions": "rwmxDaAdcCs", "flags": "FileInherit:DirInherit"}]`,
+			want: []connectors.AclEntry{
+				{Type: "allow", Who: "special:owner@", Permissions: "rwmxDaAdcCs", Flags: "FileInherit:DirInherit"},
+			},
+		},
+		{
+			name: "template variables",
+			template: `[
+				{"type": "allow", "who": "group:{{ .PVCNamespace }}-admins", "permissions": "rwmxDaAdcCs"},
+				{"type": "deny", "who": "user:{{ .PVCName }}-{{ .PVName }}", "permissions": "w"}
+			]`,
+			want: []connectors.AclEntry{
+				{Type: "allow", Who: "group:ns1-admins", Permissions: "rwmxDaAdcCs"},
+				{Type: "deny", Who: "user:pvc1-pvc-1234", Permissions: "w"},
+			},
+		},
+		{
+			name:     "unknown variable",
+			template: `[{"type": "allow", "who": "group:{{ .Namespace }}", "permissions": "r"}]`,
+			wantErr:  true,
+		},
+		{
+			name:     "invalid template syntax",
+			template: `[{"type": "allow", "who": "group:{{ .PVCName", "permissions": "r"}]`,
+			wantErr:  true,
+		},
+		{
+			name:     "not a JSON list",
+			template: `{"type": "allow", "who": "special:owner@", "permissions": "r"}`,
+			wantErr:  true,
+		},
+		{
+			name:     "no entries",
+			template: `[]`,
+			wantErr:  true,
+		},
+		{
+			name:     "invalid entry type",
+			template: `[{"type": "audit", "who": "special:owner@", "permissions": "r"}]`,
+			wantErr:  true,
+		},
+		{
+			name:     "missing who",
+			template: `[{"type": "allow", "permissions": "r"}]`,
+			wantErr:  true,
+		},
+		{
+			name:     "missing permissions",
+			template: `[{"type": "allow", "who": "special:owner@"}]`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			acl, err := renderACLTemplate(tc.template, vars)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("renderACLTemplate() succeeded with entries %+v, want an error", acl.Entries)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("renderACLTemplate() failed: %v", err)
+			}
+			if acl.Type != connectors.AclTypeNFSv4 {
+				t.Errorf("renderACLTemplate() type = %q, want %q", acl.Type, connectors.AclTypeNFSv4)
+			}
+			if !reflect.DeepEqual(acl.Entries, tc.want) {
+				t.Errorf("renderACLTemplate() entries = %+v, want %+v", acl.Entries, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateConfigMapName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "acl-templates"},
+		{name: "acl.templates"},
+		{name: "", wantErr: true},
+		{name: "ns1/acl-templates", wantErr: true},
+		{name: "ACL", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		err := validateConfigMapName(tc.name)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("validateConfigMapName(%q) error = %v, want error %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+/**
+ * Copyright 2024 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scale
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/utils"
+)
+
+// The controller service of the driver runs in the node plugin pod next to
+// the attacher, the attachments known by a driver instance are synced with
+// the VolumeAttachments when it starts to serve ControllerPublishVolume
+// and periodically after, as the attacher may have been served by another
+// instance meanwhile.
+const attachmentsResyncInterval = 5 * time.Minute
+
+// volumeRevertLabel is set on the PV of a volume by the operator while the
+// volume is reverted to a snapshot by a ScaleVolumeRevert.
+const volumeRevertLabel = "csi.ibm.com/volume-revert"
+
+// volumeAttachments tracks the nodes the volumes are published to by
+// ControllerPublishVolume, with the access mode of the publish.
+type volumeAttachments struct {
+	mutex sync.Mutex
+	// volume ID -> node ID -> attachment
+	nodes map[string]map[string]*volumeAttachment
+	// volume ID -> node ID -> time of the last ControllerUnpublishVolume
+	detached map[string]map[string]time.Time
+	lastSync time.Time
+	syncing  bool
+}
+
+// volumeAttachment is the publish of a volume to a node.
+type volumeAttachment struct {
+	mode csi.VolumeCapability_AccessMode_Mode
+	// recorded is the time the publish was recorded by this instance
+	recorded time.Time
+	// publishing is set while ControllerPublishVolume is in progress
+	publishing bool
+}
+
+func isSingleNodeAccessMode(mode csi.VolumeCapability_AccessMode_Mode) bool {
+	switch mode {
+	case csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY,
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_SINGLE_WRITER,
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_MULTI_WRITER:
+		return true
+	}
+	return false
+}
+
+// pvAccessMode returns the CSI access mode of the access modes of a PV as
+// kubelet requests it.
+func pvAccessMode(accessModes []corev1.PersistentVolumeAccessMode) csi.VolumeCapability_AccessMode_Mode {
+	mode := csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER
+	for _, accessMode := range accessModes {
+		switch accessMode {
+		case corev1.ReadWriteMany, corev1.ReadOnlyMany:
+			return csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER
+		case corev1.ReadWriteOncePod:
+			mode = csi.VolumeCapability_AccessMode_SINGLE_NODE_SINGLE_WRITER
+		case corev1.ReadWriteOnce:
+			if mode != csi.VolumeCapability_AccessMode_SINGLE_NODE_SINGLE_WRITER {
+				mode = csi.VolumeCapability_AccessMode_SINGLE_NODE_MULTI_WRITER
+			}
+		}
+	}
+	return mode
+}
+
+// sync merges the attached VolumeAttachments of the driver into the
+// attachments if they were not synced for attachmentsResyncInterval. The
+// VolumeAttachments are listed without the mutex held. An attachment is
+// dropped only if it was recorded before the list, its publish is not in
+// progress and no VolumeAttachment exists for it anymore. An attached
+// VolumeAttachment is added unless the volume was unpublished from the node
+// after the list. The attachments kept in memory are used if the
+// VolumeAttachments cannot be listed.
+func (a *volumeAttachments) sync(ctx context.Context, driverName string) {
+	loggerId := utils.GetLoggerId(ctx)
+	a.mutex.Lock()
+	if a.syncing || time.Since(a.lastSync) < attachmentsResyncInterval {
+		a.mutex.Unlock()
+		return
+	}
+	a.syncing = true
+	a.mutex.Unlock()
+	listed := time.Now()
+	defer func() {
+		a.mutex.Lock()
+		a.syncing = false
+		a.mutex.Unlock()
+	}()
+
+	client, err := getKubeClient()
+	if err != nil {
+		klog.Warningf("[%s] unable to rebuild volume attachments, failed to create Kubernetes client: %v", loggerId, err)
+		return
+	}
+	vaList, err := client.StorageV1().VolumeAttachments().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.Warningf("[%s] unable to rebuild volume attachments, failed to list VolumeAttachments: %v", loggerId, err)
+		return
+	}
+	pvList, err := client.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.Warningf("[%s] unable to rebuild volume attachments, failed to list PersistentVolumes: %v", loggerId, err)
+		return
+	}
+	pvs := make(map[string]*corev1.PersistentVolume)
+	for i := range pvList.Items {
+		pv := &pvList.Items[i]
+		if pv.Spec.CSI != nil && pv.Spec.CSI.Driver == driverName {
+			pvs[pv.Name] = pv
+		}
+	}
+
+	// volume ID -> node ID -> attached
+	vas := make(map[string]map[string]bool)
+	modes := make(map[string]csi.VolumeCapability_AccessMode_Mode)
+	for _, va := range vaList.Items {
+		if va.Spec.Attacher != driverName || va.Spec.Source.PersistentVolumeName == nil {
+			continue
+		}
+		pv, ok := pvs[*va.Spec.Source.PersistentVolumeName]
+		if !ok {
+			continue
+		}
+		volumeID := pv.Spec.CSI.VolumeHandle
+		if vas[volumeID] == nil {
+			vas[volumeID] = make(map[string]bool)
+		}
+		vas[volumeID][va.Spec.NodeName] = va.Status.Attached
+		modes[volumeID] = pvAccessMode(pv.Spec.AccessModes)
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.lastSync = listed
+	if a.nodes == nil {
+		a.nodes = make(map[string]map[string]*volumeAttachment)
+	}
+	dropped, added := 0, 0
+	for volumeID, nodes := range a.nodes {
+		for nodeID, attachment := range nodes {
+			if _, found := vas[volumeID][nodeID]; found || attachment.publishing || !attachment.recorded.Before(listed) {
+				continue
+			}
+			delete(nodes, nodeID)
+			dropped++
+		}
+		if len(nodes) == 0 {
+			delete(a.nodes, volumeID)
+		}
+	}
+	for volumeID, nodes := range vas {
+		for nodeID, attached := range nodes {
+			if !attached {
+				continue
+			}
+			if _, found := a.nodes[volumeID][nodeID]; found {
+				continue
+			}
+			if detached, found := a.detached[volumeID][nodeID]; found && !detached.Before(listed) {
+				continue
+			}
+			if a.nodes[volumeID] == nil {
+				a.nodes[volumeID] = make(map[string]*volumeAttachment)
+			}
+			a.nodes[volumeID][nodeID] = &volumeAttachment{mode: modes[volumeID], recorded: listed}
+			added++
+		}
+	}
+	// The unpublishes before the list are in the VolumeAttachments
+	for volumeID, nodes := range a.detached {
+		for nodeID, detached := range nodes {
+			if detached.Before(listed) {
+				delete(nodes, nodeID)
+			}
+		}
+		if len(nodes) == 0 {
+			delete(a.detached, volumeID)
+		}
+	}
+	klog.Infof("[%s] synced attachments with VolumeAttachments: %d added, %d dropped, %d volumes attached", loggerId, added, dropped, len(a.nodes))
+}
+
+// attach records the publish of a volume to a node. A volume published
+// with a single node access mode cannot be published to another node, and
+// FailedPrecondition is returned then. It returns true if the publish was
+// not recorded already. The publish is in progress until published or
+// detach is called.
+func (a *volumeAttachments) attach(ctx context.Context, driverName string, volumeID string, nodeID string, mode csi.VolumeCapability_AccessMode_Mode) (bool, error) {
+	a.sync(ctx, driverName)
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.nodes == nil {
+		a.nodes = make(map[string]map[string]*volumeAttachment)
+	}
+
+	for otherNodeID, other := range a.nodes[volumeID] {
+		if otherNodeID == nodeID {
+			continue
+		}
+		if isSingleNodeAccessMode(mode) || isSingleNodeAccessMode(other.mode) {
+			klog.Errorf("[%s] volume [%s] is published to node [%s] with access mode %v, it cannot be published to node [%s] with access mode %v", utils.GetLoggerId(ctx), volumeID, otherNodeID, other.mode, nodeID, mode)
+			return false, status.Error(codes.FailedPrecondition, fmt.Sprintf("volume [%s] is published to node [%s] with access mode %v, it cannot be published to another node", volumeID, otherNodeID, other.mode))
+		}
+	}
+
+	if a.nodes[volumeID] == nil {
+		a.nodes[volumeID] = make(map[string]*volumeAttachment)
+	}
+	_, found := a.nodes[volumeID][nodeID]
+	a.nodes[volumeID][nodeID] = &volumeAttachment{mode: mode, recorded: time.Now(), publishing: true}
+	return !found, nil
+}
+
+// published records the completion of the publish of a volume to a node.
+func (a *volumeAttachments) published(volumeID string, nodeID string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if attachment, found := a.nodes[volumeID][nodeID]; found {
+		attachment.publishing = false
+	}
+}
+
+// detach removes the publish of a volume to a node.
+func (a *volumeAttachments) detach(volumeID string, nodeID string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	delete(a.nodes[volumeID], nodeID)
+	if len(a.nodes[volumeID]) == 0 {
+		delete(a.nodes, volumeID)
+	}
+	if a.detached == nil {
+		a.detached = make(map[string]map[string]time.Time)
+	}
+	if a.detached[volumeID] == nil {
+		a.detached[volumeID] = make(map[string]time.Time)
+	}
+	a.detached[volumeID][nodeID] = time.Now()
+}
+
+// checkVolumeNotReverted returns FailedPrecondition if the volume is being
+// reverted to a snapshot, the volume cannot be published until the revert
+// completes.
+func checkVolumeNotReverted(ctx context.Context, driverName string, volumeID string) error {
+	client, err := getKubeClient()
+	if err != nil {
+		return status.Error(codes.Internal, fmt.Sprintf("failed to create Kubernetes client: %v", err))
+	}
+	pvList, err := client.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{LabelSelector: volumeRevertLabel})
+	if err != nil {
+		return status.Error(codes.Unavailable, fmt.Sprintf("failed to list the PersistentVolumes being reverted: %v", err))
+	}
+	for _, pv := range pvList.Items {
+		if pv.Spec.CSI != nil && pv.Spec.CSI.Driver == driverName && pv.Spec.CSI.VolumeHandle == volumeID {
+			klog.Errorf("[%s] volume [%s] of PV [%s] is being reverted to a snapshot", utils.GetLoggerId(ctx), volumeID, pv.Name)
+			return status.Error(codes.FailedPrecondition, fmt.Sprintf("volume [%s] of PV [%s] is being reverted to a snapshot, it cannot be published until the revert completes", volumeID, pv.Name))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+/**
+ * Copyright 2024 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scale
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sys/unix"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"k8s.io/mount-utils"
+
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/utils"
+)
+
+const (
+	// BIND_MOUNT_REPAIR enables or disables the repair of the stale bind
+	// mounts of the volumes, ENABLED by default
+	BIND_MOUNT_REPAIR = "BIND_MOUNT_REPAIR"
+
+	kubeletRootDirEnv     = "KUBELET_ROOT_DIR_PATH"
+	defaultKubeletRootDir = "/var/lib/kubelet"
+
+	// Reasons of the events of the node
+	staleBindMountRepairedReason     = "StaleBindMountRepaired"
+	staleBindMountRepairFailedReason = "StaleBindMountRepairFailed"
+
+	// bindMountRepairDelay is the delay of the check of the bind mounts
+	// after a change of the GPFS filesystem mounts
+	bindMountRepairDelay = 5 * time.Second
+
+	bindMountRepairSucceeded = "succeeded"
+	bindMountRepairFailed    = "failed"
+)
+
+var (
+	staleBindMounts = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "ibm_spectrum_scale_csi_stale_bind_mounts",
+		Help: "Number of stale bind mounts of volumes found on the node by the last check.",
+	})
+	bindMountRepairs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ibm_spectrum_scale_csi_bind_mount_repairs_total",
+		Help: "Number of repairs of stale bind mounts of volumes on the node by filesystem and result.",
+	}, []string{"filesystem", "result"})
+)
+
+func init() {
+	prometheus.MustRegister(staleBindMounts, bindMountRepairs)
+}
+
+// bindMountRepairer repairs the bind mounts of the volumes published to the
+// node which refer to a previous mount of their filesystem, e.g. after GPFS
+// was restarted or the filesystem was unmounted and mounted again on the
+// node. Such bind mounts fail with ESTALE or ENOTCONN until they are mounted
+// again. The bind mounts are checked when the node plugin starts and each
+// time a GPFS filesystem is mounted or unmounted. The containers which use a repaired volume
+// keep the stale mount until they are restarted, as the mount propagated into
+// a container cannot be replaced.
+type bindMountRepairer struct {
+	nodeID   string
+	podsDir  string
+	recorder record.EventRecorder
+	// trigger coalesces the mount table changes notified during a check
+	trigger chan struct{}
+}
+
+// startBindMountRepair starts the repair of the stale bind mounts of the
+// node unless it is disabled.
+func (driver *ScaleDriver) startBindMountRepair(ctx context.Context, recorder record.EventRecorder) {
+	loggerId := utils.GetLoggerId(ctx)
+	if strings.ToUpper(utils.GetEnv(BIND_MOUNT_REPAIR, "ENABLED")) == "DISABLED" {
+		klog.Infof("[%s] repair of stale bind mounts is disabled", loggerId)
+		return
+	}
+	kubeletRootDir := utils.GetEnv(kubeletRootDirEnv, defaultKubeletRootDir)
+	r := &bindMountRepairer{
+		nodeID:   driver.nodeID,
+		podsDir:  filepath.Join(kubeletRootDir, "pods") + "/",
+		recorder: recorder,
+		trigger:  make(chan struct{}, 1),
+	}
+	gpfsMounts.subscribe(r.notify)
+	r.notify()
+	go r.run()
+	klog.Infof("[%s] repair of stale bind mounts under [%s] is enabled", loggerId, r.podsDir)
+}
+
+// newEventRecorder returns a recorder of the events of the driver, or nil if
+// there is no Kubernetes client.
+func newEventRecorder(ctx context.Context, component string) record.EventRecorder {
+	client, err := getKubeClient()
+	if err != nil {
+		klog.Warningf("[%s] events are not recorded, unable to create the Kubernetes client: %v", utils.GetLoggerId(ctx), err)
+		return nil
+	}
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	return broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: component})
+}
+
+func (r *bindMountRepairer) notify() {
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// run checks the bind mounts once the GPFS mounts did not change for
+// bindMountRepairDelay, so that the filesystems remounted together, e.g. when
+// GPFS is restarted, are repaired by a single check.
+func (r *bindMountRepairer) run() {
+	for range r.trigger {
+		time.Sleep(bindMountRepairDelay)
+		select {
+		case <-r.trigger:
+		default:
+		}
+		r.repairAll(utils.SetLoggerId(context.Background()))
+	}
+}
+
+// repairAll mounts the stale bind mounts of the volumes again.
+func (r *bindMountRepairer) repairAll(ctx context.Context) {
+	loggerId := utils.GetLoggerId(ctx)
+	file, err := os.Open(procMountInfoPath)
+	if err != nil {
+		klog.Errorf("[%s] unable to open [%s]: %v", loggerId, procMountInfoPath, err)
+		return
+	}
+	infos, err := parseMountInfo(file)
+	file.Close()
+	if err != nil {
+		klog.Errorf("[%s] unable to parse [%s]: %v", loggerId, procMountInfoPath, err)
+		return
+	}
+
+	stale := r.findStaleBindMounts(ctx, infos)
+	staleBindMounts.Set(float64(len(stale)))
+	for _, bindMount := range stale {
+		r.repair(ctx, bindMount.target, bindMount.fs)
+	}
+}
+
+type staleBindMount struct {
+	// target is the bind mount of the volume
+	target mountInfo
+	// fs is the current mount of the filesystem of the volume
+	fs mountInfo
+}
+
+// findStaleBindMounts returns the bind mounts of the volumes of the pods
+// which refer to a previous mount of a filesystem mounted on the node. The
+// bind mounts of the filesystems which are not mounted are left as they are.
+func (r *bindMountRepairer) findStaleBindMounts(ctx context.Context, infos []mountInfo) []staleBindMount {
+	filesystems := make(map[string]mountInfo)
+	for _, info := range infos {
+		if info.FsType == gpfsFsType && info.Root == "/" && !strings.HasPrefix(info.MountPoint, r.podsDir) {
+			filesystems[info.Source] = info
+		}
+	}
+
+	var stale []staleBindMount
+	for _, info := range infos {
+		if info.FsType != gpfsFsType || !strings.HasPrefix(info.MountPoint, r.podsDir) {
+			continue
+		}
+		fs, ok := filesystems[info.Source]
+		if !ok {
+			continue
+		}
+		if fs.Major == info.Major && fs.Minor == info.Minor {
+			var stat unix.Stat_t
+			err := unix.Stat(info.MountPoint, &stat)
+			if err == nil || !isStaleMountError(err) {
+				continue
+			}
+		}
+		klog.Warningf("[%s] bind mount [%s] of filesystem [%s] is stale", utils.GetLoggerId(ctx), info.MountPoint, info.Source)
+		stale = append(stale, staleBindMount{target: info, fs: fs})
+	}
+	return stale
+}
+
+func isStaleMountError(err error) bool {
+	return errors.Is(err, unix.ESTALE) || errors.Is(err, unix.ENOTCONN) || errors.Is(err, unix.EIO)
+}
+
+// repair mounts a stale bind mount of a volume again from the current mount
+// of its filesystem. The target path is locked as in NodePublishVolume and
+// NodeUnpublishVolume, and skipped if one of them is in progress.
+func (r *bindMountRepairer) repair(ctx context.Context, target mountInfo, fs mountInfo) {
+	loggerId := utils.GetLoggerId(ctx)
+	targetPath := target.MountPoint
+	if !lock(targetPath, ctx) {
+		klog.V(4).Infof("[%s] bind mount [%s] is not repaired, a NodePublish/NodeUnpublish request is in progress", loggerId, targetPath)
+		return
+	}
+	defer unlock(targetPath, ctx)
+
+	// The mounts are done on the host, see NodePublishVolume
+	source := filepath.Join(strings.TrimPrefix(fs.MountPoint, hostDir), target.Root)
+	options := []string{"bind"}
+	for _, option := range append(target.Options, target.SuperOptions...) {
+		if option == "ro" {
+			options = append(options, option)
+		}
+	}
+
+	klog.Infof("[%s] repairing stale bind mount [%s] -> [%s]", loggerId, targetPath, source)
+	err := r.remount(source, targetPath, options)
+	if err != nil {
+		klog.Errorf("[%s] unable to repair stale bind mount [%s] -> [%s]: %v", loggerId, targetPath, source, err)
+		bindMountRepairs.WithLabelValues(target.Source, bindMountRepairFailed).Inc()
+		r.event(ctx, v1.EventTypeWarning, staleBindMountRepairFailedReason,
+			fmt.Sprintf("Unable to repair stale bind mount %s of filesystem %s: %v", targetPath, target.Source, err))
+		return
+	}
+	bindMountRepairs.WithLabelValues(target.Source, bindMountRepairSucceeded).Inc()
+	r.event(ctx, v1.EventTypeNormal, staleBindMountRepairedReason,
+		fmt.Sprintf("Repaired stale bind mount %s of filesystem %s, the pods using it must be restarted", targetPath, target.Source))
+}
+
+func (r *bindMountRepairer) remount(source string, targetPath string, options []string) error {
+	mounter := &mount.Mounter{}
+	if err := mounter.Unmount(targetPath); err != nil {
+		return fmt.Errorf("unmount failed: %v", err)
+	}
+	if err := mounter.Mount(source, targetPath, "", options); err != nil {
+		return fmt.Errorf("mount failed: %v", err)
+	}
+	var stat unix.Stat_t
+	if err := unix.Stat(targetPath, &stat); err != nil {
+		return fmt.Errorf("stat failed after mount: %v", err)
+	}
+	return nil
+}
+
+func (r *bindMountRepairer) event(ctx context.Context, eventType string, reason string, message string) {
+	recordNodeEvent(ctx, r.recorder, r.nodeID, eventType, reason, message)
+}
+
+// recordNodeEvent records an event of a node if there is a recorder. The node
+// is read to refer to it by its UID, the event is not recorded if it cannot be
+// read.
+func recordNodeEvent(ctx context.Context, recorder record.EventRecorder, nodeID string, eventType string, reason string, message string) {
+	if recorder == nil {
+		return
+	}
+	loggerId := utils.GetLoggerId(ctx)
+	client, err := getKubeClient()
+	if err != nil {
+		klog.Warningf("[%s] event %s of node [%s] is not recorded: %v", loggerId, reason, nodeID, err)
+		return
+	}
+	node, err := client.CoreV1().Nodes().Get(ctx, nodeID, metav1.GetOptions{})
+	if err != nil {
+		klog.Warningf("[%s] event %s of node [%s] is not recorded, unable to get the node: %v", loggerId, reason, nodeID, err)
+		return
+	}
+	recorder.Event(node, eventType, reason, message)
+}
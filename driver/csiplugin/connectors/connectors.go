@@ -18,6 +18,8 @@ package connectors
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/settings"
 	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/utils"
@@ -37,8 +39,10 @@ type SpectrumScaleConnector interface {
 	ListFilesystems(ctx context.Context) ([]string, error)
 	GetFilesystemDetails(ctx context.Context, filesystemName string) (FileSystem_v2, error)
 	GetFilesystemMountpoint(ctx context.Context, filesystemName string) (string, error)
+	IsFilesystemEncrypted(ctx context.Context, filesystemName string) (bool, error)
 	//Node operations
 	CheckIfGatewayNodePresent(ctx context.Context) (bool, error)
+	ListNodes(ctx context.Context) ([]Node_v2, error)
 	//Fileset operations
 	CreateFileset(ctx context.Context, filesystemName string, filesetName string, opts map[string]interface{}) error
 	CheckFilesetWithAFMTarget(ctx context.Context, filesystemName string, afmTarget string) (string, error)
@@ -46,13 +50,15 @@ type SpectrumScaleConnector interface {
 	DeleteBucketKeys(ctx context.Context, bucket string) error
 	CreateS3CacheFileset(ctx context.Context, filesystemName string, filesetName string, mode string, opts map[string]interface{}, access map[string]string, scheme string) error
 	UpdateFileset(ctx context.Context, filesystemName string, filesetName string, opts map[string]interface{}) error
+	FlushCacheFileset(ctx context.Context, filesystemName string, filesetName string) error
+	GetAFMCacheState(ctx context.Context, filesystemName string, filesetName string) (string, int, error)
 	DeleteFileset(ctx context.Context, filesystemName string, filesetName string) error
 	//LinkFileset(filesystemName string, filesetName string) error
 	LinkFileset(ctx context.Context, filesystemName string, filesetName string, linkpath string) error
 	UnlinkFileset(ctx context.Context, filesystemName string, filesetName string) error
 	//ListFilesets(filesystemName string) ([]resources.Volume, error)
 	ListFileset(ctx context.Context, filesystemName string, filesetName string) (Fileset_v2, error)
-	ListCSIIndependentFilesets(ctx context.Context, filesystemName string) ([]Fileset_v2, error)
+	ListFilesets(ctx context.Context, filesystemName string, filter FilesetFilter) ([]Fileset_v2, error)
 	GetFilesetsInodeSpace(ctx context.Context, filesystemName string, inodeSpace int) ([]Fileset_v2, error)
 	IsFilesetLinked(ctx context.Context, filesystemName string, filesetName string) (bool, error)
 	FilesetRefreshTask(ctx context.Context) error
@@ -65,6 +71,7 @@ type SpectrumScaleConnector interface {
 	//Directory operations
 	MakeDirectory(ctx context.Context, filesystemName string, relativePath string, uid string, gid string) error
 	MakeDirectoryV2(ctx context.Context, filesystemName string, relativePath string, uid string, gid string, permissions string) error
+	SetAcl(ctx context.Context, filesystemName string, relativePath string, acl SetAclRequest) error
 	MountFilesystem(ctx context.Context, filesystemName string, nodeName string) error
 	UnmountFilesystem(ctx context.Context, filesystemName string, nodeName string) error
 	GetFilesystemName(ctx context.Context, filesystemUUID string) (string, error)
@@ -79,27 +86,35 @@ type SpectrumScaleConnector interface {
 	GetFileSetResponseFromId(ctx context.Context, filesystemName string, Id string) (Fileset_v2, error)
 	GetFileSetResponseFromName(ctx context.Context, filesystemName string, filesetName string) (Fileset_v2, error)
 	SetFilesystemPolicy(ctx context.Context, policy *Policy, filesystemName string) error
+	DeletePolicyPartition(ctx context.Context, partitionName string, filesystemName string) error
 	DoesTierExist(ctx context.Context, tierName string, filesystemName string) error
 	GetTierInfoFromName(ctx context.Context, tierName string, filesystemName string) (*StorageTier, error)
 	GetFirstDataTier(ctx context.Context, filesystemName string) (string, error)
 	IsValidNodeclass(ctx context.Context, nodeclass string) (bool, error)
+	GetNodeclassMembers(ctx context.Context, nodeclass string) ([]string, error)
 	IsSnapshotSupported(ctx context.Context) (bool, error)
 	CheckIfDefaultPolicyPartitionExists(ctx context.Context, partitionName string, filesystemName string) bool
 
 	//Snapshot operations
 	WaitForJobCompletion(ctx context.Context, statusCode int, jobID uint64) error
 	WaitForJobCompletionWithResp(ctx context.Context, statusCode int, jobID uint64) (GenericResponse, error)
+	GetJob(ctx context.Context, jobID uint64) (Job, error)
+	CancelJob(ctx context.Context, jobID uint64) error
 	CreateSnapshot(ctx context.Context, filesystemName string, filesetName string, snapshotName string) error
+	CreateExpiringSnapshot(ctx context.Context, filesystemName string, filesetName string, snapshotName string, expirationTime string) error
 	DeleteSnapshot(ctx context.Context, filesystemName string, filesetName string, snapshotName string) error
 	GetLatestFilesetSnapshots(ctx context.Context, filesystemName string, filesetName string) ([]Snapshot_v2, error)
 	GetSnapshotUid(ctx context.Context, filesystemName string, filesetName string, snapName string) (string, error)
 	GetSnapshotCreateTimestamp(ctx context.Context, filesystemName string, filesetName string, snapName string) (string, error)
+	GetSnapshotExpirationTime(ctx context.Context, filesystemName string, filesetName string, snapName string) (string, error)
 	CheckIfSnapshotExist(ctx context.Context, filesystemName string, filesetName string, snapshotName string) (bool, error)
 	ListFilesetSnapshots(ctx context.Context, filesystemName string, filesetName string) ([]Snapshot_v2, error)
+	ListFilesystemSnapshots(ctx context.Context, filesystemName string, snapshotName string) ([]Snapshot_v2, error)
 	CopyFsetSnapshotPath(ctx context.Context, filesystemName string, filesetName string, snapshotName string, srcPath string, targetPath string, nodeclass string) (int, uint64, error)
 	CopyFilesetPath(ctx context.Context, filesystemName string, filesetName string, srcPath string, targetPath string, nodeclass string) (int, uint64, error)
 	CopyDirectoryPath(ctx context.Context, filesystemName string, srcPath string, targetPath string, nodeclass string) (int, uint64, error)
 	IsNodeComponentHealthy(ctx context.Context, nodeName string, component string) (bool, error)
+	GetNodeHealthStates(ctx context.Context, nodeName string, component string) ([]State, error)
 }
 
 const (
@@ -125,8 +140,91 @@ const (
 	FilesetComment                string = "Fileset created by IBM Container Storage Interface driver"
 	UserSpecifiedCacheMode        string = "cacheMode"
 	UserSpecifiedVolumeType       string = "volumeType"
+
+	UserSpecifiedReplicationClusterId    string = "replicationClusterId"
+	UserSpecifiedReplicationVolBackendFs string = "replicationVolBackendFs"
+	UserSpecifiedReplicationRPO          string = "replicationRPO"
+	UserSpecifiedReplicationTargetHost   string = "replicationTargetHost"
+	UserSpecifiedCachePrefetch           string = "cachePrefetch"
+	UserSpecifiedCacheDeletePolicy       string = "cacheDeletePolicy"
+	UserSpecifiedCloneMode               string = "cloneMode"
+	UserSpecifiedMigrationPool           string = "migrationPool"
+	UserSpecifiedMigrationSourcePool     string = "migrationSourcePool"
+	UserSpecifiedMigrationAccessDays     string = "migrationAccessDays"
+	UserSpecifiedMigrationThreshold      string = "migrationThreshold"
+	UserSpecifiedEncryption              string = "encryption"
+	UserSpecifiedImmutability            string = "immutability"
+	UserSpecifiedACLTemplate             string = "aclTemplate"
+	UserSpecifiedACLTemplateConfigMap    string = "aclTemplateConfigMap"
+	UserSpecifiedRetentionPeriod         string = "retentionPeriod"
+	AFMModePrimary                       string = "primary"
+)
+
+// Integrated archive mode option of CreateFileset and its values
+const (
+	FilesetOptIAMMode   string = "iamMode"
+	IAMModeNonCompliant string = "noncompliant"
+	IAMModeCompliant    string = "compliant"
+)
+
+// AFM options of CreateFileset
+const (
+	AFMOptMode      string = "afmMode"
+	AFMOptTarget    string = "afmTarget"
+	AFMOptPrimaryID string = "afmPrimaryID"
+	AFMOptRPO       string = "afmRPO"
+)
+
+// AFM cache states of a fileset
+const (
+	AFMCacheStateActive       string = "Active"
+	AFMCacheStateInactive     string = "Inactive"
+	AFMCacheStateClean        string = "Clean"
+	AFMCacheStateDirty        string = "Dirty"
+	AFMCacheStateDisconnected string = "Disconnected"
+	AFMCacheStateUnmounted    string = "Unmounted"
 )
 
+// FilesetFilter selects the filesets listed by ListFilesets
+type FilesetFilter struct {
+	// IndependentOnly lists only the independent filesets
+	IndependentOnly bool
+	// CSIOnly lists only the filesets created or adopted by the driver
+	CSIOnly bool
+}
+
+// filesetCommentTimeSep separates FilesetComment from the time the driver
+// created or adopted the fileset in the comment of the fileset
+const filesetCommentTimeSep = ", since "
+
+// CSIFilesetComment returns the comment of a fileset created or adopted by the
+// driver at the given time.
+func CSIFilesetComment(since time.Time) string {
+	return FilesetComment + filesetCommentTimeSep + since.UTC().Format(time.RFC3339)
+}
+
+// IsCSIFilesetComment returns true if a fileset comment is the comment of a
+// fileset created or adopted by the driver, with or without the time.
+func IsCSIFilesetComment(comment string) bool {
+	comment = strings.TrimSpace(comment)
+	return comment == FilesetComment || strings.HasPrefix(comment, FilesetComment+filesetCommentTimeSep)
+}
+
+// GetCSIFilesetCommentTime returns the time the driver created or adopted a
+// fileset from its comment, and false if the comment has no time, e.g. for
+// the filesets of earlier releases.
+func GetCSIFilesetCommentTime(comment string) (time.Time, bool) {
+	since, found := strings.CutPrefix(strings.TrimSpace(comment), FilesetComment+filesetCommentTimeSep)
+	if !found {
+		return time.Time{}, false
+	}
+	created, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return created, true
+}
+
 func GetSpectrumScaleConnector(ctx context.Context, config settings.Clusters) (SpectrumScaleConnector, error) {
 	klog.V(4).Infof("[%s] connector GetSpectrumScaleConnector", utils.GetLoggerId(ctx))
 	return NewSpectrumRestV2(ctx, config)
@@ -0,0 +1,55 @@
+/**
+ * Copyright 2024 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package connectors
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCSIFilesetComment(t *testing.T) {
+	since := time.Date(2024, time.May, 2, 10, 11, 12, 0, time.FixedZone("UTC+2", 2*60*60))
+	comment := CSIFilesetComment(since)
+	if want := FilesetComment + ", since 2024-05-02T08:11:12Z"; comment != want {
+		t.Errorf("CSIFilesetComment() = %q, want %q", comment, want)
+	}
+
+	tests := []struct {
+		comment   string
+		wantCSI   bool
+		wantTime  time.Time
+		wantFound bool
+	}{
+		{comment: comment, wantCSI: true, wantTime: since, wantFound: true},
+		{comment: comment + "  ", wantCSI: true, wantTime: since, wantFound: true},
+		{comment: FilesetComment, wantCSI: true},
+		{comment: FilesetComment + ", since yesterday", wantCSI: true},
+		{comment: FilesetComment + " by hand"},
+		{comment: "Fileset of the finance department"},
+		{comment: ""},
+	}
+
+	for _, tc := range tests {
+		if got := IsCSIFilesetComment(tc.comment); got != tc.wantCSI {
+			t.Errorf("IsCSIFilesetComment(%q) = %t, want %t", tc.comment, got, tc.wantCSI)
+		}
+		got, found := GetCSIFilesetCommentTime(tc.comment)
+		if found != tc.wantFound || !got.Equal(tc.wantTime) {
+			t.Errorf("GetCSIFilesetCommentTime(%q) = %v, %t, want %v, %t", tc.comment, got, found, tc.wantTime, tc.wantFound)
+		}
+	}
+}
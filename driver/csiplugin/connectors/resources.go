@@ -268,7 +268,8 @@ type CreateFilesetResquest struct {
 }
 
 type CreateSnapshotRequest struct {
-	SnapshotName string `json:"snapshotName,omitempty"`
+	SnapshotName   string `json:"snapshotName,omitempty"`
+	ExpirationTime string `json:"expirationTime,omitempty"`
 }
 
 type CopySnapshotRequest struct {
@@ -385,6 +386,16 @@ type BlockInfo struct {
 	WriteCacheThreshold int    `json:"writeCacheThreshold,omitempty"`
 }
 
+type Nodeclass struct {
+	NodeclassName string   `json:"nodeclassName,omitempty"`
+	MemberNodes   []string `json:"memberNodes,omitempty"`
+}
+
+type GetNodeclassResponse struct {
+	Nodeclasses []Nodeclass `json:"nodeclasses,omitempty"`
+	Status      Status      `json:"status,omitempty"`
+}
+
 type MountInfo struct {
 	MountPoint             string `json:"mountPoint,omitempty"`
 	AutomaticMountOption   string `json:"automaticMountOption,omitempty"`
@@ -523,6 +534,7 @@ type Snapshot_v2 struct {
 	SnapID         int    `json:"snapID,omitempty"`
 	Status         string `json:"status,omitempty"`
 	Created        string `json:"created,omitempty"`
+	ExpirationTime string `json:"expirationTime,omitempty"`
 }
 
 type GetSnapshotResponse_v2 struct {
@@ -639,6 +651,25 @@ type CreateFilesetRequest struct {
 	AfmShowHomeSnapshots         string `json:"afmShowHomeSnapshots,omitempty"`
 }
 
+type AFMControlRequest struct {
+	Action string   `json:"action"`
+	Paths  []string `json:"paths,omitempty"`
+}
+
+type AFMCacheState struct {
+	FilesetName   string `json:"filesetName,omitempty"`
+	FilesetTarget string `json:"filesetTarget,omitempty"`
+	CacheState    string `json:"cacheState,omitempty"`
+	GatewayNode   string `json:"gatewayNode,omitempty"`
+	QueueLength   int    `json:"queueLength,omitempty"`
+	QueueNumExec  int    `json:"queueNumExec,omitempty"`
+}
+
+type GetAFMStateResponse struct {
+	AFMStates []AFMCacheState `json:"afmStates,omitempty"`
+	Status    Status          `json:"status,omitempty"`
+}
+
 type CreateS3CacheFilesetRequest struct {
 	FilesetName      string `json:"filesetName"`
 	Mode             string `json:"mode"`
@@ -663,6 +694,21 @@ type CreateMakeDirRequest struct {
 	PERMISSIONS string `json:"permissions,omitempty"` //permissions
 }
 
+// AclTypeNFSv4 is the type of the NFSv4 ACLs set by SetAcl
+const AclTypeNFSv4 = "NFSV4"
+
+type SetAclRequest struct {
+	Type    string     `json:"type"`
+	Entries []AclEntry `json:"entries"`
+}
+
+type AclEntry struct {
+	Type        string `json:"type"`        //allow or deny
+	Who         string `json:"who"`         //e.g. special:owner@, user:name, group:name
+	Permissions string `json:"permissions"` //e.g. rwmxDaAdcCs
+	Flags       string `json:"flags,omitempty"`
+}
+
 type SymLnkRequest struct {
 	FilesystemName string `json:"filesystemName"`
 	RelativePath   string `json:"relativePath"`
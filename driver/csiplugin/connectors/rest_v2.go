@@ -133,6 +133,39 @@ func (s *SpectrumRestV2) WaitForJobCompletionWithResp(ctx context.Context, statu
 	return GenericResponse{}, nil
 }
 
+// GetJob returns the details of a job, including the progress of a running job
+func (s *SpectrumRestV2) GetJob(ctx context.Context, jobID uint64) (Job, error) {
+	klog.V(4).Infof("[%s] rest_v2 GetJob. jobID: %d", utils.GetLoggerId(ctx), jobID)
+
+	jobURL := fmt.Sprintf("scalemgmt/v2/jobs/%d?fields=:all:", jobID)
+	jobQueryResponse := GenericResponse{}
+
+	err := s.doHTTP(ctx, jobURL, "GET", &jobQueryResponse, nil)
+	if err != nil {
+		klog.Errorf("[%s] Error in get job request: %v", utils.GetLoggerId(ctx), err)
+		return Job{}, err
+	}
+	if len(jobQueryResponse.Jobs) == 0 {
+		return Job{}, fmt.Errorf("unable to get Job details for %s: %v", jobURL, jobQueryResponse)
+	}
+	return jobQueryResponse.Jobs[0], nil
+}
+
+// CancelJob cancels a running job
+func (s *SpectrumRestV2) CancelJob(ctx context.Context, jobID uint64) error {
+	klog.V(4).Infof("[%s] rest_v2 CancelJob. jobID: %d", utils.GetLoggerId(ctx), jobID)
+
+	cancelJobURL := fmt.Sprintf("scalemgmt/v2/jobs/%d", jobID)
+	cancelJobResponse := GenericResponse{}
+
+	err := s.doHTTP(ctx, cancelJobURL, "DELETE", &cancelJobResponse, nil)
+	if err != nil {
+		klog.Errorf("[%s] Error in cancel job request: %v", utils.GetLoggerId(ctx), err)
+		return err
+	}
+	return nil
+}
+
 func (s *SpectrumRestV2) AsyncJobCompletion(ctx context.Context, jobURL string) (GenericResponse, error) {
 	klog.V(4).Infof("[%s] rest_v2 AsyncJobCompletion. jobURL: %s", utils.GetLoggerId(ctx), jobURL)
 
@@ -438,11 +471,28 @@ func (s *SpectrumRestV2) CopyDirectoryPath(ctx context.Context, filesystemName s
 }
 
 func (s *SpectrumRestV2) CreateSnapshot(ctx context.Context, filesystemName string, filesetName string, snapshotName string) error {
-	loggerId := GetLoggerId(ctx)
-	klog.V(4).Infof("[%s] rest_v2 CreateSnapshot. filesystem: %s, fileset: %s, snapshot: %v", loggerId, filesystemName, filesetName, snapshotName)
+	klog.V(4).Infof("[%s] rest_v2 CreateSnapshot. filesystem: %s, fileset: %s, snapshot: %v", GetLoggerId(ctx), filesystemName, filesetName, snapshotName)
+
+	snapshotreq := CreateSnapshotRequest{}
+	snapshotreq.SnapshotName = snapshotName
+	return s.createSnapshot(ctx, filesystemName, filesetName, snapshotreq)
+}
+
+// CreateExpiringSnapshot creates a snapshot of a fileset which can not be
+// deleted before its expiration time, in the yyyy-mm-dd-hh:mm:ss format in
+// the timezone of the cluster.
+func (s *SpectrumRestV2) CreateExpiringSnapshot(ctx context.Context, filesystemName string, filesetName string, snapshotName string, expirationTime string) error {
+	klog.V(4).Infof("[%s] rest_v2 CreateExpiringSnapshot. filesystem: %s, fileset: %s, snapshot: %v, expirationTime: %v", GetLoggerId(ctx), filesystemName, filesetName, snapshotName, expirationTime)
 
 	snapshotreq := CreateSnapshotRequest{}
 	snapshotreq.SnapshotName = snapshotName
+	snapshotreq.ExpirationTime = expirationTime
+	return s.createSnapshot(ctx, filesystemName, filesetName, snapshotreq)
+}
+
+func (s *SpectrumRestV2) createSnapshot(ctx context.Context, filesystemName string, filesetName string, snapshotreq CreateSnapshotRequest) error {
+	loggerId := GetLoggerId(ctx)
+	snapshotName := snapshotreq.SnapshotName
 
 	createSnapshotURL := fmt.Sprintf("scalemgmt/v2/filesystems/%s/filesets/%s/snapshots", filesystemName, filesetName)
 	createSnapshotResponse := GenericResponse{}
@@ -549,6 +599,77 @@ func (s *SpectrumRestV2) UpdateFileset(ctx context.Context, filesystemName strin
 	return nil
 }
 
+// setAFMFilesetOpts sets the AFM-DR attributes of a fileset request from the passed opts
+func setAFMFilesetOpts(filesetreq *CreateFilesetRequest, opts map[string]interface{}) {
+	if afmMode, ok := opts[AFMOptMode]; ok {
+		filesetreq.AfmMode = afmMode.(string)
+	}
+	if afmTarget, ok := opts[AFMOptTarget]; ok {
+		filesetreq.AfmTarget = afmTarget.(string)
+	}
+	if afmPrimaryID, ok := opts[AFMOptPrimaryID]; ok {
+		filesetreq.AfmPrimaryID = afmPrimaryID.(string)
+	}
+	if afmRPO, ok := opts[AFMOptRPO]; ok {
+		filesetreq.AfmRPO = afmRPO.(int)
+	}
+}
+
+// FlushCacheFileset starts flushing the queued operations of a cache fileset to its home
+func (s *SpectrumRestV2) FlushCacheFileset(ctx context.Context, filesystemName string, filesetName string) error {
+	klog.V(4).Infof("[%s] rest_v2 FlushCacheFileset. filesystem: %s, fileset: %s", utils.GetLoggerId(ctx), filesystemName, filesetName)
+	return s.runAFMControl(ctx, filesystemName, filesetName, AFMControlRequest{Action: "flushPending"})
+}
+
+// GetAFMCacheState returns the AFM cache state and the queue length of a cache fileset
+func (s *SpectrumRestV2) GetAFMCacheState(ctx context.Context, filesystemName string, filesetName string) (string, int, error) {
+	klog.V(4).Infof("[%s] rest_v2 GetAFMCacheState. filesystem: %s, fileset: %s", utils.GetLoggerId(ctx), filesystemName, filesetName)
+
+	getAFMStateURL := fmt.Sprintf("scalemgmt/v2/filesystems/%s/afm/state?filter=filesetName=%s", filesystemName, filesetName)
+	getAFMStateResponse := GetAFMStateResponse{}
+
+	err := s.doHTTP(ctx, getAFMStateURL, "GET", &getAFMStateResponse, nil)
+	if err != nil {
+		klog.Errorf("[%s] Error in get AFM state request: %v", utils.GetLoggerId(ctx), err)
+		return "", 0, err
+	}
+
+	for _, afmState := range getAFMStateResponse.AFMStates {
+		if afmState.FilesetName == filesetName {
+			return afmState.CacheState, afmState.QueueLength, nil
+		}
+	}
+	klog.Errorf("[%s] No AFM state returned for fileset %s", utils.GetLoggerId(ctx), filesetName)
+	return "", 0, fmt.Errorf("no AFM state returned for fileset %s", filesetName)
+}
+
+func (s *SpectrumRestV2) runAFMControl(ctx context.Context, filesystemName string, filesetName string, afmctlreq AFMControlRequest) error {
+	loggerID := utils.GetLoggerId(ctx)
+	action := afmctlreq.Action
+
+	afmctlURL := fmt.Sprintf("scalemgmt/v2/filesystems/%s/filesets/%s/afmctl", filesystemName, filesetName)
+	afmctlResponse := GenericResponse{}
+
+	err := s.doHTTP(ctx, afmctlURL, "PUT", &afmctlResponse, afmctlreq)
+	if err != nil {
+		klog.Errorf("[%s] Failed to run AFM action %s on fileset %s, error: %v", loggerID, action, filesetName, err)
+		return err
+	}
+
+	err = s.isRequestAccepted(ctx, afmctlResponse, afmctlURL)
+	if err != nil {
+		klog.Errorf("[%s] The AFM action %s request for fileset %s is not accepted for processing, error: %v", loggerID, action, filesetName, err)
+		return err
+	}
+
+	err = s.WaitForJobCompletion(ctx, afmctlResponse.Status.Code, afmctlResponse.Jobs[0].JobID)
+	if err != nil {
+		klog.Errorf("[%s] Failed to run AFM action %s on fileset %s, error: %v", loggerID, action, filesetName, err)
+		return err
+	}
+	return nil
+}
+
 func (s *SpectrumRestV2) CheckIfGatewayNodePresent(ctx context.Context) (bool, error) {
 	loggerId := GetLoggerId(ctx)
 	klog.V(4).Infof("[%s] rest_v2 CheckIfGatewayNodePresent", loggerId)
@@ -568,12 +689,40 @@ func (s *SpectrumRestV2) CheckIfGatewayNodePresent(ctx context.Context) (bool, e
 	return false, nil
 }
 
+// ListNodes returns the admin node name and the network details of the
+// nodes of the cluster.
+func (s *SpectrumRestV2) ListNodes(ctx context.Context) ([]Node_v2, error) {
+	loggerId := GetLoggerId(ctx)
+	klog.V(4).Infof("[%s] rest_v2 ListNodes", loggerId)
+
+	getNodesURL := "scalemgmt/v2/nodes?fields=adminNodeName,network"
+	getNodesResponse := GetNodesResponse_v2{}
+
+	err := s.doHTTP(ctx, getNodesURL, "GET", &getNodesResponse, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes, error: %v", err)
+	}
+	nodes := getNodesResponse.Nodes
+
+	emptyPages := Pages{}
+	for getNodesResponse.Paging != emptyPages {
+		getNodesURL = strings.TrimPrefix(getNodesResponse.Paging.Next, "/")
+		getNodesResponse = GetNodesResponse_v2{}
+		err := s.doHTTP(ctx, getNodesURL, "GET", &getNodesResponse, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list nodes, error: %v", err)
+		}
+		nodes = append(nodes, getNodesResponse.Nodes...)
+	}
+	return nodes, nil
+}
+
 func (s *SpectrumRestV2) CreateFileset(ctx context.Context, filesystemName string, filesetName string, opts map[string]interface{}) error {
 	klog.V(4).Infof("[%s] rest_v2 CreateFileset. filesystem: %s, fileset: %s, opts: %v", utils.GetLoggerId(ctx), filesystemName, filesetName, opts)
 
 	filesetreq := CreateFilesetRequest{}
 	filesetreq.FilesetName = filesetName
-	filesetreq.Comment = FilesetComment
+	filesetreq.Comment = CSIFilesetComment(time.Now())
 
 	filesetType, filesetTypeSpecified := opts[UserSpecifiedFilesetType]
 	inodeLimit, inodeLimitSpecified := opts[UserSpecifiedInodeLimit]
@@ -614,6 +763,10 @@ func (s *SpectrumRestV2) CreateFileset(ctx context.Context, filesystemName strin
 	if permissionsSpecified {
 		filesetreq.Permissions = fmt.Sprintf("%s", permissions)
 	}
+	if iamMode, iamModeSpecified := opts[FilesetOptIAMMode]; iamModeSpecified {
+		filesetreq.IamMode = fmt.Sprintf("%v", iamMode)
+	}
+	setAFMFilesetOpts(&filesetreq, opts)
 
 	createFilesetURL := fmt.Sprintf("scalemgmt/v2/filesystems/%s/filesets", filesystemName)
 	createFilesetResponse := GenericResponse{}
@@ -909,14 +1062,20 @@ func (s *SpectrumRestV2) CheckFilesetWithAFMTarget(ctx context.Context, filesyst
 	return "", nil
 }
 
-func (s *SpectrumRestV2) ListCSIIndependentFilesets(ctx context.Context, filesystemName string) ([]Fileset_v2, error) {
+// ListFilesets lists the filesets of a filesystem selected by the filter. The
+// comment of the filesets created or adopted by the driver is matched here as
+// it carries the time the driver created or adopted the fileset, which the
+// filter of the GUI can not match.
+func (s *SpectrumRestV2) ListFilesets(ctx context.Context, filesystemName string, filter FilesetFilter) ([]Fileset_v2, error) {
 	loggerID := utils.GetLoggerId(ctx)
-	klog.V(4).Infof("[%s] rest_v2 ListCSIIndependentFilesets. filesystem: %s", loggerID, filesystemName)
+	klog.V(4).Infof("[%s] rest_v2 ListFilesets. filesystem: %s, filter: %+v", loggerID, filesystemName, filter)
 
-	encodedFilesetComment := strings.ReplaceAll(FilesetComment, " ", "%20")
 	url := fmt.Sprintf("scalemgmt/v2/filesystems/%s/filesets", filesystemName)
-	filter := fmt.Sprintf("filter=config.isInodeSpaceOwner=true,config.comment=%s", encodedFilesetComment)
-	getFilesetURL := url + "?" + filter
+	query := "fields=config"
+	if filter.IndependentOnly {
+		query += "&filter=config.isInodeSpaceOwner=true"
+	}
+	getFilesetURL := url + "?" + query
 	klog.V(6).Infof("[%s] getFilesetURL [%v] ", loggerID, getFilesetURL)
 	getFilesetResponse := GetFilesetResponse_v2{}
 
@@ -932,7 +1091,7 @@ func (s *SpectrumRestV2) ListCSIIndependentFilesets(ctx context.Context, filesys
 	for getFilesetResponse.Paging != emptyPages {
 		lastID := strconv.Itoa(getFilesetResponse.Paging.LastID)
 
-		getFilesetURL := url + "?lastId=" + lastID + "&" + filter
+		getFilesetURL := url + "?lastId=" + lastID + "&" + query
 		getFilesetResponse = GetFilesetResponse_v2{}
 		klog.V(6).Infof("[%s] getFilesetURL with lastId [%v] ", loggerID, getFilesetURL)
 		err := s.doHTTP(ctx, getFilesetURL, "GET", &getFilesetResponse, nil)
@@ -943,7 +1102,16 @@ func (s *SpectrumRestV2) ListCSIIndependentFilesets(ctx context.Context, filesys
 		filesets = append(filesets, getFilesetResponse.Filesets...)
 	}
 
-	return filesets, nil
+	if !filter.CSIOnly {
+		return filesets, nil
+	}
+	csiFilesets := make([]Fileset_v2, 0, len(filesets))
+	for _, fileset := range filesets {
+		if IsCSIFilesetComment(fileset.Config.Comment) {
+			csiFilesets = append(csiFilesets, fileset)
+		}
+	}
+	return csiFilesets, nil
 }
 
 func (s *SpectrumRestV2) GetFilesetsInodeSpace(ctx context.Context, filesystemName string, inodeSpace int) ([]Fileset_v2, error) {
@@ -1112,6 +1280,35 @@ func (s *SpectrumRestV2) MakeDirectoryV2(ctx context.Context, filesystemName str
 	return nil
 }
 
+func (s *SpectrumRestV2) SetAcl(ctx context.Context, filesystemName string, relativePath string, acl SetAclRequest) error {
+	klog.V(4).Infof("[%s] rest_v2 SetAcl. filesystem: %s, path: %s, acl: %v", utils.GetLoggerId(ctx), filesystemName, relativePath, acl)
+
+	formattedPath := strings.ReplaceAll(relativePath, "/", "%2F")
+	setAclURL := fmt.Sprintf("scalemgmt/v2/filesystems/%s/acl/%s", filesystemName, formattedPath)
+
+	setAclResponse := GenericResponse{}
+
+	err := s.doHTTP(ctx, setAclURL, "PUT", &setAclResponse, acl)
+	if err != nil {
+		klog.Errorf("[%s] Error in set ACL request: %v", utils.GetLoggerId(ctx), err)
+		return err
+	}
+
+	err = s.isRequestAccepted(ctx, setAclResponse, setAclURL)
+	if err != nil {
+		klog.Errorf("[%s] Request not accepted for processing: %v", utils.GetLoggerId(ctx), err)
+		return err
+	}
+
+	err = s.WaitForJobCompletion(ctx, setAclResponse.Status.Code, setAclResponse.Jobs[0].JobID)
+	if err != nil {
+		klog.Errorf("[%s] Unable to set ACL of %s: %v.", utils.GetLoggerId(ctx), relativePath, err)
+		return err
+	}
+
+	return nil
+}
+
 func (s *SpectrumRestV2) SetFilesetQuota(ctx context.Context, filesystemName string, filesetName string, hardLimit string, softLimit string) error {
 	loggerId := GetLoggerId(ctx)
 	klog.V(4).Infof("[%s] rest_v2 SetFilesetQuota. filesystem: %s, fileset: %s, hardLimit: %s, softLimit: %s", loggerId, filesystemName, filesetName, hardLimit, softLimit)
@@ -1178,6 +1375,24 @@ func (s *SpectrumRestV2) IsValidNodeclass(ctx context.Context, nodeclass string)
 	return true, nil
 }
 
+// GetNodeclassMembers returns the member nodes of a nodeclass
+func (s *SpectrumRestV2) GetNodeclassMembers(ctx context.Context, nodeclass string) ([]string, error) {
+	klog.V(4).Infof("[%s] rest_v2 GetNodeclassMembers. nodeclass: %s", utils.GetLoggerId(ctx), nodeclass)
+
+	getNodeclassURL := fmt.Sprintf("scalemgmt/v2/nodeclasses/%s?fields=memberNodes", nodeclass)
+	nodeclassResponse := GetNodeclassResponse{}
+
+	err := s.doHTTP(ctx, getNodeclassURL, "GET", &nodeclassResponse, nil)
+	if err != nil {
+		klog.Errorf("[%s] Error in get nodeclass request: %v", utils.GetLoggerId(ctx), err)
+		return nil, err
+	}
+	if len(nodeclassResponse.Nodeclasses) == 0 {
+		return nil, fmt.Errorf("no nodeclass returned for %s", nodeclass)
+	}
+	return nodeclassResponse.Nodeclasses[0].MemberNodes, nil
+}
+
 func (s *SpectrumRestV2) IsSnapshotSupported(ctx context.Context) (bool, error) {
 	klog.V(4).Infof("[%s] rest_v2 IsSnapshotSupported", utils.GetLoggerId(ctx))
 
@@ -1416,6 +1631,27 @@ func (s *SpectrumRestV2) GetFilesystemDetails(ctx context.Context, filesystemNam
 	return getFilesystemDetailsURLResponse.FileSystems[0], nil
 }
 
+func (s *SpectrumRestV2) IsFilesystemEncrypted(ctx context.Context, filesystemName string) (bool, error) {
+	loggerId := GetLoggerId(ctx)
+	klog.V(4).Infof("[%s] rest_v2 IsFilesystemEncrypted. Name: %s", loggerId, filesystemName)
+
+	getFilesystemDetailsURL := fmt.Sprintf("scalemgmt/v2/filesystems/%s?fields=settings.encryption", filesystemName)
+	getFilesystemDetailsURLResponse := GetFilesystemResponse_v2{}
+
+	err := s.doHTTP(ctx, getFilesystemDetailsURL, "GET", &getFilesystemDetailsURLResponse, nil)
+	if err != nil {
+		klog.Errorf("[%s] Unable to get encryption setting of filesystem %s: %v", loggerId, filesystemName, err)
+		return false, err
+	}
+
+	if len(getFilesystemDetailsURLResponse.FileSystems) == 0 {
+		klog.Errorf("[%s] Unable to fetch filesystem details for %s", loggerId, filesystemName)
+		return false, fmt.Errorf("unable to fetch filesystem details for %s", filesystemName)
+	}
+
+	return getFilesystemDetailsURLResponse.FileSystems[0].Settings.Encryption, nil
+}
+
 func (s *SpectrumRestV2) GetFsUid(ctx context.Context, filesystemName string) (string, error) {
 	klog.V(4).Infof("rest_v2 GetFsUid. filesystem: %s", filesystemName)
 
@@ -1624,6 +1860,24 @@ func (s *SpectrumRestV2) GetSnapshotCreateTimestamp(ctx context.Context, filesys
 	return fmt.Sprintf(getSnapshotResponse.Snapshots[0].Created), nil
 }
 
+func (s *SpectrumRestV2) GetSnapshotExpirationTime(ctx context.Context, filesystemName string, filesetName string, snapName string) (string, error) {
+	klog.V(4).Infof("[%s] rest_v2 GetSnapshotExpirationTime. filesystem: %s, fileset: %s, snapshot: %s ", utils.GetLoggerId(ctx), filesystemName, filesetName, snapName)
+
+	getSnapshotURL := fmt.Sprintf("scalemgmt/v2/filesystems/%s/filesets/%s/snapshots/%s?fields=:all:", filesystemName, filesetName, snapName)
+	getSnapshotResponse := GetSnapshotResponse_v2{}
+
+	err := s.doHTTP(ctx, getSnapshotURL, "GET", &getSnapshotResponse, nil)
+	if err != nil {
+		return "", fmt.Errorf("unable to list snapshot %v", snapName)
+	}
+
+	if len(getSnapshotResponse.Snapshots) == 0 {
+		return "", fmt.Errorf("unable to list snapshot %v", snapName)
+	}
+
+	return getSnapshotResponse.Snapshots[0].ExpirationTime, nil
+}
+
 //nolint:dupl
 func (s *SpectrumRestV2) GetSnapshotUid(ctx context.Context, filesystemName string, filesetName string, snapName string) (string, error) {
 	klog.V(4).Infof("[%s] rest_v2 GetSnapshotUid. filesystem: %s, fileset: %s, snapshot: %s ", utils.GetLoggerId(ctx), filesystemName, filesetName, snapName)
@@ -1678,6 +1932,23 @@ func (s *SpectrumRestV2) ListFilesetSnapshots(ctx context.Context, filesystemNam
 	return listFilesetSnapshotResponse.Snapshots, nil
 }
 
+// ListFilesystemSnapshots lists the snapshots of all filesets of a filesystem
+// with the given name.
+func (s *SpectrumRestV2) ListFilesystemSnapshots(ctx context.Context, filesystemName string, snapshotName string) ([]Snapshot_v2, error) {
+	loggerId := GetLoggerId(ctx)
+	klog.V(4).Infof("[%s] rest_v2 ListFilesystemSnapshots. filesystem: %s, snapshot: %s", loggerId, filesystemName, snapshotName)
+
+	listSnapshotURL := fmt.Sprintf("scalemgmt/v2/filesystems/%s/snapshots?filter=snapshotName=%s", filesystemName, snapshotName)
+	listSnapshotResponse := GetSnapshotResponse_v2{}
+
+	err := s.doHTTP(ctx, listSnapshotURL, "GET", &listSnapshotResponse, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list snapshots [%v] of filesystem %v. Error [%v]", snapshotName, filesystemName, err)
+	}
+
+	return listSnapshotResponse.Snapshots, nil
+}
+
 func (s *SpectrumRestV2) CheckIfFileDirPresent(ctx context.Context, filesystemName string, relPath string) (bool, error) {
 	klog.V(4).Infof("[%s] rest_v2 CheckIfFileDirPresent. filesystem: %s, path: %s", utils.GetLoggerId(ctx), filesystemName, relPath)
 
@@ -1747,6 +2018,21 @@ func (s *SpectrumRestV2) IsNodeComponentHealthy(ctx context.Context, nodeName st
 	return true, nil
 }
 
+func (s *SpectrumRestV2) GetNodeHealthStates(ctx context.Context, nodeName string, component string) ([]State, error) {
+	loggerId := GetLoggerId(ctx)
+	klog.V(4).Infof("[%s] rest_v2 GetNodeHealthStates, nodeName: %s, component: %s", loggerId, nodeName, component)
+
+	getNodeHealthStatesURL := fmt.Sprintf("scalemgmt/v2/nodes/%s/health/states?filter=component=%s", nodeName, component)
+	getNodeHealthStatesResponse := GetNodeHealthStatesResponse_v2{}
+
+	err := s.doHTTP(ctx, getNodeHealthStatesURL, "GET", &getNodeHealthStatesResponse, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get health states of component %v for nodename %v: %v", component, nodeName, err)
+	}
+
+	return getNodeHealthStatesResponse.States, nil
+}
+
 func (s *SpectrumRestV2) SetFilesystemPolicy(ctx context.Context, policy *Policy, filesystemName string) error {
 	loggerId := GetLoggerId(ctx)
 	klog.V(4).Infof("[%s] rest_v2 setFilesystemPolicy for filesystem %s", loggerId, filesystemName)
@@ -1769,6 +2055,34 @@ func (s *SpectrumRestV2) SetFilesystemPolicy(ctx context.Context, policy *Policy
 	return nil
 }
 
+func (s *SpectrumRestV2) DeletePolicyPartition(ctx context.Context, partitionName string, filesystemName string) error {
+	loggerId := GetLoggerId(ctx)
+	klog.V(4).Infof("[%s] rest_v2 DeletePolicyPartition. name %s, filesystem %s", loggerId, partitionName, filesystemName)
+
+	partitionURL := fmt.Sprintf("scalemgmt/v2/filesystems/%s/partition/%s", filesystemName, partitionName)
+	deletePartitionResponse := GenericResponse{}
+
+	err := s.doHTTP(ctx, partitionURL, "DELETE", &deletePartitionResponse, nil)
+	if err != nil {
+		klog.Errorf("[%s] unable to delete policy partition %s of filesystem %s: %v", loggerId, partitionName, filesystemName, err)
+		return err
+	}
+
+	err = s.isRequestAccepted(ctx, deletePartitionResponse, partitionURL)
+	if err != nil {
+		klog.Errorf("[%s] request not accepted for processing: %v", loggerId, err)
+		return err
+	}
+
+	err = s.WaitForJobCompletion(ctx, deletePartitionResponse.Status.Code, deletePartitionResponse.Jobs[0].JobID)
+	if err != nil {
+		klog.Errorf("[%s] deleting policy partition %s of filesystem %s failed with error %v", loggerId, partitionName, filesystemName, err)
+		return err
+	}
+
+	return nil
+}
+
 func (s *SpectrumRestV2) DoesTierExist(ctx context.Context, tierName string, filesystemName string) error {
 	loggerId := GetLoggerId(ctx)
 	klog.V(4).Infof("[%s] rest_v2 DoesTierExist. name %s, filesystem %s", loggerId, tierName, filesystemName)
@@ -21,7 +21,10 @@ import (
 	"math"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -36,6 +39,10 @@ import (
 	"golang.org/x/net/context"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 )
 
@@ -62,7 +69,17 @@ const (
 	pvcNameKey      = "csi.storage.k8s.io/pvc/name"
 	pvcNamespaceKey = "csi.storage.k8s.io/pvc/namespace"
 
+	// copyInProgressReason is the reason of the events of the PVCs of the
+	// volumes whose content is being copied
+	copyInProgressReason = "VolumeContentCopyInProgress"
+
 	defaultS3Port = "443"
+
+	// Secret key of the paths to prefetch when a cache volume is created
+	cachePrefetchKey = "prefetch"
+	// PVC annotation with the paths of a cache volume to prefetch, which is
+	// handled by the operator
+	cachePrefetchAnnotation = "csi.ibm.com/cache-prefetch"
 )
 
 var bucketLock = make(map[string]bool)
@@ -287,7 +304,7 @@ func (cs *ScaleControllerServer) validateCG(ctx context.Context, scVol *scaleVol
 	loggerId := utils.GetLoggerId(ctx)
 	klog.V(4).Infof("[%s] Validate CG for volume [%v]", loggerId, scVol)
 
-	fsetlist, err := scVol.Connector.ListCSIIndependentFilesets(ctx, scVol.VolBackendFs)
+	fsetlist, err := scVol.Connector.ListFilesets(ctx, scVol.VolBackendFs, connectors.FilesetFilter{IndependentOnly: true, CSIOnly: true})
 	if err != nil {
 		return "", err
 	}
@@ -461,6 +478,18 @@ func (cs *ScaleControllerServer) createFilesetBasedVol(ctx context.Context, scVo
 		if scVol.ParentFileset != "" {
 			opt[connectors.UserSpecifiedParentFset] = scVol.ParentFileset
 		}
+		if iamMode, ok := immutabilityToIAMMode[scVol.Immutability]; ok {
+			opt[connectors.FilesetOptIAMMode] = iamMode
+		}
+
+		var peerConn connectors.SpectrumScaleConnector
+		var peerFsDetails connectors.FileSystem_v2
+		if scVol.ReplicationPeer != nil {
+			peerConn, peerFsDetails, err = cs.setReplicationPrimaryOpts(ctx, scVol, opt)
+			if err != nil {
+				return "", err
+			}
+		}
 
 		// Create fileset
 		klog.Infof("[%s] creating fileset for classic storageClass with fileset name: [%v]", loggerId, scVol.VolName)
@@ -470,6 +499,20 @@ func (cs *ScaleControllerServer) createFilesetBasedVol(ctx context.Context, scVo
 			klog.Errorf("[%s] volume:[%v] - failed to create fileset [%v] in filesystem [%v]. Error: %v", loggerId, scVol.VolName, scVol.VolName, scVol.VolBackendFs, err)
 			return "", err
 		}
+
+		if scVol.RetentionDays > 0 {
+			err = createRetentionSnapshot(ctx, scVol)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		if scVol.ReplicationPeer != nil {
+			err = cs.createReplicationSecondary(ctx, scVol, peerConn, peerFsDetails, opt[connectors.UserSpecifiedInodeLimit])
+			if err != nil {
+				return "", err
+			}
+		}
 		klog.Infof("[%s] finished creation of fileset for classic storageClass with fileset name: [%v]", loggerId, scVol.VolName)
 		return filesetPath, nil
 	}
@@ -484,17 +527,24 @@ func (cs *ScaleControllerServer) createFilesetVol(ctx context.Context, scVol *sc
 			var fseterr error
 			if scVol.VolumeType == cacheVolume {
 				endpoint := bucketInfo[connectors.BucketEndpoint]
-				parsedURL, err := url.Parse(endpoint)
+				afmTarget, isFileHome, err := getFileCacheHomeTarget(endpoint)
 				if err != nil {
 					klog.Errorf("[%s] volume:[%v] - failed to parse the endpoint URL [%s]. Error: [%v]", loggerId, volName, endpoint, err)
 					return "", status.Error(codes.Internal, fmt.Sprintf("volume:[%v] - failed to parse the endpoint URL [%s]. Error: [%v]", volName, endpoint, err))
 				}
-				if parsedURL.Port() == "" {
-					endpoint += ":" + string(defaultS3Port)
+				var scheme string
+				if !isFileHome {
+					parsedURL, err := url.Parse(endpoint)
+					if err != nil {
+						klog.Errorf("[%s] volume:[%v] - failed to parse the endpoint URL [%s]. Error: [%v]", loggerId, volName, endpoint, err)
+						return "", status.Error(codes.Internal, fmt.Sprintf("volume:[%v] - failed to parse the endpoint URL [%s]. Error: [%v]", volName, endpoint, err))
+					}
+					if parsedURL.Port() == "" {
+						endpoint += ":" + string(defaultS3Port)
+					}
+					afmTarget = endpoint + "/" + bucketInfo[connectors.BucketName]
+					scheme = parsedURL.Scheme
 				}
-				afmTarget := endpoint + "/" + bucketInfo[connectors.BucketName]
-
-				scheme := parsedURL.Scheme
 				lockSuccess := lockBucket(loggerId, volName, afmTarget)
 				if !lockSuccess {
 					klog.Errorf("[%s] volume:[%v] - the bucket [%s] is already locked for another volume creation", loggerId, volName, afmTarget)
@@ -516,23 +566,33 @@ func (cs *ScaleControllerServer) createFilesetVol(ctx context.Context, scVol *sc
 					return "", status.Error(codes.Internal, fmt.Sprintf("failed to create an AFM cache fileset [%v] in filesystem [%v] as another fileset [%v] with the same bucket [%v] exists already", volName, scVol.VolBackendFs, filesetWitAFMTarget, afmTarget))
 				}
 
-				// Set bucket keys for a cache volume
-				keyerr := scVol.Connector.SetBucketKeys(ctx, bucketInfo)
-				if keyerr != nil {
-					klog.Errorf("[%s] failed to set bucket keys for volume %s", loggerId, volName)
-					return "", status.Error(codes.Internal, fmt.Sprintf("failed to set bucket keys for volume %s, error: %v", volName, keyerr))
-				}
+				if isFileHome {
+					// An NFS or GPFS home is cached by a regular AFM fileset
+					opt[connectors.AFMOptMode] = scVol.CacheMode
+					opt[connectors.AFMOptTarget] = afmTarget
+					if cacheFsetErr := scVol.Connector.CreateFileset(ctx, scVol.VolBackendFs, volName, opt); cacheFsetErr != nil {
+						klog.Errorf("[%s] volume:[%v] - failed to create cache fileset [%v] in filesystem [%v]. Error: %v", loggerId, volName, volName, scVol.VolBackendFs, cacheFsetErr.Error())
+						return "", status.Error(codes.Internal, fmt.Sprintf("failed to create cache fileset [%v] in filesystem [%v]. Error: %v", volName, scVol.VolBackendFs, cacheFsetErr.Error()))
+					}
+				} else {
+					// Set bucket keys for a cache volume
+					keyerr := scVol.Connector.SetBucketKeys(ctx, bucketInfo)
+					if keyerr != nil {
+						klog.Errorf("[%s] failed to set bucket keys for volume %s", loggerId, volName)
+						return "", status.Error(codes.Internal, fmt.Sprintf("failed to set bucket keys for volume %s, error: %v", volName, keyerr))
+					}
 
-				// Create a cache fileset
-				if cacheFsetErr := scVol.Connector.CreateS3CacheFileset(ctx, scVol.VolBackendFs, volName, scVol.CacheMode, opt, bucketInfo, scheme); cacheFsetErr != nil {
-					klog.Errorf("[%s] volume:[%v] - failed to create cache fileset [%v] in filesystem [%v]. Error: %v", loggerId, volName, volName, scVol.VolBackendFs, cacheFsetErr.Error())
-					return "", status.Error(codes.Internal, fmt.Sprintf("failed to create cache fileset [%v] in filesystem [%v]. Error: %v", volName, scVol.VolBackendFs, cacheFsetErr.Error()))
-				}
+					// Create a cache fileset
+					if cacheFsetErr := scVol.Connector.CreateS3CacheFileset(ctx, scVol.VolBackendFs, volName, scVol.CacheMode, opt, bucketInfo, scheme); cacheFsetErr != nil {
+						klog.Errorf("[%s] volume:[%v] - failed to create cache fileset [%v] in filesystem [%v]. Error: %v", loggerId, volName, volName, scVol.VolBackendFs, cacheFsetErr.Error())
+						return "", status.Error(codes.Internal, fmt.Sprintf("failed to create cache fileset [%v] in filesystem [%v]. Error: %v", volName, scVol.VolBackendFs, cacheFsetErr.Error()))
+					}
 
-				// For cache fileset, add a comment as the create COS fileset
-				// interface doesn't allow setting the fileset comment.
-				if err := handleUpdateComment(ctx, scVol); err != nil {
-					return "", err
+					// For cache fileset, add a comment as the create COS fileset
+					// interface doesn't allow setting the fileset comment.
+					if err := handleUpdateComment(ctx, scVol); err != nil {
+						return "", err
+					}
 				}
 			} else {
 				// This means fileset is not present, create it
@@ -557,7 +617,7 @@ func (cs *ScaleControllerServer) createFilesetVol(ctx context.Context, scVol *sc
 		}
 	} else {
 		// fileset is present. Confirm if creator is IBM Storage Scale CSI driver and fileset type is correct.
-		if filesetInfo.Config.Comment != connectors.FilesetComment {
+		if !connectors.IsCSIFilesetComment(filesetInfo.Config.Comment) {
 			if scVol.VolumeType == cacheVolume {
 				if err := handleUpdateComment(ctx, scVol); err != nil {
 					return "", err
@@ -639,6 +699,119 @@ func (cs *ScaleControllerServer) createFilesetVol(ctx context.Context, scVol *sc
 	return targetBasePath, nil
 }
 
+// setReplicationPrimaryOpts validates the AFM-DR peer of a replicated volume and
+// sets the options to create the volume fileset as an AFM-DR primary. It returns
+// the connector and the filesystem details of the peer cluster.
+func (cs *ScaleControllerServer) setReplicationPrimaryOpts(ctx context.Context, scVol *scaleVolume, opt map[string]interface{}) (connectors.SpectrumScaleConnector, connectors.FileSystem_v2, error) {
+	loggerId := utils.GetLoggerId(ctx)
+	peer := scVol.ReplicationPeer
+	var peerFsDetails connectors.FileSystem_v2
+
+	if peer.ClusterId == scVol.ClusterId {
+		return nil, peerFsDetails, status.Error(codes.InvalidArgument, fmt.Sprintf("volume:[%v] - replication cluster [%v] must be different from the cluster owning the volume filesystem", scVol.VolName, peer.ClusterId))
+	}
+	peerConn, err := cs.getConnFromClusterID(ctx, peer.ClusterId)
+	if err != nil {
+		return nil, peerFsDetails, err
+	}
+
+	// AFM-DR needs a gateway node on both the primary and the secondary cluster
+	for clusterId, conn := range map[string]connectors.SpectrumScaleConnector{scVol.ClusterId: scVol.Connector, peer.ClusterId: peerConn} {
+		gatewayPresent, err := conn.CheckIfGatewayNodePresent(ctx)
+		if err != nil {
+			return nil, peerFsDetails, status.Error(codes.Internal, fmt.Sprintf("failed to check gateway nodes of cluster [%v]. Error: %v", clusterId, err))
+		}
+		if !gatewayPresent {
+			klog.Errorf("[%s] volume:[%v] - there is no gateway node in cluster [%v]", loggerId, scVol.VolName, clusterId)
+			return nil, peerFsDetails, status.Error(codes.Aborted, fmt.Sprintf("failed to create a replicated volume as there is no gateway node in cluster [%v]", clusterId))
+		}
+	}
+
+	peerFsDetails, err = peerConn.GetFilesystemDetails(ctx, peer.VolBackendFs)
+	if err != nil {
+		klog.Errorf("[%s] volume:[%v] - unable to get details of filesystem [%v] in replication cluster [%v]. Error: %v", loggerId, scVol.VolName, peer.VolBackendFs, peer.ClusterId, err)
+		return nil, peerFsDetails, status.Error(codes.Internal, fmt.Sprintf("unable to get details of filesystem [%v] in replication cluster [%v]. Error: %v", peer.VolBackendFs, peer.ClusterId, err))
+	}
+	if peerFsDetails.Type == filesystemTypeRemote {
+		return nil, peerFsDetails, status.Error(codes.InvalidArgument, fmt.Sprintf("filesystem [%v] is not local to replication cluster [%v]", peer.VolBackendFs, peer.ClusterId))
+	}
+	if peerFsDetails.Mount.Status != filesystemMounted {
+		return nil, peerFsDetails, status.Error(codes.Internal, fmt.Sprintf("filesystem [%v] in replication cluster [%v] is not mounted", peer.VolBackendFs, peer.ClusterId))
+	}
+
+	// The secondary fileset is linked at the same relative path in the peer
+	// filesystem. Without an NFS target host, the gateway nodes of the primary
+	// cluster reach it over the GPFS protocol, which expects the peer filesystem
+	// to be remotely mounted at the same mount point on the primary cluster.
+	secondaryPath := fmt.Sprintf("%s/%s", peerFsDetails.Mount.MountPoint, scVol.VolName)
+	afmTarget := "gpfs://" + secondaryPath
+	if peer.TargetHost != "" {
+		afmTarget = fmt.Sprintf("nfs://%s%s", peer.TargetHost, secondaryPath)
+	}
+
+	opt[connectors.AFMOptMode] = connectors.AFMModePrimary
+	opt[connectors.AFMOptTarget] = afmTarget
+	if peer.RPO != 0 {
+		opt[connectors.AFMOptRPO] = peer.RPO
+	}
+	klog.Infof("[%s] volume:[%v] - fileset will be created as AFM-DR primary with target [%v]", loggerId, scVol.VolName, afmTarget)
+	return peerConn, peerFsDetails, nil
+}
+
+// createReplicationSecondary creates and links the AFM-DR secondary fileset of a
+// replicated volume on the peer cluster. The secondary fileset is not deleted
+// along with the volume, it is left on the peer cluster for disaster recovery.
+func (cs *ScaleControllerServer) createReplicationSecondary(ctx context.Context, scVol *scaleVolume, peerConn connectors.SpectrumScaleConnector, peerFsDetails connectors.FileSystem_v2, inodeLimit interface{}) error {
+	loggerId := utils.GetLoggerId(ctx)
+	peer := scVol.ReplicationPeer
+	volName := scVol.VolName
+
+	primaryInfo, err := scVol.Connector.ListFileset(ctx, scVol.VolBackendFs, volName)
+	if err != nil {
+		klog.Errorf("[%s] volume:[%v] - unable to list fileset [%v] in filesystem [%v]. Error: %v", loggerId, volName, volName, scVol.VolBackendFs, err)
+		return status.Error(codes.Internal, fmt.Sprintf("unable to list fileset [%v] in filesystem [%v]. Error: %v", volName, scVol.VolBackendFs, err))
+	}
+	if primaryInfo.AFM.AFMMode != connectors.AFMModePrimary || primaryInfo.AFM.AFMPrimaryID == "" {
+		klog.Errorf("[%s] volume:[%v] - fileset [%v] is not an AFM-DR primary, AFM mode: [%v]", loggerId, volName, volName, primaryInfo.AFM.AFMMode)
+		return status.Error(codes.Internal, fmt.Sprintf("fileset [%v] in filesystem [%v] is not an AFM-DR primary", volName, scVol.VolBackendFs))
+	}
+
+	secondaryInfo, err := peerConn.ListFileset(ctx, peer.VolBackendFs, volName)
+	if err != nil {
+		if !strings.Contains(err.Error(), "Invalid value in 'filesetName'") {
+			klog.Errorf("[%s] volume:[%v] - unable to list fileset [%v] in filesystem [%v] of replication cluster [%v]. Error: %v", loggerId, volName, volName, peer.VolBackendFs, peer.ClusterId, err)
+			return status.Error(codes.Internal, fmt.Sprintf("unable to list fileset [%v] in filesystem [%v] of replication cluster [%v]. Error: %v", volName, peer.VolBackendFs, peer.ClusterId, err))
+		}
+		opt := make(map[string]interface{})
+		opt[connectors.UserSpecifiedFilesetType] = independentFileset
+		opt[connectors.UserSpecifiedInodeLimit] = inodeLimit
+		opt[connectors.AFMOptMode] = connectors.AFMModeSecondary
+		opt[connectors.AFMOptPrimaryID] = primaryInfo.AFM.AFMPrimaryID
+		klog.Infof("[%s] volume:[%v] - creating AFM-DR secondary fileset in filesystem [%v] of replication cluster [%v]", loggerId, volName, peer.VolBackendFs, peer.ClusterId)
+		if err = peerConn.CreateFileset(ctx, peer.VolBackendFs, volName, opt); err != nil {
+			klog.Errorf("[%s] volume:[%v] - unable to create AFM-DR secondary fileset in filesystem [%v] of replication cluster [%v]. Error: %v", loggerId, volName, peer.VolBackendFs, peer.ClusterId, err)
+			return status.Error(codes.Internal, fmt.Sprintf("unable to create AFM-DR secondary fileset [%v] in filesystem [%v] of replication cluster [%v]. Error: %v", volName, peer.VolBackendFs, peer.ClusterId, err))
+		}
+		secondaryInfo, err = peerConn.ListFileset(ctx, peer.VolBackendFs, volName)
+		if err != nil {
+			return status.Error(codes.Internal, fmt.Sprintf("unable to list newly created fileset [%v] in filesystem [%v] of replication cluster [%v]. Error: %v", volName, peer.VolBackendFs, peer.ClusterId, err))
+		}
+	} else if !connectors.IsCSIFilesetComment(secondaryInfo.Config.Comment) || secondaryInfo.AFM.AFMPrimaryID != primaryInfo.AFM.AFMPrimaryID {
+		klog.Errorf("[%s] volume:[%v] - fileset [%v] in replication cluster [%v] is not the AFM-DR secondary of the volume", loggerId, volName, volName, peer.ClusterId)
+		return status.Error(codes.Internal, fmt.Sprintf("fileset [%v] in filesystem [%v] of replication cluster [%v] exists and is not the AFM-DR secondary of the volume", volName, peer.VolBackendFs, peer.ClusterId))
+	}
+
+	if secondaryInfo.Config.Path == "" || secondaryInfo.Config.Path == filesetUnlinkedPath {
+		junctionPath := fmt.Sprintf("%s/%s", peerFsDetails.Mount.MountPoint, volName)
+		if err = peerConn.LinkFileset(ctx, peer.VolBackendFs, volName, junctionPath); err != nil {
+			klog.Errorf("[%s] volume:[%v] - linking fileset [%v] in filesystem [%v] of replication cluster [%v] at path [%v] failed. Error: %v", loggerId, volName, volName, peer.VolBackendFs, peer.ClusterId, junctionPath, err)
+			return status.Error(codes.Internal, fmt.Sprintf("linking fileset [%v] in filesystem [%v] of replication cluster [%v] at path [%v] failed. Error: %v", volName, peer.VolBackendFs, peer.ClusterId, junctionPath, err))
+		}
+	}
+	klog.Infof("[%s] volume:[%v] - AFM-DR secondary fileset is ready in filesystem [%v] of replication cluster [%v]", loggerId, volName, peer.VolBackendFs, peer.ClusterId)
+	return nil
+}
+
 func handleUpdateComment(ctx context.Context, scVol *scaleVolume) error {
 	loggerId := utils.GetLoggerId(ctx)
 	volName := scVol.VolName
@@ -672,7 +845,7 @@ func (cs *ScaleControllerServer) getVolumeSizeInBytes(req *csi.CreateVolumeReque
 
 func updateComment(ctx context.Context, scVol *scaleVolume) error {
 	updateOpts := make(map[string]interface{})
-	updateOpts[connectors.FilesetComment] = connectors.FilesetComment
+	updateOpts[connectors.FilesetComment] = connectors.CSIFilesetComment(time.Now())
 	return scVol.Connector.UpdateFileset(ctx, scVol.VolBackendFs, scVol.VolName, updateOpts)
 }
 
@@ -699,7 +872,10 @@ func checkSCSupportedParams(params map[string]string) (string, bool) {
 			"volBackendFs", "volDirBasePath", "uid", "gid", "permissions",
 			"clusterId", "filesetType", "parentFileset", "inodeLimit", "nodeClass",
 			"version", "tier", "compression", "consistencyGroup", "shared",
-			"volumeType", "cacheMode":
+			"volumeType", "cacheMode", "replicationClusterId", "replicationVolBackendFs",
+			"replicationRPO", "replicationTargetHost", "cachePrefetch", "cloneMode",
+			"migrationPool", "migrationSourcePool", "migrationAccessDays", "migrationThreshold",
+			"encryption", "immutability", "retentionPeriod", "aclTemplate", "aclTemplateConfigMap":
 			// These are valid parameters, do nothing here
 		default:
 			invalidParams = append(invalidParams, k)
@@ -882,7 +1058,7 @@ func (cs *ScaleControllerServer) CreateVolume(ctx context.Context, req *csi.Crea
 	}
 
 	if isSnapSource {
-		err = cs.validateSnapId(ctx, scaleVol, &snapIdMembers, scaleVol, assembledScaleversion)
+		err = cs.validateSnapId(ctx, scaleVol, &snapIdMembers, scaleVol, volFsInfo, assembledScaleversion)
 		if err != nil {
 			klog.Errorf("[%s] volume:[%v] - Error in source snapshot validation [%v]", loggerId, volName, err)
 			return nil, err
@@ -905,10 +1081,10 @@ func (cs *ScaleControllerServer) CreateVolume(ctx context.Context, req *csi.Crea
 			return nil, err
 		}
 
-		if isCGVolume {
-			shallowCopyTargetPath = fmt.Sprintf("%s/%s/.snapshots/%s/%s", volFsInfo.Mount.MountPoint, snapIdMembers.ConsistencyGroup, snapIdMembers.SnapName, snapIdMembers.FsetName)
-		} else {
-			shallowCopyTargetPath = fmt.Sprintf("%s/%s/.snapshots/%s/%s", volFsInfo.Mount.MountPoint, snapIdMembers.FsetName, snapIdMembers.SnapName, snapIdMembers.Path)
+		shallowCopyTargetPath, err = getShallowCopyTargetPath(ctx, scaleVol.Connector, volFsInfo, &snapIdMembers, isCGVolume)
+		if err != nil {
+			klog.Errorf("[%s] volume:[%v] - %v", loggerId, volName, err)
+			return nil, status.Error(codes.Internal, err.Error())
 		}
 
 		volID, volIDErr := cs.generateVolID(ctx, scaleVol, volFsInfo.UUID, isCGVolume, isShallowCopyVolume, shallowCopyTargetPath)
@@ -941,6 +1117,20 @@ func (cs *ScaleControllerServer) CreateVolume(ctx context.Context, req *csi.Crea
 
 	}
 
+	if scaleVol.Migration != nil {
+		err = cs.checkMigrationPools(ctx, scaleVol, volFsInfo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if scaleVol.Encryption != "" {
+		err = checkFilesystemEncryption(ctx, scaleVol)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	volReqInProcess, err := cs.IfSameVolReqInProcess(scaleVol)
 	if err != nil {
 		return nil, err
@@ -965,6 +1155,20 @@ func (cs *ScaleControllerServer) CreateVolume(ctx context.Context, req *csi.Crea
 		}
 	}
 
+	// Render the ACL template before creating the volume, so that an invalid
+	// template or a missing ConfigMap does not leave a volume behind
+	var volumeACL *connectors.SetAclRequest
+	if scaleVol.ACLTemplate != "" || scaleVol.ACLTemplateConfigMap != "" {
+		reqParams := req.GetParameters()
+		vars := aclTemplateVars{PVCNamespace: reqParams[pvcNamespaceKey], PVCName: reqParams[pvcNameKey], PVName: volName}
+		acl, err := getVolumeACL(ctx, scaleVol, vars)
+		if err != nil {
+			klog.Errorf("[%s] volume:[%v] - invalid ACL template. Error: %v", loggerId, volName, err)
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("invalid ACL template for volume %s: %v", volName, err))
+		}
+		volumeACL = &acl
+	}
+
 	/* Update driver map with new volume. Make sure to defer delete */
 
 	cs.Driver.reqmap[scaleVol.VolName] = int64(scaleVol.VolSize)
@@ -979,7 +1183,9 @@ func (cs *ScaleControllerServer) CreateVolume(ctx context.Context, req *csi.Crea
 			reqParams := req.GetParameters()
 			return nil, status.Error(codes.Aborted, fmt.Sprintf("The secret %s/%s-secret does not have required parameter(s): %v", reqParams[pvcNamespaceKey], reqParams[pvcNameKey], missingKeys))
 		}
-
+		if _, _, err := getFileCacheHomeTarget(req.Secrets[connectors.BucketEndpoint]); err != nil {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("The endpoint in the cache volume secret is not valid. Error: %v", err))
+		}
 		// A gateway node is must for cache fileset, return error if no gateway found
 		gatewayPresent, err := scaleVol.Connector.CheckIfGatewayNodePresent(ctx)
 		if err != nil {
@@ -1000,6 +1206,36 @@ func (cs *ScaleControllerServer) CreateVolume(ctx context.Context, req *csi.Crea
 		return nil, err
 	}
 
+	if scaleVol.Encryption != "" {
+		err = setEncryptionPolicy(ctx, scaleVol)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if scaleVol.Migration != nil {
+		err = setMigrationPolicy(ctx, scaleVol)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if scaleVol.VolumeType == cacheVolume {
+		// The paths in the secret take precedence over the storageClass
+		prefetch := scaleVol.CachePrefetch
+		if secretPrefetch, ok := req.Secrets[cachePrefetchKey]; ok {
+			prefetch = secretPrefetch
+		}
+		if prefetch != "" {
+			reqParams := req.GetParameters()
+			err = requestCachePrefetch(ctx, reqParams[pvcNamespaceKey], reqParams[pvcNameKey], prefetch)
+			if err != nil {
+				klog.Errorf("[%s] volume:[%v] - failed to request the prefetch of paths [%v] of the cache volume. Error: %v", loggerId, scaleVol.VolName, prefetch, err)
+				return nil, err
+			}
+		}
+	}
+
 	if !isCGVolume && scaleVol.VolumeType != cacheVolume {
 		// Create symbolic link if not present
 		err = cs.createSoftlink(ctx, scaleVol, targetPath)
@@ -1008,6 +1244,15 @@ func (cs *ScaleControllerServer) CreateVolume(ctx context.Context, req *csi.Crea
 		}
 	}
 
+	// The ACL of a clone or a restored volume is set after the copy, which
+	// overwrites the ACL of the root directory with the one of the source
+	if volumeACL != nil && !isVolSource && !isSnapSource {
+		err = setVolumeACL(ctx, scaleVol, *volumeACL, targetPath)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+
 	volID, volIDErr := cs.generateVolID(ctx, scaleVol, volFsInfo.UUID, isCGVolume, isShallowCopyVolume, targetPath)
 	if volIDErr != nil {
 		return nil, volIDErr
@@ -1037,11 +1282,18 @@ func (cs *ScaleControllerServer) CreateVolume(ctx context.Context, req *csi.Crea
 		}
 	}
 
+	if volumeACL != nil && (isVolSource || isSnapSource) {
+		err = setVolumeACL(ctx, scaleVol, *volumeACL, targetPath)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+
 	return &csi.CreateVolumeResponse{
 		Volume: &csi.Volume{
 			VolumeId:      volID,
 			CapacityBytes: int64(scaleVol.VolSize),
-			VolumeContext: req.GetParameters(),
+			VolumeContext: cs.getVolumeContext(req, scaleVol.VolName),
 			ContentSource: volSrc,
 		},
 	}, nil
@@ -1049,6 +1301,10 @@ func (cs *ScaleControllerServer) CreateVolume(ctx context.Context, req *csi.Crea
 
 func validateCacheSecret(secretData map[string]string) []string {
 	requiredKeys := []string{"endpoint", "bucket", "accesskey", "secretkey"}
+	// An NFS or GPFS home needs only the endpoint
+	if _, isFileHome, err := getFileCacheHomeTarget(secretData[connectors.BucketEndpoint]); err == nil && isFileHome {
+		requiredKeys = []string{"endpoint"}
+	}
 	missingKeys := []string{}
 	for _, key := range requiredKeys {
 		if _, exists := secretData[key]; !exists {
@@ -1058,6 +1314,130 @@ func validateCacheSecret(secretData map[string]string) []string {
 	return missingKeys
 }
 
+// requestCachePrefetch annotates the PVC of a new cache volume with the paths
+// to prefetch. The prefetch can take long, so it is run by the operator once
+// the PVC is bound, and its outcome is recorded as an event on the PVC.
+func requestCachePrefetch(ctx context.Context, pvcNamespace string, pvcName string, paths string) error {
+	loggerId := utils.GetLoggerId(ctx)
+	if pvcNamespace == "" || pvcName == "" {
+		klog.Warningf("[%s] CreateVolume - the PVC of the cache volume is not known, paths [%v] are not prefetched", loggerId, paths)
+		return nil
+	}
+	client, err := getKubeClient()
+	if err != nil {
+		return status.Error(codes.Internal, fmt.Sprintf("failed to create Kubernetes client: %v", err))
+	}
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pvc, err := client.CoreV1().PersistentVolumeClaims(pvcNamespace).Get(ctx, pvcName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if pvc.Annotations == nil {
+			pvc.Annotations = make(map[string]string)
+		}
+		pvc.Annotations[cachePrefetchAnnotation] = paths
+		_, err = client.CoreV1().PersistentVolumeClaims(pvcNamespace).Update(ctx, pvc, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return status.Error(codes.Unavailable, fmt.Sprintf("failed to annotate PVC %s/%s with the paths to prefetch: %v", pvcNamespace, pvcName, err))
+	}
+	klog.Infof("[%s] CreateVolume - requested the prefetch of paths [%v] for PVC %s/%s", loggerId, paths, pvcNamespace, pvcName)
+	return nil
+}
+
+// getCacheDeletePolicy returns the delete policy of a cache volume from the
+// attributes of its PV, which has the storageClass parameters of the volume.
+// The PV of a fileset volume has the name of the fileset.
+func getCacheDeletePolicy(ctx context.Context, pvName string) (string, error) {
+	client, err := getKubeClient()
+	if err != nil {
+		return "", status.Error(codes.Internal, fmt.Sprintf("failed to create Kubernetes client: %v", err))
+	}
+	pv, err := client.CoreV1().PersistentVolumes().Get(ctx, pvName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return cacheDeletePolicyFlush, nil
+		}
+		return "", status.Error(codes.Unavailable, fmt.Sprintf("failed to get PersistentVolume [%v]: %v", pvName, err))
+	}
+	if pv.Spec.CSI == nil || pv.Spec.CSI.VolumeAttributes[connectors.UserSpecifiedCacheDeletePolicy] == "" {
+		return cacheDeletePolicyFlush, nil
+	}
+	return strings.ToLower(pv.Spec.CSI.VolumeAttributes[connectors.UserSpecifiedCacheDeletePolicy]), nil
+}
+
+// drainCacheQueue makes sure no operation of a cache volume is queued for the
+// home before the cache fileset is deleted. Depending on the delete policy the
+// queue is flushed and the delete is retried by returning Unavailable until it
+// is empty, the delete fails right away, or the queued operations are dropped.
+func drainCacheQueue(ctx context.Context, conn connectors.SpectrumScaleConnector, filesystemName string, filesetName string) error {
+	loggerId := utils.GetLoggerId(ctx)
+	policy, err := getCacheDeletePolicy(ctx, filesetName)
+	if err != nil {
+		return err
+	}
+	if policy == cacheDeletePolicyForce {
+		klog.Infof("[%s] DeleteVolume - deleting cache fileset [%v] without flushing the queued operations", loggerId, filesetName)
+		return nil
+	}
+
+	filesetInfo, err := conn.ListFileset(ctx, filesystemName, filesetName)
+	if err != nil {
+		if strings.Contains(err.Error(), fsetNotFoundErrCode) ||
+			strings.Contains(err.Error(), fsetNotFoundErrMsg) { // fileset is already deleted
+			return nil
+		}
+		return status.Error(codes.Internal, fmt.Sprintf("unable to list fileset [%v] in filesystem [%v]. Error: %v", filesetName, filesystemName, err))
+	}
+	// Only the writer modes queue operations for the home
+	if filesetInfo.AFM.AFMMode != afmModeIW && filesetInfo.AFM.AFMMode != afmModeSW {
+		return nil
+	}
+
+	cacheState, queueLength, err := conn.GetAFMCacheState(ctx, filesystemName, filesetName)
+	if err != nil {
+		return status.Error(codes.Internal, fmt.Sprintf("unable to get AFM state of fileset [%v] in filesystem [%v]. Error: %v", filesetName, filesystemName, err))
+	}
+	if cacheState != connectors.AFMCacheStateDirty && queueLength == 0 {
+		return nil
+	}
+	if policy == cacheDeletePolicyFail {
+		return status.Error(codes.FailedPrecondition, fmt.Sprintf("cache fileset [%v] is %v with %v operations queued for the home, flush the queue or set the storageClass parameter %s to %s to delete it", filesetName, cacheState, queueLength, connectors.UserSpecifiedCacheDeletePolicy, cacheDeletePolicyForce))
+	}
+
+	klog.Infof("[%s] DeleteVolume - flushing %v queued operations of cache fileset [%v]", loggerId, queueLength, filesetName)
+	if err := conn.FlushCacheFileset(ctx, filesystemName, filesetName); err != nil {
+		return status.Error(codes.Internal, fmt.Sprintf("unable to flush the queue of cache fileset [%v] in filesystem [%v]. Error: %v", filesetName, filesystemName, err))
+	}
+	return status.Error(codes.Unavailable, fmt.Sprintf("cache fileset [%v] is %v with %v operations queued for the home, the queue is being flushed", filesetName, cacheState, queueLength))
+}
+
+// getFileCacheHomeTarget returns the AFM target of a cache volume home if the
+// endpoint is an NFS export (nfs://<host>/<path>) or a path of a remotely
+// mounted IBM Storage Scale filesystem (gpfs:///<path>). It returns false for
+// an object storage endpoint.
+func getFileCacheHomeTarget(endpoint string) (string, bool, error) {
+	parsedURL, err := url.Parse(endpoint)
+	if err != nil {
+		return "", false, err
+	}
+	homePath := path.Clean("/" + parsedURL.Path)
+	switch strings.ToLower(parsedURL.Scheme) {
+	case cacheHomeSchemeNFS:
+		if parsedURL.Hostname() == "" || homePath == "/" {
+			return "", true, fmt.Errorf("invalid NFS endpoint %s, the endpoint must be %s://<host>/<path>", endpoint, cacheHomeSchemeNFS)
+		}
+		return fmt.Sprintf("%s://%s%s", cacheHomeSchemeNFS, parsedURL.Host, homePath), true, nil
+	case cacheHomeSchemeGPFS:
+		if parsedURL.Host != "" || homePath == "/" {
+			return "", true, fmt.Errorf("invalid GPFS endpoint %s, the endpoint must be %s:///<path>", endpoint, cacheHomeSchemeGPFS)
+		}
+		return fmt.Sprintf("%s://%s", cacheHomeSchemeGPFS, homePath), true, nil
+	}
+	return "", false, nil
+}
+
 func (cs *ScaleControllerServer) setScaleVolume(ctx context.Context, req *csi.CreateVolumeRequest, volName string, volSize int64) (*scaleVolume, bool, string, error) {
 	scaleVol, err := getScaleVolumeOptions(ctx, req.GetParameters())
 	if err != nil {
@@ -1270,8 +1650,173 @@ func (cs *ScaleControllerServer) checkVolTierAndSetFilesystemPolicy(ctx context.
 	return nil
 }
 
+// checkMigrationPools checks that the pools of the migration rules of a
+// volume exist in its filesystem.
+func (cs *ScaleControllerServer) checkMigrationPools(ctx context.Context, scaleVol *scaleVolume, volFsInfo connectors.FileSystem_v2) error {
+	if err := cs.checkVolTierSupport(volFsInfo.Version); err != nil {
+		// TODO: Remove this secondary call to local gui when GUI refreshes remote cache immediately
+		tempFsInfo, err := scaleVol.Connector.GetFilesystemDetails(ctx, scaleVol.VolBackendFs)
+		if err != nil {
+			return err
+		}
+		if err := cs.checkVolTierSupport(tempFsInfo.Version); err != nil {
+			return err
+		}
+	}
+
+	pools := []string{scaleVol.Migration.TargetPool}
+	if scaleVol.Migration.SourcePool != "" {
+		pools = append(pools, scaleVol.Migration.SourcePool)
+	}
+	for _, pool := range pools {
+		if err := scaleVol.Connector.DoesTierExist(ctx, pool, scaleVol.VolBackendFs); err != nil {
+			return status.Error(codes.InvalidArgument, fmt.Sprintf("invalid migration pool: %v", err))
+		}
+	}
+	return nil
+}
+
+// getMigrationPartition returns the name of the policy partition holding
+// the migration rules of a fileset based volume.
+func getMigrationPartition(filesetName string) string {
+	return fmt.Sprintf("csi-M%s", filesetName)
+}
+
+// setMigrationPolicy installs the migration rules of a volume as a policy
+// partition of its own, scoped to the fileset of the volume, so that the
+// rules of the other volumes are kept. The driver does not run the rules,
+// they are evaluated when the administrator runs mmapplypolicy, by schedule
+// or by the lowDiskSpace callback of the filesystem for the threshold rule,
+// see MIGRATION_POLICY.
+func setMigrationPolicy(ctx context.Context, scaleVol *scaleVolume) error {
+	loggerId := utils.GetLoggerId(ctx)
+	migration := scaleVol.Migration
+	fromPool := ""
+	if migration.SourcePool != "" {
+		fromPool = fmt.Sprintf(" FROM POOL '%s'", migration.SourcePool)
+	}
+
+	rules := []string{}
+	if migration.AccessDays > 0 {
+		rules = append(rules, fmt.Sprintf("RULE 'csi-M%s-age' MIGRATE%s TO POOL '%s' FOR FILESET('%s') WHERE (DAYS(CURRENT_TIMESTAMP) - DAYS(ACCESS_TIME)) > %d",
+			scaleVol.VolName, fromPool, migration.TargetPool, scaleVol.VolName, migration.AccessDays))
+	}
+	if migration.Threshold != "" {
+		rules = append(rules, fmt.Sprintf("RULE 'csi-M%s-threshold' MIGRATE%s THRESHOLD(%s) WEIGHT(CURRENT_TIMESTAMP - ACCESS_TIME) TO POOL '%s' FOR FILESET('%s')",
+			scaleVol.VolName, fromPool, migration.Threshold, migration.TargetPool, scaleVol.VolName))
+	}
+
+	policy := connectors.Policy{
+		Policy:    strings.Join(rules, "\n"),
+		Partition: getMigrationPartition(scaleVol.VolName),
+	}
+	klog.Infof("[%s] volume:[%v] - setting migration policy [%v]", loggerId, scaleVol.VolName, policy.Policy)
+	if err := scaleVol.Connector.SetFilesystemPolicy(ctx, &policy, scaleVol.VolBackendFs); err != nil {
+		klog.Errorf("[%s] volume:[%v] - setting migration policy failed [%v]", loggerId, scaleVol.VolName, err)
+		return status.Error(codes.Internal, fmt.Sprintf("setting migration policy for volume [%v] failed. Error [%v]", scaleVol.VolName, err))
+	}
+	return nil
+}
+
+// checkFilesystemEncryption checks that the filesystem of a volume has
+// encryption enabled.
+func checkFilesystemEncryption(ctx context.Context, scaleVol *scaleVolume) error {
+	encrypted, err := scaleVol.Connector.IsFilesystemEncrypted(ctx, scaleVol.VolBackendFs)
+	if err != nil {
+		return status.Error(codes.Internal, fmt.Sprintf("unable to get encryption setting of filesystem [%v]. Error [%v]", scaleVol.VolBackendFs, err))
+	}
+	if !encrypted {
+		return status.Error(codes.FailedPrecondition, fmt.Sprintf("encryption is not enabled for filesystem [%v]", scaleVol.VolBackendFs))
+	}
+	return nil
+}
+
+// getEncryptionPartition returns the name of the policy partition holding
+// the encryption rules of a fileset based volume.
+func getEncryptionPartition(filesetName string) string {
+	return fmt.Sprintf("csi-E%s", filesetName)
+}
+
+// setEncryptionPolicy installs the encryption rules of a volume as a policy
+// partition of its own, scoped to the fileset of the volume. It must be
+// installed before any file is created in the volume as only new files are
+// encrypted.
+func setEncryptionPolicy(ctx context.Context, scaleVol *scaleVolume) error {
+	loggerId := utils.GetLoggerId(ctx)
+	rules := []string{
+		fmt.Sprintf("RULE 'csi-E%s-spec' ENCRYPTION 'csi-E%s' IS ALGO 'DEFAULTNISTSP800131A' KEYS('%s')", scaleVol.VolName, scaleVol.VolName, scaleVol.Encryption),
+		fmt.Sprintf("RULE 'csi-E%s' SET ENCRYPTION 'csi-E%s' FOR FILESET('%s')", scaleVol.VolName, scaleVol.VolName, scaleVol.VolName),
+	}
+
+	policy := connectors.Policy{
+		Policy:    strings.Join(rules, "\n"),
+		Partition: getEncryptionPartition(scaleVol.VolName),
+	}
+	klog.Infof("[%s] volume:[%v] - setting encryption policy [%v]", loggerId, scaleVol.VolName, policy.Policy)
+	if err := scaleVol.Connector.SetFilesystemPolicy(ctx, &policy, scaleVol.VolBackendFs); err != nil {
+		klog.Errorf("[%s] volume:[%v] - setting encryption policy failed [%v]", loggerId, scaleVol.VolName, err)
+		return status.Error(codes.Internal, fmt.Sprintf("setting encryption policy for volume [%v] failed. Error [%v]", scaleVol.VolName, err))
+	}
+	return nil
+}
+
+// checkCloneEncryption refuses the clone or the restore of an encrypted
+// volume into a storageClass without encryption.
+func checkCloneEncryption(ctx context.Context, conn connectors.SpectrumScaleConnector, filesystemName string, filesetName string, newvolume *scaleVolume) error {
+	if newvolume.Encryption != "" {
+		return nil
+	}
+	if conn.CheckIfDefaultPolicyPartitionExists(ctx, getEncryptionPartition(filesetName), filesystemName) {
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("the source volume [%v] is encrypted, it can only be cloned or restored into a storageClass with the parameter %s", filesetName, connectors.UserSpecifiedEncryption))
+	}
+	return nil
+}
+
+// deleteVolumePolicies removes the policy partitions holding the encryption
+// and the migration rules of a fileset based volume, if there are any.
+func deleteVolumePolicies(ctx context.Context, conn connectors.SpectrumScaleConnector, filesystemName string, filesetName string) error {
+	for _, partition := range []string{getEncryptionPartition(filesetName), getMigrationPartition(filesetName)} {
+		if !conn.CheckIfDefaultPolicyPartitionExists(ctx, partition, filesystemName) {
+			continue
+		}
+		klog.Infof("[%s] deleting policy partition [%v] of filesystem [%v]", utils.GetLoggerId(ctx), partition, filesystemName)
+		if err := conn.DeletePolicyPartition(ctx, partition, filesystemName); err != nil {
+			return status.Error(codes.Internal, fmt.Sprintf("unable to delete policy partition [%v] of filesystem [%v]. Error [%v]", partition, filesystemName, err))
+		}
+	}
+	return nil
+}
+
+// getVolumeContext returns the volume context of a new volume, the parameters
+// of the storage class. If the files of the volume are file clones of the
+// files of a snapshot, the ID of the snapshot is added as cloneParentSnapshot,
+// so that the snapshot is not deleted while the volume exists.
+func (cs *ScaleControllerServer) getVolumeContext(req *csi.CreateVolumeRequest, volName string) map[string]string {
+	jobDetails, found := cs.Driver.snapjobstatusmap.Load(volName)
+	if !found || !jobDetails.(SnapCopyJobDetails).cloned {
+		return req.GetParameters()
+	}
+	volumeContext := make(map[string]string, len(req.GetParameters())+1)
+	for key, value := range req.GetParameters() {
+		volumeContext[key] = value
+	}
+	volumeContext[cloneParentSnapshotKey] = req.GetVolumeContentSource().GetSnapshot().GetSnapshotId()
+	return volumeContext
+}
+
 func (cs *ScaleControllerServer) getCopyJobStatus(ctx context.Context, req *csi.CreateVolumeRequest, volSrc *csi.VolumeContentSource, scaleVol *scaleVolume, isVolSource bool, isSnapSource bool, snapIdMembers scaleSnapId) (*csi.CreateVolumeResponse, error) {
 	loggerId := utils.GetLoggerId(ctx)
+	if volID, found := cs.Driver.copyjobscancelled.Load(scaleVol.VolName); found {
+		klog.Infof("[%s] volume:[%v] - the copy of the content was cancelled as the PVC was deleted", loggerId, scaleVol.VolName)
+		return &csi.CreateVolumeResponse{
+			Volume: &csi.Volume{
+				VolumeId:      volID.(string),
+				CapacityBytes: int64(scaleVol.VolSize),
+				VolumeContext: req.GetParameters(),
+				ContentSource: volSrc,
+			},
+		}, nil
+	}
 	if isVolSource {
 		jobDetails, found := cs.Driver.volcopyjobstatusmap.Load(scaleVol.VolName)
 		if found {
@@ -1280,8 +1825,14 @@ func (cs *ScaleControllerServer) getCopyJobStatus(ctx context.Context, req *csi.
 			klog.V(6).Infof("[%s] volume: [%v] found in volcopyjobstatusmap with volID: [%v], jobStatus: [%v]", loggerId, scaleVol.VolName, volID, jobStatus)
 			switch jobStatus {
 			case VOLCOPY_JOB_RUNNING:
-				klog.Errorf("[%s] volume:[%v] -  volume cloning request in progress.", loggerId, scaleVol.VolName)
-				return nil, status.Error(codes.Aborted, fmt.Sprintf("volume cloning request in progress for volume: %s", scaleVol.VolName))
+				details := jobDetails.(VolCopyJobDetails)
+				details.progress = cs.getCopyJobProgress(ctx, details.clusterID, details.jobID, details.progress)
+				cs.Driver.volcopyjobstatusmap.CompareAndSwap(scaleVol.VolName, jobDetails, details)
+				if cs.reportCopyProgress(ctx, req, details.progress) {
+					return cs.cancelCopyOfDeletedPVC(ctx, req, volSrc, scaleVol, volID)
+				}
+				klog.Errorf("[%s] volume:[%v] -  volume cloning request in progress. %s", loggerId, scaleVol.VolName, details.progress)
+				return nil, status.Error(codes.Aborted, fmt.Sprintf("volume cloning request in progress for volume: %s. %s", scaleVol.VolName, details.progress))
 			case VOLCOPY_JOB_FAILED:
 				//Delete the entry from map, so that it is retried
 				klog.Errorf("[%s] volume:[%v] -  volume cloning job had failed and it will be retried", loggerId, scaleVol.VolName)
@@ -1315,8 +1866,14 @@ func (cs *ScaleControllerServer) getCopyJobStatus(ctx context.Context, req *csi.
 			klog.V(6).Infof("[%s] volume: [%v] found in snapjobstatusmap with volID: [%v], jobStatus: [%v]", loggerId, scaleVol.VolName, volID, jobStatus)
 			switch jobStatus {
 			case SNAP_JOB_RUNNING:
-				klog.Errorf("[%s] volume:[%v] -  snapshot copy request in progress for snapshot: %s.", loggerId, scaleVol.VolName, snapIdMembers.SnapName)
-				return nil, status.Error(codes.Aborted, fmt.Sprintf("snapshot copy request in progress for snapshot: %s", snapIdMembers.SnapName))
+				details := jobDetails.(SnapCopyJobDetails)
+				details.progress = cs.getCopyJobProgress(ctx, details.clusterID, details.jobID, details.progress)
+				cs.Driver.snapjobstatusmap.CompareAndSwap(scaleVol.VolName, jobDetails, details)
+				if cs.reportCopyProgress(ctx, req, details.progress) {
+					return cs.cancelCopyOfDeletedPVC(ctx, req, volSrc, scaleVol, volID)
+				}
+				klog.Errorf("[%s] volume:[%v] -  snapshot copy request in progress for snapshot: %s. %s", loggerId, scaleVol.VolName, snapIdMembers.SnapName, details.progress)
+				return nil, status.Error(codes.Aborted, fmt.Sprintf("snapshot copy request in progress for snapshot: %s. %s", snapIdMembers.SnapName, details.progress))
 			case SNAP_JOB_FAILED:
 				klog.Errorf("[%s] volume:[%v] -  snapshot copy job had failed for snapshot %s and it will be retried", loggerId, scaleVol.VolName, snapIdMembers.SnapName)
 				//Delete the entry from map, so that it is retried
@@ -1328,7 +1885,7 @@ func (cs *ScaleControllerServer) getCopyJobStatus(ctx context.Context, req *csi.
 					Volume: &csi.Volume{
 						VolumeId:      volID,
 						CapacityBytes: int64(scaleVol.VolSize),
-						VolumeContext: req.GetParameters(),
+						VolumeContext: cs.getVolumeContext(req, scaleVol.VolName),
 						ContentSource: volSrc,
 					},
 				}, nil
@@ -1343,23 +1900,150 @@ func (cs *ScaleControllerServer) getCopyJobStatus(ctx context.Context, req *csi.
 	}
 	return nil, nil
 }
-func (cs *ScaleControllerServer) copySnapContent(ctx context.Context, scVol *scaleVolume, snapId scaleSnapId, fsDetails connectors.FileSystem_v2, targetPath string, volID string) error {
+
+// getCopyJobProgress returns the percent done and the throughput of a running
+// copy job, as reported by the last progress line of the job. The previous
+// progress is returned if the job can not be queried or reports no progress.
+func (cs *ScaleControllerServer) getCopyJobProgress(ctx context.Context, clusterID string, jobID uint64, previous string) string {
 	loggerId := utils.GetLoggerId(ctx)
-	klog.Infof("[%s] copySnapContent snapId: [%v], scaleVolume: [%v]", loggerId, snapId, scVol)
-	conn, err := cs.getConnFromClusterID(ctx, snapId.ClusterId)
+	if jobID == 0 {
+		return previous
+	}
+	conn, err := cs.getConnFromClusterID(ctx, clusterID)
 	if err != nil {
-		return err
+		return previous
 	}
-
-	//err = cs.validateRemoteFs(fsDetails, scVol)
-	//if err != nil {
-	//	return err
-	//}
-
-	targetFsName, err := conn.GetFilesystemName(ctx, fsDetails.UUID)
+	job, err := conn.GetJob(ctx, jobID)
+	if err != nil {
+		klog.Warningf("[%s] unable to get the progress of copy job [%v]. Error: %v", loggerId, jobID, err)
+		return previous
+	}
+	if progress := parseCopyJobProgress(job.Result.Progress); progress != "" {
+		return progress
+	}
+	return previous
+}
+
+var (
+	copyPercentRegex    = regexp.MustCompile(`(\d+(\.\d+)?)\s*%`)
+	copyThroughputRegex = regexp.MustCompile(`(\d+(\.\d+)?\s*[KMGTP]i?B/s)`)
+)
+
+// parseCopyJobProgress returns the percent done and the throughput from the
+// last progress line of a copy job which reports them.
+func parseCopyJobProgress(progress []string) string {
+	for i := len(progress) - 1; i >= 0; i-- {
+		percent := copyPercentRegex.FindStringSubmatch(progress[i])
+		if percent == nil {
+			continue
+		}
+		message := fmt.Sprintf("%s%% done", percent[1])
+		if throughput := copyThroughputRegex.FindStringSubmatch(progress[i]); throughput != nil {
+			message = fmt.Sprintf("%s at %s", message, throughput[1])
+		}
+		return message
+	}
+	return ""
+}
+
+// reportCopyProgress records the progress of the copy of the content of a new
+// volume as an event on its PVC, and returns whether the PVC is deleted. The
+// PVC is known only if the provisioner passes its metadata to CreateVolume.
+func (cs *ScaleControllerServer) reportCopyProgress(ctx context.Context, req *csi.CreateVolumeRequest, progress string) bool {
+	loggerId := utils.GetLoggerId(ctx)
+	reqParams := req.GetParameters()
+	pvcNamespace, pvcName := reqParams[pvcNamespaceKey], reqParams[pvcNameKey]
+	if pvcNamespace == "" || pvcName == "" {
+		return false
+	}
+	client, err := getKubeClient()
+	if err != nil {
+		klog.Warningf("[%s] progress of the copy to PVC [%s/%s] is not reported: %v", loggerId, pvcNamespace, pvcName, err)
+		return false
+	}
+	pvc, err := client.CoreV1().PersistentVolumeClaims(pvcNamespace).Get(ctx, pvcName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return true
+	}
+	if err != nil {
+		klog.Warningf("[%s] progress of the copy to PVC [%s/%s] is not reported, unable to get the PVC: %v", loggerId, pvcNamespace, pvcName, err)
+		return false
+	}
+	if pvc.DeletionTimestamp != nil {
+		return true
+	}
+	if cs.Driver.recorder != nil {
+		message := "Copying the content of the volume"
+		if progress != "" {
+			message = fmt.Sprintf("%s, %s", message, progress)
+		}
+		cs.Driver.recorder.Event(pvc, v1.EventTypeNormal, copyInProgressReason, message)
+	}
+	return false
+}
+
+// cancelCopyOfDeletedPVC cancels the copy of the content of a new volume whose
+// PVC was deleted. The provisioner keeps calling CreateVolume until it gets a
+// final result, so the volume is returned as created and it is deleted like
+// any volume of a deleted PVC.
+func (cs *ScaleControllerServer) cancelCopyOfDeletedPVC(ctx context.Context, req *csi.CreateVolumeRequest, volSrc *csi.VolumeContentSource, scaleVol *scaleVolume, volID string) (*csi.CreateVolumeResponse, error) {
+	klog.Infof("[%s] volume:[%v] - the PVC was deleted, cancelling the copy of the content", utils.GetLoggerId(ctx), scaleVol.VolName)
+	if err := cs.cancelCopyJob(ctx, scaleVol.VolName); err != nil {
+		return nil, err
+	}
+	cs.Driver.copyjobscancelled.Store(scaleVol.VolName, volID)
+	return &csi.CreateVolumeResponse{
+		Volume: &csi.Volume{
+			VolumeId:      volID,
+			CapacityBytes: int64(scaleVol.VolSize),
+			VolumeContext: req.GetParameters(),
+			ContentSource: volSrc,
+		},
+	}, nil
+}
+
+// cancelCopyJob cancels the running job copying the content of a volume whose
+// PVC or volume is deleted before the copy completed.
+func (cs *ScaleControllerServer) cancelCopyJob(ctx context.Context, volName string) error {
+	loggerId := utils.GetLoggerId(ctx)
+	var jobID uint64
+	var clusterID string
+	if jobDetails, found := cs.Driver.volcopyjobstatusmap.Load(volName); found && jobDetails.(VolCopyJobDetails).jobStatus == VOLCOPY_JOB_RUNNING {
+		jobID, clusterID = jobDetails.(VolCopyJobDetails).jobID, jobDetails.(VolCopyJobDetails).clusterID
+	} else if jobDetails, found := cs.Driver.snapjobstatusmap.Load(volName); found && jobDetails.(SnapCopyJobDetails).jobStatus == SNAP_JOB_RUNNING {
+		jobID, clusterID = jobDetails.(SnapCopyJobDetails).jobID, jobDetails.(SnapCopyJobDetails).clusterID
+	}
+	if jobID == 0 {
+		return nil
+	}
+
+	conn, err := cs.getConnFromClusterID(ctx, clusterID)
 	if err != nil {
 		return err
 	}
+	klog.Infof("[%s] cancelling copy job [%v] of volume [%v]", loggerId, jobID, volName)
+	if err := conn.CancelJob(ctx, jobID); err != nil {
+		klog.Errorf("[%s] unable to cancel copy job [%v] of volume [%v]. Error: %v", loggerId, jobID, volName, err)
+		return status.Error(codes.Internal, fmt.Sprintf("unable to cancel copy job [%v] of volume [%v]. Error: %v", jobID, volName, err))
+	}
+	cs.Driver.volcopyjobstatusmap.Delete(volName)
+	cs.Driver.snapjobstatusmap.Delete(volName)
+	return nil
+}
+
+func (cs *ScaleControllerServer) copySnapContent(ctx context.Context, scVol *scaleVolume, snapId scaleSnapId, fsDetails connectors.FileSystem_v2, targetPath string, volID string) error {
+	loggerId := utils.GetLoggerId(ctx)
+	klog.Infof("[%s] copySnapContent snapId: [%v], scaleVolume: [%v]", loggerId, snapId, scVol)
+	// The copy cluster is chosen by validateSnapId
+	copyCluster := scVol.CopyCluster
+	conn := copyCluster.conn
+
+	//err = cs.validateRemoteFs(fsDetails, scVol)
+	//if err != nil {
+	//	return err
+	//}
+
+	targetFsName := copyCluster.targetFsName
 
 	targetFsDetails, err := conn.GetFilesystemDetails(ctx, targetFsName)
 	if err != nil {
@@ -1375,14 +2059,19 @@ func (cs *ScaleControllerServer) copySnapContent(ctx context.Context, scVol *sca
 		snapIDPath = fmt.Sprintf("/%s", snapId.FsetName)
 		filesetForCopy = snapId.ConsistencyGroup
 	}
-	jobStatus, jobID, err := conn.CopyFsetSnapshotPath(ctx, snapId.FsName, filesetForCopy, snapId.SnapName, snapIDPath, targetPath, scVol.NodeClass)
+	if scVol.CloneMode == cloneModeClone {
+		if cloned, err := cs.cloneSnapshotPath(ctx, copyCluster, scVol, filesetForCopy, snapId.SnapName, snapIDPath, targetPath, volID); cloned {
+			return err
+		}
+	}
+	jobStatus, jobID, err := conn.CopyFsetSnapshotPath(ctx, copyCluster.sourceFsName, filesetForCopy, snapId.SnapName, snapIDPath, targetPath, copyCluster.nodes)
 	if err != nil {
 		klog.Errorf("[%s] failed to create volume from snapshot %s: [%v]", loggerId, snapId.SnapName, err)
 		return status.Error(codes.Internal, fmt.Sprintf("failed to create volume from snapshot %s: [%v]", snapId.SnapName, err))
 
 	}
 
-	jobDetails := SnapCopyJobDetails{SNAP_JOB_RUNNING, volID}
+	jobDetails := SnapCopyJobDetails{jobStatus: SNAP_JOB_RUNNING, volID: volID, jobID: jobID, clusterID: copyCluster.clusterID}
 	cs.Driver.snapjobstatusmap.Store(scVol.VolName, jobDetails)
 
 	isResponseStatusUnknown := false
@@ -1432,7 +2121,7 @@ func (cs *ScaleControllerServer) copyShallowVolumeContent(ctx context.Context, n
 	fsMntPt := fsDetails.Mount.MountPoint
 	targetPath = fmt.Sprintf("%s/%s", fsMntPt, targetPath)
 
-	jobDetails := VolCopyJobDetails{VOLCOPY_JOB_NOT_STARTED, volID}
+	jobDetails := VolCopyJobDetails{jobStatus: VOLCOPY_JOB_NOT_STARTED, volID: volID}
 	response := connectors.GenericResponse{}
 
 	sLinkRelPath := strings.Replace(sourcevolume.Path, fsMntPt, "", 1)
@@ -1459,7 +2148,7 @@ func (cs *ScaleControllerServer) copyShallowVolumeContent(ctx context.Context, n
 		return status.Error(codes.Internal, fmt.Sprintf("failed to clone volume from shallow copy volume. Error: [%v]", jobErr))
 	}
 
-	jobDetails = VolCopyJobDetails{VOLCOPY_JOB_RUNNING, volID}
+	jobDetails = VolCopyJobDetails{jobStatus: VOLCOPY_JOB_RUNNING, volID: volID, jobID: jobID, clusterID: sourcevolume.ClusterId}
 	cs.Driver.volcopyjobstatusmap.Store(newvolume.VolName, jobDetails)
 	response, err = conn.WaitForJobCompletionWithResp(ctx, jobStatus, jobID)
 	if err != nil {
@@ -1497,6 +2186,7 @@ func (cs *ScaleControllerServer) copyShallowVolumeContent(ctx context.Context, n
 func (cs *ScaleControllerServer) copyVolumeContent(ctx context.Context, newvolume *scaleVolume, sourcevolume scaleVolId, fsDetails connectors.FileSystem_v2, targetPath string, volID string) error {
 	loggerId := utils.GetLoggerId(ctx)
 	klog.Infof("[%s] copyVolContent volume ID: [%v], scaleVolume: [%v], volume name: [%v]", loggerId, sourcevolume, newvolume, newvolume.VolName)
+	copyClusterID := sourcevolume.ClusterId
 	conn, err := cs.getConnFromClusterID(ctx, sourcevolume.ClusterId)
 	if err != nil {
 		return err
@@ -1507,9 +2197,19 @@ func (cs *ScaleControllerServer) copyVolumeContent(ctx context.Context, newvolum
 	// 	return err
 	// }
 
-	targetFsName, err := conn.GetFilesystemName(ctx, fsDetails.UUID)
-	if err != nil {
-		return err
+	var targetFsName, copyNodes string
+	if newvolume.IsFilesetBased {
+		// A fileset based volume is copied by a cluster with nodes mounting
+		// both filesystems, which may be neither the source nor the target
+		// cluster, chosen by validateCloneRequest
+		copyCluster := newvolume.CopyCluster
+		copyClusterID, conn, copyNodes = copyCluster.clusterID, copyCluster.conn, copyCluster.nodes
+		sourcevolume.FsName, targetFsName = copyCluster.sourceFsName, copyCluster.targetFsName
+	} else {
+		targetFsName, err = conn.GetFilesystemName(ctx, fsDetails.UUID)
+		if err != nil {
+			return err
+		}
 	}
 
 	targetFsDetails, err := conn.GetFilesystemDetails(ctx, targetFsName)
@@ -1520,7 +2220,7 @@ func (cs *ScaleControllerServer) copyVolumeContent(ctx context.Context, newvolum
 	fsMntPt := targetFsDetails.Mount.MountPoint
 	targetPath = fmt.Sprintf("%s/%s", fsMntPt, targetPath)
 
-	jobDetails := VolCopyJobDetails{VOLCOPY_JOB_NOT_STARTED, volID}
+	jobDetails := VolCopyJobDetails{jobStatus: VOLCOPY_JOB_NOT_STARTED, volID: volID}
 	response := connectors.GenericResponse{}
 	if newvolume.IsFilesetBased {
 		path := ""
@@ -1530,13 +2230,19 @@ func (cs *ScaleControllerServer) copyVolumeContent(ctx context.Context, newvolum
 			path = fmt.Sprintf("%s%s", sourcevolume.FsetName, "-data")
 		}
 
-		jobStatus, jobID, jobErr := conn.CopyFilesetPath(ctx, sourcevolume.FsName, sourcevolume.FsetName, path, targetPath, newvolume.NodeClass)
+		// A dependent fileset has no snapshots to hold the clone parents
+		if newvolume.CloneMode == cloneModeClone && sourcevolume.VolType != FILE_DEPENDENTFILESET_VOLUME {
+			if cloned, err := cs.cloneVolume(ctx, conn, newvolume, sourcevolume, path, targetFsName, targetPath, copyClusterID, volID); cloned {
+				return err
+			}
+		}
+		jobStatus, jobID, jobErr := conn.CopyFilesetPath(ctx, sourcevolume.FsName, sourcevolume.FsetName, path, targetPath, copyNodes)
 		if jobErr != nil {
 			klog.Errorf("[%s] failed to clone volume from volume. Error: [%v]", loggerId, jobErr)
 			return status.Error(codes.Internal, fmt.Sprintf("failed to clone volume from volume. Error: [%v]", jobErr))
 		}
 
-		jobDetails = VolCopyJobDetails{VOLCOPY_JOB_RUNNING, volID}
+		jobDetails = VolCopyJobDetails{jobStatus: VOLCOPY_JOB_RUNNING, volID: volID, jobID: jobID, clusterID: copyClusterID}
 		cs.Driver.volcopyjobstatusmap.Store(newvolume.VolName, jobDetails)
 		response, err = conn.WaitForJobCompletionWithResp(ctx, jobStatus, jobID)
 	} else {
@@ -1554,7 +2260,7 @@ func (cs *ScaleControllerServer) copyVolumeContent(ctx context.Context, newvolum
 			return status.Error(codes.Internal, fmt.Sprintf("failed to clone volume from volume. Error: [%v]", jobErr))
 		}
 
-		jobDetails = VolCopyJobDetails{VOLCOPY_JOB_RUNNING, volID}
+		jobDetails = VolCopyJobDetails{jobStatus: VOLCOPY_JOB_RUNNING, volID: volID, jobID: jobID, clusterID: sourcevolume.ClusterId}
 		cs.Driver.volcopyjobstatusmap.Store(newvolume.VolName, jobDetails)
 		response, err = conn.WaitForJobCompletionWithResp(ctx, jobStatus, jobID)
 		if err != nil {
@@ -1597,6 +2303,157 @@ func (cs *ScaleControllerServer) copyVolumeContent(ctx context.Context, newvolum
 	return nil
 }
 
+// cloneSnapshotPath builds the content of a new volume with cloneMode clone
+// from file clones of a snapshot path, the files of the snapshot are the
+// clone parents. It returns false if file clones are not supported, the data
+// is then copied.
+func (cs *ScaleControllerServer) cloneSnapshotPath(ctx context.Context, copyCluster *copyCluster, scVol *scaleVolume, filesetName string, snapshotName string, srcPath string, targetPath string, volID string) (bool, error) {
+	loggerId := utils.GetLoggerId(ctx)
+	conn, filesystemName := copyCluster.conn, copyCluster.sourceFsName
+	srcDir, err := getFilesetSnapshotPath(ctx, conn, filesystemName, filesetName, snapshotName, srcPath)
+	if err == nil {
+		err = checkFileCloneSupport(filesystemName, copyCluster.targetFsName, srcDir, targetPath)
+	}
+	if err != nil {
+		klog.Warningf("[%s] volume:[%v] - file clones of snapshot [%v] are not supported, the data is copied instead: %v", loggerId, scVol.VolName, snapshotName, err)
+		return false, nil
+	}
+
+	jobDetails := SnapCopyJobDetails{jobStatus: SNAP_JOB_RUNNING, volID: volID, clusterID: copyCluster.clusterID}
+	cs.Driver.snapjobstatusmap.Store(scVol.VolName, jobDetails)
+	klog.Infof("[%s] volume:[%v] - creating file clones of snapshot [%v] path [%v]", loggerId, scVol.VolName, snapshotName, srcDir)
+	if err := cloneFiles(ctx, srcDir, targetPath); err != nil {
+		klog.Errorf("[%s] volume:[%v] - unable to create file clones of snapshot [%v]. Error: %v", loggerId, scVol.VolName, snapshotName, err)
+		jobDetails.jobStatus = SNAP_JOB_FAILED
+		cs.Driver.snapjobstatusmap.Store(scVol.VolName, jobDetails)
+		return true, status.Error(codes.Internal, fmt.Sprintf("unable to create file clones of snapshot [%v]: %v", snapshotName, err))
+	}
+	klog.Infof("[%s] volume:[%v] - file clones of snapshot [%v] created", loggerId, scVol.VolName, snapshotName)
+	jobDetails.jobStatus = SNAP_JOB_COMPLETED
+	jobDetails.cloned = true
+	cs.Driver.snapjobstatusmap.Store(scVol.VolName, jobDetails)
+	return true, nil
+}
+
+// cloneVolume builds the content of a volume clone with cloneMode clone from
+// file clones of the source volume. A snapshot clone-<volume clone> of the
+// source volume is taken to hold the clone parents. It can not be deleted
+// while the clones exist, it is deleted with the volume clone by
+// DeleteVolume, and the source volume can not be deleted before. It returns
+// false if file clones are not supported, the data is then copied.
+func (cs *ScaleControllerServer) cloneVolume(ctx context.Context, conn connectors.SpectrumScaleConnector, newvolume *scaleVolume, sourcevolume scaleVolId, srcPath string, targetFsName string, targetPath string, clusterID string, volID string) (bool, error) {
+	loggerId := utils.GetLoggerId(ctx)
+	snapName := cloneSnapPrefix + newvolume.VolName
+	junctionPath, err := getFilesetJunctionPath(ctx, conn, sourcevolume.FsName, sourcevolume.FsetName)
+	if err == nil {
+		// The snapshot is not taken yet, check the live path
+		err = checkFileCloneSupport(sourcevolume.FsName, targetFsName, filepath.Join(junctionPath, srcPath), targetPath)
+	}
+	if err != nil {
+		klog.Warningf("[%s] volume:[%v] - file clones of volume [%v] are not supported, the data is copied instead: %v", loggerId, newvolume.VolName, sourcevolume.FsetName, err)
+		return false, nil
+	}
+
+	snapExist, err := conn.CheckIfSnapshotExist(ctx, sourcevolume.FsName, sourcevolume.FsetName, snapName)
+	if err != nil {
+		return true, status.Error(codes.Internal, fmt.Sprintf("unable to get the snapshot details for [%s]. Error [%v]", snapName, err))
+	}
+	if !snapExist {
+		if err := conn.CreateSnapshot(ctx, sourcevolume.FsName, sourcevolume.FsetName, snapName); err != nil {
+			return true, status.Error(codes.Internal, fmt.Sprintf("unable to create snapshot [%s] of fileset [%s:%s] for the volume clone. Error [%v]", snapName, sourcevolume.FsName, sourcevolume.FsetName, err))
+		}
+	}
+
+	srcDir := filepath.Join(junctionPath, ".snapshots", snapName, srcPath)
+	jobDetails := VolCopyJobDetails{jobStatus: VOLCOPY_JOB_RUNNING, volID: volID, clusterID: clusterID}
+	cs.Driver.volcopyjobstatusmap.Store(newvolume.VolName, jobDetails)
+	klog.Infof("[%s] volume:[%v] - creating file clones of volume [%v] from snapshot [%v]", loggerId, newvolume.VolName, sourcevolume.FsetName, snapName)
+	if err := cloneFiles(ctx, srcDir, targetPath); err != nil {
+		klog.Errorf("[%s] volume:[%v] - unable to create file clones of volume [%v]. Error: %v", loggerId, newvolume.VolName, sourcevolume.FsetName, err)
+		jobDetails.jobStatus = VOLCOPY_JOB_FAILED
+		cs.Driver.volcopyjobstatusmap.Store(newvolume.VolName, jobDetails)
+		return true, status.Error(codes.Internal, fmt.Sprintf("unable to create file clones of volume [%v]: %v", sourcevolume.FsetName, err))
+	}
+	klog.Infof("[%s] volume:[%v] - file clones of volume [%v] created", loggerId, newvolume.VolName, sourcevolume.FsetName)
+	jobDetails.jobStatus = VOLCOPY_JOB_COMPLETED
+	cs.Driver.volcopyjobstatusmap.Store(newvolume.VolName, jobDetails)
+	return true, nil
+}
+
+// deleteCloneSnapshots deletes the snapshot taken by cloneVolume in the
+// source fileset of a volume clone, once the volume clone is deleted.
+func deleteCloneSnapshots(ctx context.Context, conn connectors.SpectrumScaleConnector, filesystemName string, volName string) error {
+	loggerId := utils.GetLoggerId(ctx)
+	snapName := cloneSnapPrefix + volName
+	snapshots, err := conn.ListFilesystemSnapshots(ctx, filesystemName, snapName)
+	if err != nil {
+		return status.Error(codes.Internal, fmt.Sprintf("unable to list the snapshots [%v] of filesystem [%v]. Error [%v]", snapName, filesystemName, err))
+	}
+	for _, snapshot := range snapshots {
+		if snapshot.SnapshotName != snapName || snapshot.FilesetName == "" {
+			continue
+		}
+		klog.Infof("[%s] deleting snapshot [%v] of fileset [%v] holding the file clones of volume [%v]", loggerId, snapName, snapshot.FilesetName, volName)
+		if err := conn.DeleteSnapshot(ctx, filesystemName, snapshot.FilesetName, snapName); err != nil {
+			return status.Error(codes.Internal, fmt.Sprintf("unable to delete snapshot [%v] of fileset [%v]. Error [%v]", snapName, snapshot.FilesetName, err))
+		}
+	}
+	return nil
+}
+
+// checkFileCloneSupport returns an error if file clones of the source path
+// can not be created in the target path, which are paths on the host.
+func checkFileCloneSupport(sourceFsName string, targetFsName string, srcDir string, targetDir string) error {
+	// A clone and its parent must be in the same filesystem
+	if sourceFsName != targetFsName {
+		return fmt.Errorf("the source filesystem %s is not the target filesystem %s", sourceFsName, targetFsName)
+	}
+	return checkLocalFileClones(srcDir, targetDir)
+}
+
+// getFilesetJunctionPath returns the junction path of a linked fileset.
+func getFilesetJunctionPath(ctx context.Context, conn connectors.SpectrumScaleConnector, filesystemName string, filesetName string) (string, error) {
+	filesetInfo, err := conn.ListFileset(ctx, filesystemName, filesetName)
+	if err != nil {
+		return "", fmt.Errorf("unable to get the details of fileset [%v] of filesystem [%v]: %v", filesetName, filesystemName, err)
+	}
+	if filesetInfo.Config.Path == "" || filesetInfo.Config.Path == filesetUnlinkedPath {
+		return "", fmt.Errorf("fileset [%v] of filesystem [%v] is not linked", filesetName, filesystemName)
+	}
+	return filesetInfo.Config.Path, nil
+}
+
+// getShallowCopyTargetPath returns the path of the data of a snapshot which a
+// shallow copy volume uses, under the .snapshots directory of the junction of
+// the snapshot fileset, e.g. the filesystem mount point for the root fileset.
+func getShallowCopyTargetPath(ctx context.Context, conn connectors.SpectrumScaleConnector, volFsInfo connectors.FileSystem_v2, snapIdMembers *scaleSnapId, isCGVolume bool) (string, error) {
+	filesetName, path := snapIdMembers.FsetName, snapIdMembers.Path
+	if isCGVolume {
+		filesetName, path = snapIdMembers.ConsistencyGroup, snapIdMembers.FsetName
+	}
+	snapshotPath, err := getFilesetSnapshotPath(ctx, conn, snapIdMembers.FsName, filesetName, snapIdMembers.SnapName, path)
+	if err != nil {
+		return "", err
+	}
+	// The junction is reported with the mount point of the cluster owning
+	// the filesystem
+	mountPoint, err := conn.GetFilesystemMountpoint(ctx, snapIdMembers.FsName)
+	if err != nil {
+		return "", fmt.Errorf("unable to get the mount point of filesystem [%v]: %v", snapIdMembers.FsName, err)
+	}
+	return filepath.Join(volFsInfo.Mount.MountPoint, strings.TrimPrefix(snapshotPath, mountPoint)), nil
+}
+
+// getFilesetSnapshotPath returns the path of a path of a fileset in a
+// snapshot of the fileset, under the .snapshots directory of its junction.
+func getFilesetSnapshotPath(ctx context.Context, conn connectors.SpectrumScaleConnector, filesystemName string, filesetName string, snapshotName string, path string) (string, error) {
+	junctionPath, err := getFilesetJunctionPath(ctx, conn, filesystemName, filesetName)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(junctionPath, ".snapshots", snapshotName, path), nil
+}
+
 func (cs *ScaleControllerServer) assembledScaleVersion(ctx context.Context, conn connectors.SpectrumScaleConnector) (string, error) {
 	assembledScaleVer := ""
 	scaleVersion, err := conn.GetScaleVersion(ctx)
@@ -1686,7 +2543,7 @@ func (cs *ScaleControllerServer) checkCGSupport(assembledScaleversion string) er
 	 return nil
  }*/
 
-func (cs *ScaleControllerServer) validateSnapId(ctx context.Context, scaleVol *scaleVolume, sourcesnapshot *scaleSnapId, newvolume *scaleVolume, assembledScaleversion string) error {
+func (cs *ScaleControllerServer) validateSnapId(ctx context.Context, scaleVol *scaleVolume, sourcesnapshot *scaleSnapId, newvolume *scaleVolume, volFsInfo connectors.FileSystem_v2, assembledScaleversion string) error {
 
 	loggerId := utils.GetLoggerId(ctx)
 	klog.Infof("[%s] validateSnapId [%v]", loggerId, sourcesnapshot)
@@ -1695,11 +2552,6 @@ func (cs *ScaleControllerServer) validateSnapId(ctx context.Context, scaleVol *s
 		return err
 	}
 
-	// Restrict cross cluster cloning
-	if newvolume.ClusterId != sourcesnapshot.ClusterId {
-		return status.Error(codes.Unimplemented, "creating volume from snapshot across clusters is not supported")
-	}
-
 	// Restrict cross storage class version volume from snapshot
 	// if len(newvolume.StorageClassType) != 0 || len(sourcesnapshot.StorageClassType) != 0 {
 	// 	if newvolume.StorageClassType != sourcesnapshot.StorageClassType {
@@ -1723,23 +2575,19 @@ func (cs *ScaleControllerServer) validateSnapId(ctx context.Context, scaleVol *s
 		return chkSnapshotErr
 	}
 
-	if newvolume.NodeClass != "" {
-		isValidNodeclass, err := conn.IsValidNodeclass(ctx, newvolume.NodeClass)
-		if err != nil {
-			return err
-		}
-
-		if !isValidNodeclass {
-			return status.Error(codes.NotFound, fmt.Sprintf("nodeclass [%s] not found on cluster [%v]", newvolume.NodeClass, newvolume.ClusterId))
-		}
-	}
-
 	sourcesnapshot.FsName, err = conn.GetFilesystemName(ctx, sourcesnapshot.FsUUID)
 
 	if err != nil {
 		return status.Error(codes.Internal, fmt.Sprintf("unable to get filesystem Name for Id [%v] and clusterId [%v]. Error [%v]", sourcesnapshot.FsUUID, sourcesnapshot.ClusterId, err))
 	}
 
+	// The snapshot is copied by a cluster with nodes mounting both filesystems
+	copyCluster, err := cs.getCopyCluster(ctx, sourcesnapshot.ClusterId, sourcesnapshot.FsUUID, newvolume.ClusterId, volFsInfo.UUID, newvolume.NodeClass)
+	if err != nil {
+		return err
+	}
+	newvolume.CopyCluster = &copyCluster
+
 	filesetToCheck := sourcesnapshot.FsetName
 	if sourcesnapshot.StorageClassType == STORAGECLASS_ADVANCED {
 		filesetToCheck = sourcesnapshot.ConsistencyGroup
@@ -1765,6 +2613,12 @@ func (cs *ScaleControllerServer) validateSnapId(ctx context.Context, scaleVol *s
 		return status.Error(codes.Internal, fmt.Sprintf("snapshot [%v] does not exist for fileset [%v]", sourcesnapshot.SnapName, filesetToCheck))
 	}
 
+	if sourcesnapshot.FsetName != "" {
+		if err := checkCloneEncryption(ctx, conn, sourcesnapshot.FsName, sourcesnapshot.FsetName, newvolume); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -1848,9 +2702,9 @@ func (cs *ScaleControllerServer) validateCloneRequest(ctx context.Context, scale
 		return status.Error(codes.Unimplemented, "cloning of cache volume is not supported")
 	}
 
-	// Restrict cross cluster cloning
-	if newvolume.ClusterId != sourcevolume.ClusterId {
-		return status.Error(codes.Unimplemented, "cloning of volume across clusters is not supported")
+	// Restrict cross cluster cloning of directory based and shallow copy volumes
+	if newvolume.ClusterId != sourcevolume.ClusterId && (!sourcevolume.IsFilesetBased || sourcevolume.VolType == FILE_SHALLOWCOPY_VOLUME) {
+		return status.Error(codes.Unimplemented, "cloning of directory based or shallow copy volume across clusters is not supported")
 	}
 
 	// Restrict cross storage class version
@@ -1907,9 +2761,20 @@ func (cs *ScaleControllerServer) validateCloneRequest(ctx context.Context, scale
 				return err
 			}
 		}
+
+		if err := checkCloneEncryption(ctx, conn, sourcevolume.FsName, sourcevolume.FsetName, newvolume); err != nil {
+			return err
+		}
 	}
 
-	if newvolume.NodeClass != "" {
+	if sourcevolume.IsFilesetBased && sourcevolume.VolType != FILE_SHALLOWCOPY_VOLUME {
+		// The volume is copied by a cluster with nodes mounting both filesystems
+		copyCluster, err := cs.getCopyCluster(ctx, sourcevolume.ClusterId, sourcevolume.FsUUID, newvolume.ClusterId, volFsInfo.UUID, newvolume.NodeClass)
+		if err != nil {
+			return err
+		}
+		newvolume.CopyCluster = &copyCluster
+	} else if newvolume.NodeClass != "" {
 		isValidNodeclass, err := conn.IsValidNodeclass(ctx, newvolume.NodeClass)
 		if err != nil {
 			return err
@@ -1923,6 +2788,120 @@ func (cs *ScaleControllerServer) validateCloneRequest(ctx context.Context, scale
 	return nil
 }
 
+// copyCluster is the cluster copying the content of a volume clone or a
+// snapshot restore, with the names of the source and the target filesystems
+// on that cluster and the nodes running the copy.
+type copyCluster struct {
+	clusterID    string
+	conn         connectors.SpectrumScaleConnector
+	sourceFsName string
+	targetFsName string
+	// nodeclass or comma separated names of the nodes mounting both filesystems
+	nodes string
+}
+
+// getCopyCluster returns the cluster which copies data from the source
+// filesystem to the target filesystem. The cluster must have nodes, in the
+// nodeclass if one is passed, on which both filesystems are mounted. The
+// source cluster is preferred, then the target cluster, then the other
+// clusters of the custom resource, for the filesystems remotely mounted there.
+func (cs *ScaleControllerServer) getCopyCluster(ctx context.Context, sourceClusterID string, sourceFsUUID string, targetClusterID string, targetFsUUID string, nodeClass string) (copyCluster, error) {
+	loggerId := utils.GetLoggerId(ctx)
+	clusterIDs := []string{sourceClusterID}
+	if targetClusterID != sourceClusterID {
+		clusterIDs = append(clusterIDs, targetClusterID)
+	}
+	otherClusterIDs := []string{}
+	for clusterID := range cs.Driver.connmap {
+		if clusterID != "primary" && clusterID != sourceClusterID && clusterID != targetClusterID {
+			otherClusterIDs = append(otherClusterIDs, clusterID)
+		}
+	}
+	sort.Strings(otherClusterIDs)
+	clusterIDs = append(clusterIDs, otherClusterIDs...)
+
+	reasons := []string{}
+	for _, clusterID := range clusterIDs {
+		conn, found := cs.Driver.connmap[clusterID]
+		if !found {
+			continue
+		}
+		candidate := copyCluster{clusterID: clusterID, conn: conn}
+		commonNodes, reason := getCommonMountNodes(ctx, conn, sourceFsUUID, targetFsUUID, &candidate)
+		candidate.nodes = strings.Join(commonNodes, ",")
+		if reason == "" && nodeClass != "" {
+			members, err := conn.GetNodeclassMembers(ctx, nodeClass)
+			if err != nil {
+				reason = fmt.Sprintf("unable to get the members of nodeclass %s: %v", nodeClass, err)
+			} else {
+				commonNodes = intersectNodes(commonNodes, members)
+				if len(commonNodes) == 0 {
+					reason = fmt.Sprintf("no node of nodeclass %s mounts both filesystems", nodeClass)
+				} else if len(commonNodes) == len(members) {
+					candidate.nodes = nodeClass
+				} else {
+					candidate.nodes = strings.Join(commonNodes, ",")
+				}
+			}
+		}
+		if reason == "" {
+			klog.V(4).Infof("[%s] copying from filesystem [%v] to filesystem [%v] on cluster [%v] nodes [%v]", loggerId, candidate.sourceFsName, candidate.targetFsName, clusterID, candidate.nodes)
+			return candidate, nil
+		}
+		reasons = append(reasons, fmt.Sprintf("cluster %s: %s", clusterID, reason))
+	}
+	return copyCluster{}, status.Error(codes.FailedPrecondition, fmt.Sprintf("no cluster has a node which mounts both the source filesystem [%v] and the target filesystem [%v] to copy the data: %s", sourceFsUUID, targetFsUUID, strings.Join(reasons, "; ")))
+}
+
+// getCommonMountNodes returns the nodes of a cluster on which both filesystems
+// are mounted and sets their names on the cluster in copyCluster, or the
+// reason why there is no such node.
+func getCommonMountNodes(ctx context.Context, conn connectors.SpectrumScaleConnector, sourceFsUUID string, targetFsUUID string, candidate *copyCluster) ([]string, string) {
+	var err error
+	candidate.sourceFsName, err = conn.GetFilesystemName(ctx, sourceFsUUID)
+	if err != nil {
+		return nil, fmt.Sprintf("source filesystem is not known: %v", err)
+	}
+	candidate.targetFsName, err = conn.GetFilesystemName(ctx, targetFsUUID)
+	if err != nil {
+		return nil, fmt.Sprintf("target filesystem is not known: %v", err)
+	}
+	sourceMount, err := conn.GetFilesystemMountDetails(ctx, candidate.sourceFsName)
+	if err != nil {
+		return nil, fmt.Sprintf("unable to get mount details of filesystem %s: %v", candidate.sourceFsName, err)
+	}
+	if candidate.targetFsName == candidate.sourceFsName {
+		if len(sourceMount.NodesMounted) == 0 {
+			return nil, fmt.Sprintf("filesystem %s is not mounted on any node", candidate.sourceFsName)
+		}
+		return sourceMount.NodesMounted, ""
+	}
+	targetMount, err := conn.GetFilesystemMountDetails(ctx, candidate.targetFsName)
+	if err != nil {
+		return nil, fmt.Sprintf("unable to get mount details of filesystem %s: %v", candidate.targetFsName, err)
+	}
+	commonNodes := intersectNodes(sourceMount.NodesMounted, targetMount.NodesMounted)
+	if len(commonNodes) == 0 {
+		return nil, fmt.Sprintf("no node mounts both filesystems %s and %s", candidate.sourceFsName, candidate.targetFsName)
+	}
+	return commonNodes, ""
+}
+
+// intersectNodes returns the nodes which are in both lists
+func intersectNodes(nodes []string, otherNodes []string) []string {
+	otherNodeSet := make(map[string]bool, len(otherNodes))
+	for _, node := range otherNodes {
+		otherNodeSet[node] = true
+	}
+	common := []string{}
+	for _, node := range nodes {
+		if otherNodeSet[node] {
+			common = append(common, node)
+		}
+	}
+	return common
+}
+
 func (cs *ScaleControllerServer) GetSnapIdMembers(sId string) (scaleSnapId, error) {
 	splitSid := strings.Split(sId, ";")
 	var sIdMem scaleSnapId
@@ -1978,6 +2957,11 @@ func (cs *ScaleControllerServer) DeleteFilesetVol(ctx context.Context, Filesyste
 			return false, status.Error(codes.Internal, fmt.Sprintf("unable to list snapshot for fileset [%v]. Error: [%v]", FilesetName, err))
 		}
 
+		for _, snapshot := range snapshotList {
+			if strings.HasPrefix(snapshot.SnapshotName, cloneSnapPrefix) {
+				return false, status.Error(codes.FailedPrecondition, fmt.Sprintf("volume fileset [%v] contains snapshot [%v] holding the file clones of volume [%v], delete that volume first", FilesetName, snapshot.SnapshotName, strings.TrimPrefix(snapshot.SnapshotName, cloneSnapPrefix)))
+			}
+		}
 		if len(snapshotList) > 0 {
 			return false, status.Error(codes.Internal, fmt.Sprintf("volume fileset [%v] contains one or more snapshot, delete snapshot/volumesnapshot", FilesetName))
 		}
@@ -2026,7 +3010,7 @@ func (cs *ScaleControllerServer) DeleteCGFileset(ctx context.Context, Filesystem
 	}
 
 	// Check if fileset was created by IBM Storage Scale CSI Driver
-	if filesetDetails.Config.Comment == connectors.FilesetComment {
+	if connectors.IsCSIFilesetComment(filesetDetails.Config.Comment) {
 		// before deletion of fileset get its inodeSpace.
 		// this will help to identify if there are one or more dependent filesets for same inodeSpace
 		// which is shared with independent fileset
@@ -2086,6 +3070,17 @@ func (cs *ScaleControllerServer) DeleteVolume(ctx context.Context, req *csi.Dele
 		return nil, err
 	}
 
+	// Cancel the copy of the content of a volume clone or a snapshot restore
+	// which is still running, if the volume is deleted before its PVC
+	copyVolName := filepath.Base(volumeIdMembers.Path)
+	if volumeIdMembers.IsFilesetBased {
+		copyVolName = volumeIdMembers.FsetName
+	}
+	if err := cs.cancelCopyJob(ctx, copyVolName); err != nil {
+		return nil, err
+	}
+	cs.Driver.copyjobscancelled.Delete(copyVolName)
+
 	primaryConn, isprimaryConnPresent := cs.Driver.connmap["primary"]
 	if !isprimaryConnPresent {
 		klog.Errorf("[%s] unable to get connector for primary cluster", loggerId)
@@ -2181,6 +3176,18 @@ func (cs *ScaleControllerServer) DeleteVolume(ctx context.Context, req *csi.Dele
 			pvName := filepath.Base(relPath)
 
 			if pvName == FilesetName {
+				if volumeIdMembers.StorageClassType == STORAGECLASS_CACHE {
+					if err := drainCacheQueue(ctx, conn, FilesystemName, FilesetName); err != nil {
+						return nil, err
+					}
+				}
+
+				if volumeIdMembers.StorageClassType == STORAGECLASS_CLASSIC {
+					if err := checkVolumeRetention(ctx, conn, FilesystemName, FilesetName); err != nil {
+						return nil, err
+					}
+				}
+
 				checkForSnapshots := false
 				if volumeIdMembers.VolType == FILE_INDEPENDENTFILESET_VOLUME {
 					checkForSnapshots = true
@@ -2190,8 +3197,17 @@ func (cs *ScaleControllerServer) DeleteVolume(ctx context.Context, req *csi.Dele
 					return nil, err
 				}
 
-				// Delete fileset related symlink
+				// The clones of the volume are deleted with its fileset, the
+				// snapshot of the source volume holding their parents can go
+				if err := deleteCloneSnapshots(ctx, conn, FilesystemName, FilesetName); err != nil {
+					return nil, err
+				}
+
+				// Delete fileset related policy rules and symlink
 				if volumeIdMembers.StorageClassType == STORAGECLASS_CLASSIC {
+					if err := deleteVolumePolicies(ctx, conn, FilesystemName, FilesetName); err != nil {
+						return nil, err
+					}
 					err = primaryConn.DeleteSymLnk(ctx, cs.Driver.primary.GetPrimaryFs(), relPath)
 					if err != nil {
 						return nil, status.Error(codes.Internal, fmt.Sprintf("unable to delete symlnk [%v:%v] Error [%v]", cs.Driver.primary.GetPrimaryFs(), relPath, err))
@@ -2207,8 +3223,9 @@ func (cs *ScaleControllerServer) DeleteVolume(ctx context.Context, req *csi.Dele
 					}
 				}
 
-				// Delete bucket keys for a cache volume
-				if volumeIdMembers.StorageClassType == STORAGECLASS_CACHE {
+				// Delete bucket keys for a cache volume, an NFS or GPFS home has no keys
+				_, isFileHome, _ := getFileCacheHomeTarget(req.Secrets[connectors.BucketEndpoint])
+				if volumeIdMembers.StorageClassType == STORAGECLASS_CACHE && !isFileHome {
 					bucketName := req.Secrets[connectors.BucketName]
 					endpoint := req.Secrets[connectors.BucketEndpoint]
 					parsedURL, err := url.Parse(endpoint)
@@ -2332,7 +3349,7 @@ func (cs *ScaleControllerServer) ValidateVolumeCapabilities(ctx context.Context,
 	}
 
 	for _, cap := range req.VolumeCapabilities {
-		if cap.GetAccessMode().GetMode() != csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER {
+		if !cs.Driver.isVolumeCapabilityAccessModeSupported(cap.GetAccessMode().GetMode()) {
 			return &csi.ValidateVolumeCapabilitiesResponse{Message: ""}, nil
 		}
 	}
@@ -2354,11 +3371,19 @@ func (cs *ScaleControllerServer) ControllerUnpublishVolume(ctx context.Context,
 	}
 
 	volumeID := req.GetVolumeId()
-	_, err := getVolIDMembers(volumeID)
+	volumeIDMembers, err := getVolIDMembers(volumeID)
 	if err != nil {
 		return nil, status.Error(codes.InvalidArgument, "ControllerUnpublishVolume : VolumeID is not in proper format")
 	}
 
+	cs.Driver.attachments.detach(volumeID, req.GetNodeId())
+
+	// The filesystems mounted by ControllerPublishVolume are unmounted once
+	// no volume using them is published to the node
+	if utils.GetEnv(SKIP_MOUNT_UNMOUNT, yes) == no {
+		cs.Driver.scheduleUnmounts(ctx, req.GetNodeId(), volumeIDMembers.FsUUID)
+	}
+
 	return &csi.ControllerUnpublishVolumeResponse{}, nil
 }
 
@@ -2399,6 +3424,28 @@ func (cs *ScaleControllerServer) ControllerPublishVolume(ctx context.Context, re
 		return nil, status.Error(codes.InvalidArgument, "ControllerPublishVolume : VolumeID is not in proper format")
 	}
 
+	// A volume with a single node access mode is published to one node at a
+	// time, the publish is released if it fails
+	accessMode := req.GetVolumeCapability().GetAccessMode().GetMode()
+	if !cs.Driver.isVolumeCapabilityAccessModeSupported(accessMode) && accessMode != csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("ControllerPublishVolume : access mode %v is not supported", accessMode))
+	}
+	if err := checkVolumeNotReverted(ctx, cs.Driver.name, volumeID); err != nil {
+		return nil, err
+	}
+	newAttachment, err := cs.Driver.attachments.attach(ctx, cs.Driver.name, volumeID, nodeID, accessMode)
+	if err != nil {
+		return nil, err
+	}
+	published := false
+	defer func() {
+		if newAttachment && !published {
+			cs.Driver.attachments.detach(volumeID, nodeID)
+		} else {
+			cs.Driver.attachments.published(volumeID, nodeID)
+		}
+	}()
+
 	filesystemID := volumeIDMembers.FsUUID
 	volumePath := volumeIDMembers.Path
 
@@ -2415,6 +3462,13 @@ func (cs *ScaleControllerServer) ControllerPublishVolume(ctx context.Context, re
 
 	//Check if primary filesystem is mounted.
 	primaryfsName := cs.Driver.primary.GetPrimaryFs()
+	if skipMountUnmount == no {
+		// Cancel the pending unmounts of the filesystems from the node and
+		// wait for a running one before checking the mounts
+		cs.Driver.rescheduleUnmounts(ctx)
+		unlockMounts := cs.Driver.lockMounts(nodeID, primaryfsName, fsName)
+		defer unlockMounts()
+	}
 	pfsMount, err := cs.Driver.connmap["primary"].GetFilesystemMountDetails(ctx, primaryfsName)
 	if err != nil {
 		klog.Errorf("[%s] ControllerPublishVolume : Error in getting filesystem mount details for %s", loggerId, primaryfsName)
@@ -2422,9 +3476,19 @@ func (cs *ScaleControllerServer) ControllerPublishVolume(ctx context.Context, re
 	}
 
 	// Node mapping check
-	scalenodeID := getNodeMapping(nodeID)
+	scalenodeID := cs.Driver.getScaleNodeName(ctx, nodeID)
 	klog.Infof("[%s] ControllerUnpublishVolume : scalenodeID:%s --known as-- k8snodeName: %s", loggerId, scalenodeID, nodeID)
 
+	// A mount on a node with an unhealthy GPFS daemon would hang, the health
+	// of the filesystems is checked once they are mounted
+	if err := cs.Driver.checkPublishNodeHealth(ctx, scalenodeID); err != nil {
+		return nil, err
+	}
+	healthCheckFs := []string{primaryfsName}
+	if fsName != primaryfsName {
+		healthCheckFs = append(healthCheckFs, fsName)
+	}
+
 	shortnameNodeMapping := utils.GetEnv(SHORTNAME_NODE_MAPPING, no)
 	if shortnameNodeMapping == yes {
 		klog.V(4).Infof("[%s] ControllerPublishVolume : SHORTNAME_NODE_MAPPING is set to %s", loggerId, shortnameNodeMapping)
@@ -2484,6 +3548,10 @@ func (cs *ScaleControllerServer) ControllerPublishVolume(ctx context.Context, re
 
 	if isFsMounted && ispFsMounted {
 		klog.V(4).Infof("[%s] ControllerPublishVolume : %s and %s are mounted on %s so returning success", loggerId, fsName, primaryfsName, scalenodeID)
+		if err := cs.Driver.checkPublishFilesystemHealth(ctx, scalenodeID, healthCheckFs); err != nil {
+			return nil, err
+		}
+		published = true
 		return &csi.ControllerPublishVolumeResponse{}, nil
 	}
 
@@ -2500,6 +3568,9 @@ func (cs *ScaleControllerServer) ControllerPublishVolume(ctx context.Context, re
 			klog.Errorf("[%s] ControllerPublishVolume : Error in mounting filesystem %s on node %s", loggerId, primaryfsName, scalenodeID)
 			return nil, status.Error(codes.Internal, fmt.Sprintf("ControllerPublishVolume :  Error in mounting filesystem %s on node %s. Error [%v]", primaryfsName, scalenodeID, err))
 		}
+		if err := updateMountedFilesystems(ctx, nodeID, primaryfsName, true); err != nil {
+			klog.Warningf("[%s] ControllerPublishVolume : unable to record filesystem %s as mounted on node %s by the driver, it is not unmounted when unused. Error [%v]", loggerId, primaryfsName, nodeID, err)
+		}
 	}
 
 	//mount the volume filesystem if mounted
@@ -2510,7 +3581,17 @@ func (cs *ScaleControllerServer) ControllerPublishVolume(ctx context.Context, re
 			klog.Errorf("[%s] ControllerPublishVolume : Error in mounting filesystem %s on node %s", loggerId, fsName, scalenodeID)
 			return nil, status.Error(codes.Internal, fmt.Sprintf("ControllerPublishVolume : Error in mounting filesystem %s on node %s. Error [%v]", fsName, scalenodeID, err))
 		}
+		if err := updateMountedFilesystems(ctx, nodeID, fsName, true); err != nil {
+			klog.Warningf("[%s] ControllerPublishVolume : unable to record filesystem %s as mounted on node %s by the driver, it is not unmounted when unused. Error [%v]", loggerId, fsName, nodeID, err)
+		}
 	}
+
+	// The cached filesystem health states predate the mounts
+	cs.Driver.nodeHealth.invalidate(scalenodeID, healthComponentFilesystem)
+	if err := cs.Driver.checkPublishFilesystemHealth(ctx, scalenodeID, healthCheckFs); err != nil {
+		return nil, err
+	}
+	published = true
 	return &csi.ControllerPublishVolumeResponse{}, nil
 }
 
@@ -2593,7 +3674,10 @@ func (cs *ScaleControllerServer) CreateSnapshot(ctx context.Context, req *csi.Cr
 	}
 
 	if !volumeIDMembers.IsFilesetBased {
-		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("CreateSnapshot - volume [%s] - Volume snapshot can only be created when source volume is fileset", volID))
+		if volumeIDMembers.StorageClassType == STORAGECLASS_ADVANCED {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("CreateSnapshot - volume [%s] - Volume snapshot can only be created when source volume is dependent fileset for new storageClass", volID))
+		}
+		return cs.createLWVolSnapshot(ctx, req, volumeIDMembers)
 	}
 
 	if (volumeIDMembers.StorageClassType == STORAGECLASS_ADVANCED) && (volumeIDMembers.VolType != FILE_DEPENDENTFILESET_VOLUME) {
@@ -2754,7 +3838,7 @@ func (cs *ScaleControllerServer) CreateSnapshot(ctx context.Context, req *csi.Cr
 		// storageclass_type;volumeType;clusterId;FSUUID;consistency_group;filesetName;snapshotName;metaSnapshotName
 		snapID = fmt.Sprintf("%s;%s;%s;%s;%s;%s;%s;%s", volumeIDMembers.StorageClassType, volumeIDMembers.VolType, volumeIDMembers.ClusterId, volumeIDMembers.FsUUID, filesetName, filesetResp.FilesetName, snapName, req.GetName())
 	} else {
-		if filesetResp.Config.Comment == connectors.FilesetComment &&
+		if connectors.IsCSIFilesetComment(filesetResp.Config.Comment) &&
 			(cs.Driver.primary.PrimaryFset != filesetName || cs.Driver.primary.PrimaryFs != filesystemName) {
 			// Dynamically created PVC, here path is the xxx-data directory within the fileset where all volume data resides
 			// storageclass_type;volumeType;clusterId;FSUUID;consistency_group;filesetName;snapshotName;metaSnapshotName;path
@@ -2797,6 +3881,183 @@ func (cs *ScaleControllerServer) CreateSnapshot(ctx context.Context, req *csi.Cr
 	}, nil
 }
 
+// createLWVolSnapshot creates a snapshot for a lightweight volume. As a
+// directory can not be snapshotted on its own, a snapshot of the independent
+// fileset containing the volume directory is taken and the path of the volume
+// directory within that fileset is recorded in the snapshot ID, so that only
+// the volume directory is copied when a volume is created from the snapshot.
+func (cs *ScaleControllerServer) createLWVolSnapshot(ctx context.Context, req *csi.CreateSnapshotRequest, volumeIDMembers scaleVolId) (*csi.CreateSnapshotResponse, error) {
+	loggerId := utils.GetLoggerId(ctx)
+	volID := req.GetSourceVolumeId()
+	snapName := req.GetName()
+	klog.V(4).Infof("[%s] CreateSnapshot - creating snapshot [%s] for lightweight volume [%s]", loggerId, snapName, volID)
+
+	conn, err := cs.getConnFromClusterID(ctx, volumeIDMembers.ClusterId)
+	if err != nil {
+		return nil, err
+	}
+	assembledScaleversion, err := cs.assembledScaleVersion(ctx, conn)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("the  IBM Storage Scale version check for permissions failed with error %s", err))
+	}
+	/* Check if IBM Storage Scale supports Snapshot */
+	chkSnapshotErr := cs.checkSnapshotSupport(assembledScaleversion)
+	if chkSnapshotErr != nil {
+		return nil, chkSnapshotErr
+	}
+
+	primaryConn, isprimaryConnPresent := cs.Driver.connmap["primary"]
+	if !isprimaryConnPresent {
+		klog.Errorf("[%s] CreateSnapshot - unable to get connector for primary cluster", loggerId)
+		return nil, status.Error(codes.Internal, "CreateSnapshot - unable to find primary cluster details in custom resource")
+	}
+
+	filesystemName, err := primaryConn.GetFilesystemName(ctx, volumeIDMembers.FsUUID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("CreateSnapshot - Unable to get filesystem Name for Filesystem Uid [%v] and clusterId [%v]. Error [%v]", volumeIDMembers.FsUUID, volumeIDMembers.ClusterId, err))
+	}
+
+	mountInfo, err := primaryConn.GetFilesystemMountDetails(ctx, filesystemName)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("CreateSnapshot - unable to get mount info for FS [%v] in primary cluster", filesystemName))
+	}
+
+	volDirPath, err := cs.getLWVolDirPath(ctx, primaryConn, volumeIDMembers)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(volDirPath, mountInfo.MountPoint) {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("CreateSnapshot - volume directory [%v] is not under the mount point [%v] of filesystem [%v]", volDirPath, mountInfo.MountPoint, filesystemName))
+	}
+	volDirRelPath := strings.Trim(strings.Replace(volDirPath, mountInfo.MountPoint, "", 1), "!/")
+
+	filesystemName = getRemoteFsName(mountInfo.RemoteDeviceName)
+	filesetName, filesetRelPath, err := cs.getContainingIndependentFileset(ctx, conn, filesystemName, volDirRelPath)
+	if err != nil {
+		return nil, err
+	}
+	pathInFileset := strings.Trim(strings.TrimPrefix(volDirRelPath, filesetRelPath), "/")
+	if pathInFileset == "" {
+		pathInFileset = "/"
+	}
+	klog.V(4).Infof("[%s] CreateSnapshot - volume directory [%s] of lightweight volume [%s] is in fileset [%s:%s] at path [%s]", loggerId, volDirPath, volID, filesystemName, filesetName, pathInFileset)
+
+	snapExist, err := conn.CheckIfSnapshotExist(ctx, filesystemName, filesetName, snapName)
+	if err != nil {
+		klog.Errorf("[%s] CreateSnapshot [%s] - Unable to get the snapshot details. Error [%v]", loggerId, snapName, err)
+		return nil, status.Error(codes.Internal, fmt.Sprintf("Unable to get the snapshot details for [%s]. Error [%v]", snapName, err))
+	}
+
+	if !snapExist {
+		snapshotList, err := conn.ListFilesetSnapshots(ctx, filesystemName, filesetName)
+		if err != nil {
+			klog.Errorf("[%s] CreateSnapshot [%s] - unable to list snapshots for fileset [%s:%s]. Error: [%v]", loggerId, snapName, filesystemName, filesetName, err)
+			return nil, status.Error(codes.Internal, fmt.Sprintf("unable to list snapshots for fileset [%s:%s]. Error: [%v]", filesystemName, filesetName, err))
+		}
+
+		if len(snapshotList) >= 256 {
+			klog.Errorf("[%s] CreateSnapshot [%s] - max limit of snapshots reached for fileset [%s:%s]. No more snapshots can be created for this fileset.", loggerId, snapName, filesystemName, filesetName)
+			return nil, status.Error(codes.OutOfRange, fmt.Sprintf("max limit of snapshots reached for fileset [%s:%s]. No more snapshots can be created for this fileset.", filesystemName, filesetName))
+		}
+
+		snaperr := conn.CreateSnapshot(ctx, filesystemName, filesetName, snapName)
+		if snaperr != nil {
+			klog.Errorf("[%s] Snapshot [%s] - Unable to create snapshot. Error [%v]", loggerId, snapName, snaperr)
+			return nil, status.Error(codes.Internal, fmt.Sprintf("unable to create snapshot [%s]. Error [%v]", snapName, snaperr))
+		}
+	}
+
+	// storageclass_type;volumeType;clusterId;FSUUID;consistency_group;filesetName;snapshotName;metaSnapshotName;path
+	snapID := fmt.Sprintf("%s;%s;%s;%s;%s;%s;%s;%s;%s", STORAGECLASS_CLASSIC, FILE_DIRECTORYBASED_VOLUME, volumeIDMembers.ClusterId, volumeIDMembers.FsUUID, "", filesetName, snapName, "", pathInFileset)
+
+	timestamp, err := cs.getSnapshotCreateTimestamp(ctx, conn, filesystemName, filesetName, snapName)
+	if err != nil {
+		klog.Errorf("[%s] Error getting create timestamp for snapshot %s:%s:%s", loggerId, filesystemName, filesetName, snapName)
+		return nil, err
+	}
+
+	// Lightweight volumes have no quota, so the restore size is not known
+	return &csi.CreateSnapshotResponse{
+		Snapshot: &csi.Snapshot{
+			SnapshotId:     snapID,
+			SourceVolumeId: volID,
+			ReadyToUse:     true,
+			CreationTime:   &timestamp,
+			SizeBytes:      0,
+		},
+	}, nil
+}
+
+// getLWVolDirPath returns the absolute path of the directory of a lightweight
+// volume. The volume path is a symlink in the primary fileset for the volumes
+// created by the driver, in which case the symlink target is returned.
+func (cs *ScaleControllerServer) getLWVolDirPath(ctx context.Context, primaryConn connectors.SpectrumScaleConnector, volumeIDMembers scaleVolId) (string, error) {
+	loggerId := utils.GetLoggerId(ctx)
+	primaryFSMountPoint, err := cs.getPrimaryFSMountPoint(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(volumeIDMembers.Path, primaryFSMountPoint) {
+		return volumeIDMembers.Path, nil
+	}
+
+	relPath := strings.Trim(strings.Replace(volumeIDMembers.Path, primaryFSMountPoint, "", 1), "!/")
+	statInfo, err := primaryConn.StatDirectory(ctx, cs.Driver.primary.GetPrimaryFs(), relPath)
+	if err != nil {
+		klog.Errorf("[%s] unable to stat directory using FS [%s] at path [%s]. Error [%v]", loggerId, cs.Driver.primary.GetPrimaryFs(), relPath, err)
+		return "", status.Error(codes.Internal, fmt.Sprintf("unable to stat directory using FS [%s] at path [%s]. Error [%v]", cs.Driver.primary.GetPrimaryFs(), relPath, err))
+	}
+
+	target, isSymlink := parseStatSymlinkTarget(statInfo)
+	if !isSymlink {
+		return volumeIDMembers.Path, nil
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(volumeIDMembers.Path), target)
+	}
+	return filepath.Clean(target), nil
+}
+
+// getContainingIndependentFileset returns the name and the junction path,
+// relative to the filesystem mount point, of the independent fileset which
+// contains the directory dirRelPath of the filesystem.
+func (cs *ScaleControllerServer) getContainingIndependentFileset(ctx context.Context, conn connectors.SpectrumScaleConnector, filesystemName string, dirRelPath string) (string, string, error) {
+	loggerId := utils.GetLoggerId(ctx)
+	fsDetails, err := conn.GetFilesystemDetails(ctx, filesystemName)
+	if err != nil {
+		klog.Errorf("[%s] unable to get details for filesystem [%s]. Error [%v]", loggerId, filesystemName, err)
+		return "", "", status.Error(codes.Internal, fmt.Sprintf("unable to get details for filesystem [%s]. Error [%v]", filesystemName, err))
+	}
+
+	filesets, err := conn.ListFilesets(ctx, filesystemName, connectors.FilesetFilter{IndependentOnly: true})
+	if err != nil {
+		klog.Errorf("[%s] unable to list independent filesets of filesystem [%s]. Error [%v]", loggerId, filesystemName, err)
+		return "", "", status.Error(codes.Internal, fmt.Sprintf("unable to list independent filesets of filesystem [%s]. Error [%v]", filesystemName, err))
+	}
+
+	filesetName := ""
+	filesetRelPath := ""
+	for _, fileset := range filesets {
+		// unlinked filesets have no junction path
+		if !strings.HasPrefix(fileset.Config.Path, fsDetails.Mount.MountPoint) {
+			continue
+		}
+		relPath := strings.Trim(strings.Replace(fileset.Config.Path, fsDetails.Mount.MountPoint, "", 1), "!/")
+		if relPath != "" && relPath != dirRelPath && !strings.HasPrefix(dirRelPath, relPath+"/") {
+			continue
+		}
+		if filesetName == "" || len(relPath) > len(filesetRelPath) {
+			filesetName = fileset.FilesetName
+			filesetRelPath = relPath
+		}
+	}
+
+	if filesetName == "" {
+		return "", "", status.Error(codes.Internal, fmt.Sprintf("unable to find the independent fileset containing the directory [%s] in filesystem [%s]", dirRelPath, filesystemName))
+	}
+	return filesetName, filesetRelPath, nil
+}
+
 func (cs *ScaleControllerServer) getSnapshotCreateTimestamp(ctx context.Context, conn connectors.SpectrumScaleConnector, fs string, fset string, snap string) (timestamppb.Timestamp, error) {
 	var timestamp timestamppb.Timestamp
 
@@ -2806,35 +4067,125 @@ func (cs *ScaleControllerServer) getSnapshotCreateTimestamp(ctx context.Context,
 		return timestamp, err
 	}
 
-	timezoneOffset, err := conn.GetTimeZoneOffset(ctx)
+	t, err := parseClusterTimestamp(ctx, conn, createTS)
 	if err != nil {
-		klog.Errorf("[%s] snapshot [%s] - Unable to get cluster timezone", utils.GetLoggerId(ctx), snap)
+		klog.Errorf("[%s] snapshot - for fileset [%s:%s] error in parsing timestamp: [%v]. Error: [%v]", utils.GetLoggerId(ctx), fs, fset, createTS, err)
 		return timestamp, err
 	}
+	timestamp.Seconds = t.Unix()
+	timestamp.Nanos = 0
+
+	klog.Infof("[%s] getSnapshotCreateTimestamp: for fileset [%s:%s] snapshot creation timestamp: [%v]", utils.GetLoggerId(ctx), fs, fset, t)
+	return timestamp, nil
+}
+
+// parseClusterTimestamp parses a timestamp returned by the REST API in the
+// timezone of the cluster.
+func parseClusterTimestamp(ctx context.Context, conn connectors.SpectrumScaleConnector, ts string) (time.Time, error) {
+	timezoneOffset, err := conn.GetTimeZoneOffset(ctx)
+	if err != nil {
+		klog.Errorf("[%s] Unable to get cluster timezone", utils.GetLoggerId(ctx))
+		return time.Time{}, err
+	}
 
 	// for GMT, REST API returns Z instead of 00:00
 	if timezoneOffset == "Z" {
 		timezoneOffset = "+00:00"
 	}
 
-	// Rest API returns create timestamp in the format 2006-01-02 15:04:05,000
+	// Rest API returns timestamps in the format 2006-01-02 15:04:05,000
 	// irrespective of the cluster timezone. We replace the last part of this date
 	// with the timezone offset returned by cluster config REST API and then parse
 	// the timestamp with correct zone info
 	const longForm = "2006-01-02 15:04:05-07:00"
 	//nolint::staticcheck
 
-	createTSTZ := strings.Replace(createTS, ",000", timezoneOffset, 1)
-	t, err := time.Parse(longForm, createTSTZ)
+	tsTZ := strings.Replace(ts, ",000", timezoneOffset, 1)
+	return time.Parse(longForm, tsTZ)
+}
+
+// formatClusterTimestamp formats a time in the timezone of the cluster in the
+// yyyy-mm-dd-hh:mm:ss format of the snapshot expiration time.
+func formatClusterTimestamp(ctx context.Context, conn connectors.SpectrumScaleConnector, t time.Time) (string, error) {
+	timezoneOffset, err := conn.GetTimeZoneOffset(ctx)
 	if err != nil {
-		klog.Errorf("[%s] snapshot - for fileset [%s:%s] error in parsing timestamp: [%v]. Error: [%v]", utils.GetLoggerId(ctx), fs, fset, createTS, err)
-		return timestamp, err
+		klog.Errorf("[%s] Unable to get cluster timezone", utils.GetLoggerId(ctx))
+		return "", err
 	}
-	timestamp.Seconds = t.Unix()
-	timestamp.Nanos = 0
+	if timezoneOffset == "Z" {
+		timezoneOffset = "+00:00"
+	}
+	zone, err := time.Parse("-07:00", timezoneOffset)
+	if err != nil {
+		return "", err
+	}
+	return t.In(zone.Location()).Format("2006-01-02-15:04:05"), nil
+}
 
-	klog.Infof("[%s] getSnapshotCreateTimestamp: for fileset [%s:%s] snapshot creation timestamp: [%v]", utils.GetLoggerId(ctx), fs, fset, createTSTZ)
-	return timestamp, nil
+// createRetentionSnapshot takes a snapshot of the fileset of an immutable
+// volume expiring at the end of its retention period. The snapshot can not be
+// deleted before it expires, and the fileset can not be deleted while it has
+// snapshots, so the volume is retained by the cluster itself.
+func createRetentionSnapshot(ctx context.Context, scVol *scaleVolume) error {
+	loggerId := utils.GetLoggerId(ctx)
+	expirationTime, err := formatClusterTimestamp(ctx, scVol.Connector, time.Now().AddDate(0, 0, scVol.RetentionDays))
+	if err != nil {
+		return status.Error(codes.Internal, fmt.Sprintf("unable to get the expiration time of the retention snapshot of fileset [%v]. Error [%v]", scVol.VolName, err))
+	}
+	err = scVol.Connector.CreateExpiringSnapshot(ctx, scVol.VolBackendFs, scVol.VolName, retentionSnapName, expirationTime)
+	if err != nil {
+		klog.Errorf("[%s] volume:[%v] - unable to create retention snapshot expiring at [%v]. Error: %v", loggerId, scVol.VolName, expirationTime, err)
+		return status.Error(codes.Internal, fmt.Sprintf("unable to create the retention snapshot of fileset [%v] expiring at [%v]. Error [%v]", scVol.VolName, expirationTime, err))
+	}
+	klog.Infof("[%s] volume:[%v] - retained until [%v]", loggerId, scVol.VolName, expirationTime)
+	return nil
+}
+
+// getSnapshotExpiry returns the expiration time of a snapshot, and false if
+// the snapshot has no expiration time.
+func getSnapshotExpiry(ctx context.Context, conn connectors.SpectrumScaleConnector, fs string, fset string, snapName string) (time.Time, bool, error) {
+	expirationTime, err := conn.GetSnapshotExpirationTime(ctx, fs, fset, snapName)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("unable to get expiration time of snapshot [%v] of fileset [%v]: %v", snapName, fset, err)
+	}
+	if expirationTime == "" {
+		return time.Time{}, false, nil
+	}
+	expiry, err := parseClusterTimestamp(ctx, conn, expirationTime)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("unable to parse expiration time [%v] of snapshot [%v]: %v", expirationTime, snapName, err)
+	}
+	return expiry, true, nil
+}
+
+// checkVolumeRetention refuses the deletion of the fileset of an immutable
+// volume until its retention snapshot expires, and then deletes the snapshot.
+func checkVolumeRetention(ctx context.Context, conn connectors.SpectrumScaleConnector, fs string, fset string) error {
+	loggerId := utils.GetLoggerId(ctx)
+	exists, err := conn.CheckIfSnapshotExist(ctx, fs, fset, retentionSnapName)
+	if err != nil {
+		if strings.Contains(err.Error(), fsetNotFoundErrCode) ||
+			strings.Contains(err.Error(), fsetNotFoundErrMsg) {
+			return nil
+		}
+		return status.Error(codes.Internal, fmt.Sprintf("unable to check the retention snapshot of fileset [%v] of filesystem [%v]. Error [%v]", fset, fs, err))
+	}
+	if !exists {
+		return nil
+	}
+
+	expiry, expires, err := getSnapshotExpiry(ctx, conn, fs, fset, retentionSnapName)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if expires && time.Now().Before(expiry) {
+		klog.Errorf("[%s] fileset [%v] of filesystem [%v] is retained until [%v]", loggerId, fset, fs, expiry)
+		return status.Error(codes.FailedPrecondition, fmt.Sprintf("volume fileset [%v] cannot be deleted before its retention period expires at [%v]", fset, expiry))
+	}
+	if err := conn.DeleteSnapshot(ctx, fs, fset, retentionSnapName); err != nil {
+		return status.Error(codes.Internal, fmt.Sprintf("unable to delete the expired retention snapshot of fileset [%v]. Error [%v]", fset, err))
+	}
+	return nil
 }
 
 func (cs *ScaleControllerServer) getSnapRestoreSize(ctx context.Context, conn connectors.SpectrumScaleConnector, filesystemName string, filesetName string) (int64, error) {
@@ -2920,6 +4271,17 @@ func parseStatDirInfo(statInfo string) (int, error) {
 	return nlink, err
 }
 
+// parseStatSymlinkTarget returns the target of a symlink from the stat
+// output, which starts with "File: <link> -> <target>" for a symlink.
+func parseStatSymlinkTarget(statInfo string) (string, bool) {
+	firstLine := strings.TrimSpace(strings.Split(statInfo, "\n")[0])
+	_, target, isSymlink := strings.Cut(firstLine, " -> ")
+	if !isSymlink {
+		return "", false
+	}
+	return strings.Trim(strings.TrimSpace(target), "'\"‘’"), true
+}
+
 // DeleteSnapshot - Delete snapshot
 func (cs *ScaleControllerServer) DeleteSnapshot(ctx context.Context, req *csi.DeleteSnapshotRequest) (*csi.DeleteSnapshotResponse, error) {
 	loggerId := utils.GetLoggerId(ctx)
@@ -2987,6 +4349,10 @@ func (cs *ScaleControllerServer) DeleteSnapshot(ctx context.Context, req *csi.De
 
 		// skip delete snapshot if not exist, return success
 		if snapExist {
+			if err := checkCloneParentSnapshot(ctx, snapID); err != nil {
+				return nil, err
+			}
+
 			if snapIdMembers.StorageClassType == STORAGECLASS_CLASSIC {
 				shallowCopyRefPath = fmt.Sprintf("%s/%s", snapIdMembers.FsetName, snapIdMembers.SnapName)
 			}
@@ -3039,6 +4405,10 @@ func (cs *ScaleControllerServer) DeleteSnapshot(ctx context.Context, req *csi.De
 				snaperr := conn.DeleteSnapshot(ctx, filesystemName, filesetName, snapIdMembers.SnapName)
 				if snaperr != nil {
 					klog.Errorf("[%s] DeleteSnapshot - error deleting snapshot %s: %v", loggerId, snapIdMembers.SnapName, snaperr)
+					// Snapshots of immutable filesets cannot be deleted before they expire
+					if expiry, expires, err := getSnapshotExpiry(ctx, conn, filesystemName, filesetName, snapIdMembers.SnapName); err == nil && expires && time.Now().Before(expiry) {
+						return nil, status.Error(codes.FailedPrecondition, fmt.Sprintf("snapshot [%v] of fileset [%v] cannot be deleted before it expires at [%v]", snapIdMembers.SnapName, filesetName, expiry))
+					}
 					return nil, snaperr
 				}
 				klog.Infof("[%s] DeleteSnapshot - successfully deleted snapshot [%s] from fileset [%s] under filesystem [%s]", loggerId, snapIdMembers.SnapName, filesetName, filesystemName)
@@ -3182,8 +4552,134 @@ func (cs *ScaleControllerServer) ControllerExpandVolume(ctx context.Context, req
 	}, nil
 }
 
+// ControllerGetVolume reports the condition of a volume. For an AFM-DR primary
+// fileset the replication lag is reported, which is the age of the most recent
+// RPO snapshot of the fileset.
 func (cs *ScaleControllerServer) ControllerGetVolume(ctx context.Context, req *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+	loggerId := utils.GetLoggerId(ctx)
+	klog.V(4).Infof("[%s] ControllerGetVolume - Volume get req: %v", loggerId, req)
+
+	if err := cs.Driver.ValidateControllerServiceRequest(ctx, csi.ControllerServiceCapability_RPC_GET_VOLUME); err != nil {
+		klog.Errorf("[%s] ControllerGetVolume - invalid get volume req: %v", loggerId, req)
+		return nil, status.Error(codes.Internal, fmt.Sprintf("ControllerGetVolume ValidateControllerServiceRequest failed: %v", err))
+	}
+
+	volID := req.GetVolumeId()
+	if len(volID) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "volume ID missing in request")
+	}
+
+	volumeIDMembers, err := getVolIDMembers(volID)
+	if err != nil {
+		klog.Errorf("[%s] ControllerGetVolume - Error in Volume ID %v: %v", loggerId, volID, err)
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("ControllerGetVolume - Error in Volume ID %v: %v", volID, err))
+	}
+
+	condition := &csi.VolumeCondition{Abnormal: false, Message: "volume is healthy"}
+	if volumeIDMembers.IsFilesetBased && volumeIDMembers.VolType != FILE_SHALLOWCOPY_VOLUME {
+		condition, err = cs.getFilesetVolCondition(ctx, volumeIDMembers)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &csi.ControllerGetVolumeResponse{
+		Volume: &csi.Volume{
+			VolumeId: volID,
+		},
+		Status: &csi.ControllerGetVolumeResponse_VolumeStatus{
+			VolumeCondition: condition,
+		},
+	}, nil
+}
+
+// getFilesetVolCondition returns the condition of a fileset based volume,
+// including the replication lag if the fileset is an AFM-DR primary.
+func (cs *ScaleControllerServer) getFilesetVolCondition(ctx context.Context, volumeIDMembers scaleVolId) (*csi.VolumeCondition, error) {
+	loggerId := utils.GetLoggerId(ctx)
+	conn, err := cs.getConnFromClusterID(ctx, volumeIDMembers.ClusterId)
+	if err != nil {
+		return nil, err
+	}
+
+	filesystemName, err := conn.GetFilesystemName(ctx, volumeIDMembers.FsUUID)
+	if err != nil {
+		klog.Errorf("[%s] ControllerGetVolume - unable to get filesystem Name for Filesystem Uid [%v] and clusterId [%v]. Error [%v]", loggerId, volumeIDMembers.FsUUID, volumeIDMembers.ClusterId, err)
+		return nil, status.Error(codes.Internal, fmt.Sprintf("ControllerGetVolume - unable to get filesystem Name for Filesystem Uid [%v] and clusterId [%v]. Error [%v]", volumeIDMembers.FsUUID, volumeIDMembers.ClusterId, err))
+	}
+
+	filesetName := volumeIDMembers.FsetName
+	filesetInfo, err := conn.ListFileset(ctx, filesystemName, filesetName)
+	if err != nil {
+		if strings.Contains(err.Error(), "Invalid value in 'filesetName'") {
+			return nil, status.Error(codes.NotFound, fmt.Sprintf("ControllerGetVolume - fileset [%v] does not exist in filesystem [%v]", filesetName, filesystemName))
+		}
+		return nil, status.Error(codes.Internal, fmt.Sprintf("ControllerGetVolume - unable to list fileset [%v] in filesystem [%v]. Error: %v", filesetName, filesystemName, err))
+	}
+
+	if filesetInfo.Config.Path == "" || filesetInfo.Config.Path == filesetUnlinkedPath {
+		return &csi.VolumeCondition{Abnormal: true, Message: fmt.Sprintf("fileset [%v] is not linked", filesetName)}, nil
+	}
+	if volumeIDMembers.StorageClassType == STORAGECLASS_CACHE {
+		return getCacheVolCondition(ctx, conn, filesystemName, filesetName)
+	}
+	if filesetInfo.AFM.AFMMode != connectors.AFMModePrimary {
+		return &csi.VolumeCondition{Abnormal: false, Message: "volume is healthy"}, nil
+	}
+
+	rpo := time.Duration(filesetInfo.AFM.AFMRPO) * time.Minute
+	if rpo == 0 {
+		return &csi.VolumeCondition{Abnormal: false, Message: "replication is enabled, replication lag is unknown as RPO is not set"}, nil
+	}
+
+	snapshots, err := conn.ListFilesetSnapshots(ctx, filesystemName, filesetName)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("ControllerGetVolume - unable to list snapshots of fileset [%v] in filesystem [%v]. Error: %v", filesetName, filesystemName, err))
+	}
+	var lastRPOSnap *connectors.Snapshot_v2
+	for i := range snapshots {
+		if strings.HasPrefix(snapshots[i].SnapshotName, afmRPOSnapPrefix) && (lastRPOSnap == nil || snapshots[i].SnapID > lastRPOSnap.SnapID) {
+			lastRPOSnap = &snapshots[i]
+		}
+	}
+	if lastRPOSnap == nil {
+		return &csi.VolumeCondition{Abnormal: true, Message: fmt.Sprintf("replication is enabled, no RPO snapshot of fileset [%v] is taken yet, RPO is %v", filesetName, rpo)}, nil
+	}
+
+	timestamp, err := cs.getSnapshotCreateTimestamp(ctx, conn, filesystemName, filesetName, lastRPOSnap.SnapshotName)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("ControllerGetVolume - unable to get create time of snapshot [%v] of fileset [%v]. Error: %v", lastRPOSnap.SnapshotName, filesetName, err))
+	}
+	lag := time.Since(time.Unix(timestamp.GetSeconds(), 0)).Truncate(time.Second)
+	klog.V(4).Infof("[%s] ControllerGetVolume - replication lag of fileset [%v] is [%v], RPO is [%v]", loggerId, filesetName, lag, rpo)
+
+	// An RPO snapshot is expected every RPO interval, one missed interval is tolerated
+	if lag > 2*rpo {
+		return &csi.VolumeCondition{Abnormal: true, Message: fmt.Sprintf("replication lag is %v, which exceeds the RPO of %v", lag, rpo)}, nil
+	}
+	return &csi.VolumeCondition{Abnormal: false, Message: fmt.Sprintf("replication lag is %v, RPO is %v", lag, rpo)}, nil
+}
+
+// getCacheVolCondition returns the condition of a cache volume from the AFM
+// state of the cache fileset. A Dirty fileset has writes queued which are not
+// yet at the home, while a Disconnected or Unmounted fileset cannot reach
+// the home at all.
+func getCacheVolCondition(ctx context.Context, conn connectors.SpectrumScaleConnector, filesystemName string, filesetName string) (*csi.VolumeCondition, error) {
+	loggerId := utils.GetLoggerId(ctx)
+	cacheState, queueLength, err := conn.GetAFMCacheState(ctx, filesystemName, filesetName)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("ControllerGetVolume - unable to get AFM state of fileset [%v] in filesystem [%v]. Error: %v", filesetName, filesystemName, err))
+	}
+	klog.V(4).Infof("[%s] ControllerGetVolume - AFM state of fileset [%v] is [%v], queue length is [%v]", loggerId, filesetName, cacheState, queueLength)
+
+	switch cacheState {
+	case connectors.AFMCacheStateActive, connectors.AFMCacheStateInactive, connectors.AFMCacheStateClean:
+		return &csi.VolumeCondition{Abnormal: false, Message: fmt.Sprintf("cache state is %v", cacheState)}, nil
+	case connectors.AFMCacheStateDirty:
+		return &csi.VolumeCondition{Abnormal: false, Message: fmt.Sprintf("cache state is %v, %v operations are queued for the home", cacheState, queueLength)}, nil
+	default:
+		return &csi.VolumeCondition{Abnormal: true, Message: fmt.Sprintf("cache state is %v, %v operations are queued for the home", cacheState, queueLength)}, nil
+	}
 }
 
 // getRemoteClusterID returns the cluster ID for the passed cluster name.
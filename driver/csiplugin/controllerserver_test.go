@@ -0,0 +1,392 @@
+/**
+ * Copyright 2024 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scale
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/connectors"
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/settings"
+	"github.com/container-storage-interface/spec/lib/go/csi"
+)
+
+func TestParseCopyJobProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		progress []string
+		want     string
+	}{
+		{
+			name:     "no progress",
+			progress: nil,
+			want:     "",
+		},
+		{
+			name:     "no percent",
+			progress: []string{"Starting copy of /fs1/fset1", "Scanning directories"},
+			want:     "",
+		},
+		{
+			name:     "percent only",
+			progress: []string{"Copied 42% of the files"},
+			want:     "42% done",
+		},
+		{
+			name:     "decimal percent and throughput",
+			progress: []string{"Progress: 12.5 % done, 120.3 MiB/s"},
+			want:     "12.5% done at 120.3 MiB/s",
+		},
+		{
+			name:     "throughput without space",
+			progress: []string{"75% 2GB/s"},
+			want:     "75% done at 2GB/s",
+		},
+		{
+			name: "last line with a percent",
+			progress: []string{
+				"10% done, 50 MB/s",
+				"60% done, 80 MB/s",
+				"Waiting for the next batch",
+			},
+			want: "60% done at 80 MB/s",
+		},
+		{
+			name:     "unknown throughput unit",
+			progress: []string{"30% done, 5 files/s"},
+			want:     "30% done",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseCopyJobProgress(tc.progress); got != tc.want {
+				t.Errorf("parseCopyJobProgress(%q) = %q, want %q", tc.progress, got, tc.want)
+			}
+		})
+	}
+}
+
+// fakeConnector implements the connector calls used to snapshot a
+// lightweight volume, any other call panics.
+type fakeConnector struct {
+	connectors.SpectrumScaleConnector
+	mountPoint       string
+	remoteDeviceName string
+	// stat output of the paths relative to the mount point
+	statInfo  map[string]string
+	filesets  []connectors.Fileset_v2
+	snapshots []string
+}
+
+func (c *fakeConnector) GetScaleVersion(ctx context.Context) (string, error) {
+	return "5.1.9-0", nil
+}
+
+func (c *fakeConnector) GetTimeZoneOffset(ctx context.Context) (string, error) {
+	return "Z", nil
+}
+
+func (c *fakeConnector) GetFilesystemName(ctx context.Context, filesystemUUID string) (string, error) {
+	return "fs1", nil
+}
+
+func (c *fakeConnector) GetFilesystemMountDetails(ctx context.Context, filesystemName string) (connectors.MountInfo, error) {
+	return connectors.MountInfo{MountPoint: c.mountPoint, RemoteDeviceName: c.remoteDeviceName}, nil
+}
+
+func (c *fakeConnector) GetFilesystemDetails(ctx context.Context, filesystemName string) (connectors.FileSystem_v2, error) {
+	return connectors.FileSystem_v2{Name: filesystemName, Mount: connectors.MountInfo{MountPoint: c.mountPoint}}, nil
+}
+
+func (c *fakeConnector) StatDirectory(ctx context.Context, filesystemName string, dirName string) (string, error) {
+	if statInfo, ok := c.statInfo[dirName]; ok {
+		return statInfo, nil
+	}
+	return "  File: " + c.mountPoint + "/" + dirName + "\n  Size: 4096\n", nil
+}
+
+func (c *fakeConnector) ListFilesets(ctx context.Context, filesystemName string, filter connectors.FilesetFilter) ([]connectors.Fileset_v2, error) {
+	return c.filesets, nil
+}
+
+func (c *fakeConnector) CheckIfSnapshotExist(ctx context.Context, filesystemName string, filesetName string, snapshotName string) (bool, error) {
+	for _, snap := range c.snapshots {
+		if snap == filesystemName+":"+filesetName+":"+snapshotName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c *fakeConnector) ListFilesetSnapshots(ctx context.Context, filesystemName string, filesetName string) ([]connectors.Snapshot_v2, error) {
+	return nil, nil
+}
+
+func (c *fakeConnector) CreateSnapshot(ctx context.Context, filesystemName string, filesetName string, snapshotName string) error {
+	c.snapshots = append(c.snapshots, filesystemName+":"+filesetName+":"+snapshotName)
+	return nil
+}
+
+func (c *fakeConnector) GetSnapshotCreateTimestamp(ctx context.Context, filesystemName string, filesetName string, snapName string) (string, error) {
+	return "2024-05-02 10:11:12,000", nil
+}
+
+func newFakeFileset(name string, junction string) connectors.Fileset_v2 {
+	fileset := connectors.Fileset_v2{FilesetName: name}
+	fileset.Config.Path = junction
+	fileset.Config.IsInodeSpaceOwner = true
+	return fileset
+}
+
+// newFakeControllerServer returns a controller server with the filesets
+// root (/ibm/fs1), data (/ibm/fs1/data), nested (/ibm/fs1/data/nested)
+// and the unlinked fileset old in the primary filesystem fs1.
+func newFakeControllerServer(statInfo map[string]string) (*ScaleControllerServer, *fakeConnector) {
+	conn := &fakeConnector{
+		mountPoint:       "/ibm/fs1",
+		remoteDeviceName: "fs1",
+		statInfo:         statInfo,
+		filesets: []connectors.Fileset_v2{
+			newFakeFileset("root", "/ibm/fs1"),
+			newFakeFileset("data", "/ibm/fs1/data"),
+			newFakeFileset("nested", "/ibm/fs1/data/nested"),
+			newFakeFileset("old", "--"),
+		},
+	}
+	driver := &ScaleDriver{
+		connmap: map[string]connectors.SpectrumScaleConnector{"primary": conn, "cid1": conn},
+		primary: settings.Primary{PrimaryFs: "fs1", PrimaryFset: "pfset", PrimaryCid: "cid1"},
+	}
+	return &ScaleControllerServer{Driver: driver}, conn
+}
+
+func TestParseStatSymlinkTarget(t *testing.T) {
+	tests := []struct {
+		name          string
+		statInfo      string
+		wantTarget    string
+		wantIsSymlink bool
+	}{
+		{
+			name:     "directory",
+			statInfo: "  File: /ibm/fs1/lwdir/pvc-1\n  Size: 4096  Blocks: 1  IO Block: 262144 directory\n",
+		},
+		{
+			name:          "absolute target",
+			statInfo:      "  File: /ibm/fs1/pfset/.volumes/pvc-1 -> /ibm/fs1/data/pvc-1\n  Size: 20  Blocks: 0  IO Block: 262144 symbolic link\n",
+			wantTarget:    "/ibm/fs1/data/pvc-1",
+			wantIsSymlink: true,
+		},
+		{
+			name:          "relative target",
+			statInfo:      "  File: /ibm/fs1/pfset/.volumes/pvc-1 -> ../../data/pvc-1\n",
+			wantTarget:    "../../data/pvc-1",
+			wantIsSymlink: true,
+		},
+		{
+			name:          "quoted names",
+			statInfo:      "  File: '/ibm/fs1/pfset/.volumes/pvc-1' -> '/ibm/fs1/data/pvc-1'\n",
+			wantTarget:    "/ibm/fs1/data/pvc-1",
+			wantIsSymlink: true,
+		},
+		{
+			name:          "typographic quotes",
+			statInfo:      "  File: ‘/ibm/fs1/pfset/.volumes/pvc-1’ -> ‘/ibm/fs1/data/pvc-1’\n",
+			wantTarget:    "/ibm/fs1/data/pvc-1",
+			wantIsSymlink: true,
+		},
+		{
+			name: "empty",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			target, isSymlink := parseStatSymlinkTarget(tc.statInfo)
+			if target != tc.wantTarget || isSymlink != tc.wantIsSymlink {
+				t.Errorf("parseStatSymlinkTarget(%q) = %q, %t, want %q, %t", tc.statInfo, target, isSymlink, tc.wantTarget, tc.wantIsSymlink)
+			}
+		})
+	}
+}
+
+func TestGetLWVolDirPath(t *testing.T) {
+	statInfo := map[string]string{
+		"pfset/.volumes/pvc-1": "  File: /ibm/fs1/pfset/.volumes/pvc-1 -> /ibm/fs1/data/pvc-1\n",
+		"pfset/.volumes/pvc-2": "  File: /ibm/fs1/pfset/.volumes/pvc-2 -> ../../data/nested/pvc-2\n",
+	}
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{
+			name: "absolute symlink",
+			path: "/ibm/fs1/pfset/.volumes/pvc-1",
+			want: "/ibm/fs1/data/pvc-1",
+		},
+		{
+			name: "relative symlink",
+			path: "/ibm/fs1/pfset/.volumes/pvc-2",
+			want: "/ibm/fs1/data/nested/pvc-2",
+		},
+		{
+			name: "directory in the primary filesystem",
+			path: "/ibm/fs1/lwdir/pvc-3",
+			want: "/ibm/fs1/lwdir/pvc-3",
+		},
+		{
+			name: "directory in another filesystem",
+			path: "/ibm/fs2/lwdir/pvc-4",
+			want: "/ibm/fs2/lwdir/pvc-4",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cs, conn := newFakeControllerServer(statInfo)
+			got, err := cs.getLWVolDirPath(context.Background(), conn, scaleVolId{Path: tc.path})
+			if err != nil {
+				t.Fatalf("getLWVolDirPath(%q) failed: %v", tc.path, err)
+			}
+			if got != tc.want {
+				t.Errorf("getLWVolDirPath(%q) = %q, want %q", tc.path, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGetContainingIndependentFileset(t *testing.T) {
+	tests := []struct {
+		dirRelPath      string
+		wantFileset     string
+		wantFilesetPath string
+	}{
+		{dirRelPath: "lwdir/pvc-1", wantFileset: "root", wantFilesetPath: ""},
+		{dirRelPath: "data", wantFileset: "data", wantFilesetPath: "data"},
+		{dirRelPath: "data/pvc-1", wantFileset: "data", wantFilesetPath: "data"},
+		{dirRelPath: "data/nested/pvc-2", wantFileset: "nested", wantFilesetPath: "data/nested"},
+		{dirRelPath: "data/nestedother/pvc-3", wantFileset: "data", wantFilesetPath: "data"},
+		{dirRelPath: "dataother/pvc-4", wantFileset: "root", wantFilesetPath: ""},
+		{dirRelPath: "old/pvc-5", wantFileset: "root", wantFilesetPath: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.dirRelPath, func(t *testing.T) {
+			cs, conn := newFakeControllerServer(nil)
+			fileset, filesetPath, err := cs.getContainingIndependentFileset(context.Background(), conn, "fs1", tc.dirRelPath)
+			if err != nil {
+				t.Fatalf("getContainingIndependentFileset(%q) failed: %v", tc.dirRelPath, err)
+			}
+			if fileset != tc.wantFileset || filesetPath != tc.wantFilesetPath {
+				t.Errorf("getContainingIndependentFileset(%q) = %q, %q, want %q, %q", tc.dirRelPath, fileset, filesetPath, tc.wantFileset, tc.wantFilesetPath)
+			}
+		})
+	}
+
+	t.Run("no linked fileset", func(t *testing.T) {
+		cs, conn := newFakeControllerServer(nil)
+		conn.filesets = []connectors.Fileset_v2{newFakeFileset("old", "--")}
+		if _, _, err := cs.getContainingIndependentFileset(context.Background(), conn, "fs1", "lwdir/pvc-1"); err == nil {
+			t.Errorf("getContainingIndependentFileset() succeeded without a linked fileset")
+		}
+	})
+}
+
+func TestCreateLWVolSnapshotID(t *testing.T) {
+	statInfo := map[string]string{
+		"pfset/.volumes/pvc-1": "  File: /ibm/fs1/pfset/.volumes/pvc-1 -> /ibm/fs1/data/nested/pvc-1\n",
+	}
+	tests := []struct {
+		name             string
+		path             string
+		remoteDeviceName string
+		existingSnapshot string
+		wantSnapID       string
+		wantSnapshot     string
+	}{
+		{
+			name:         "directory in the root fileset",
+			path:         "/ibm/fs1/lwdir/pvc-2",
+			wantSnapID:   "0;0;cid1;uuid1;;root;snap1;;lwdir/pvc-2",
+			wantSnapshot: "fs1:root:snap1",
+		},
+		{
+			name:         "symlink to a directory in a nested fileset",
+			path:         "/ibm/fs1/pfset/.volumes/pvc-1",
+			wantSnapID:   "0;0;cid1;uuid1;;nested;snap1;;pvc-1",
+			wantSnapshot: "fs1:nested:snap1",
+		},
+		{
+			name:         "junction of a fileset",
+			path:         "/ibm/fs1/data",
+			wantSnapID:   "0;0;cid1;uuid1;;data;snap1;;/",
+			wantSnapshot: "fs1:data:snap1",
+		},
+		{
+			name:             "remotely mounted filesystem",
+			path:             "/ibm/fs1/data/pvc-3",
+			remoteDeviceName: "owner.cluster:remotefs1",
+			wantSnapID:       "0;0;cid1;uuid1;;data;snap1;;pvc-3",
+			wantSnapshot:     "remotefs1:data:snap1",
+		},
+		{
+			name:             "existing snapshot",
+			path:             "/ibm/fs1/data/pvc-3",
+			existingSnapshot: "fs1:data:snap1",
+			wantSnapID:       "0;0;cid1;uuid1;;data;snap1;;pvc-3",
+			wantSnapshot:     "fs1:data:snap1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cs, conn := newFakeControllerServer(statInfo)
+			if tc.remoteDeviceName != "" {
+				conn.remoteDeviceName = tc.remoteDeviceName
+			}
+			if tc.existingSnapshot != "" {
+				conn.snapshots = []string{tc.existingSnapshot}
+			}
+			volID := fmt.Sprintf("cid1;uuid1;path=%s", tc.path)
+			req := &csi.CreateSnapshotRequest{SourceVolumeId: volID, Name: "snap1"}
+			resp, err := cs.createLWVolSnapshot(context.Background(), req, scaleVolId{ClusterId: "cid1", FsUUID: "uuid1", Path: tc.path})
+			if err != nil {
+				t.Fatalf("createLWVolSnapshot(%q) failed: %v", volID, err)
+			}
+			if got := resp.GetSnapshot().GetSnapshotId(); got != tc.wantSnapID {
+				t.Errorf("createLWVolSnapshot(%q) snapshot ID = %q, want %q", volID, got, tc.wantSnapID)
+			}
+			if len(conn.snapshots) != 1 || conn.snapshots[0] != tc.wantSnapshot {
+				t.Errorf("createLWVolSnapshot(%q) snapshots = %q, want [%q]", volID, conn.snapshots, tc.wantSnapshot)
+			}
+			if got := resp.GetSnapshot().GetCreationTime().GetSeconds(); got != 1714644672 {
+				t.Errorf("createLWVolSnapshot(%q) creation time = %d, want 1714644672", volID, got)
+			}
+
+			snapIDMembers, err := cs.GetSnapIdMembers(tc.wantSnapID)
+			if err != nil {
+				t.Fatalf("GetSnapIdMembers(%q) failed: %v", tc.wantSnapID, err)
+			}
+			if snapIDMembers.VolType != FILE_DIRECTORYBASED_VOLUME || snapIDMembers.SnapName != "snap1" {
+				t.Errorf("GetSnapIdMembers(%q) = %+v, want a snapshot snap1 of a directory volume", tc.wantSnapID, snapIDMembers)
+			}
+		})
+	}
+}
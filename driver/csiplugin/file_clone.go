@@ -0,0 +1,164 @@
+/**
+ * Copyright 2024 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scale
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/utils"
+)
+
+const (
+	// mmcloneCmd runs the mmclone command of the host through the chroot
+	// wrapper of the driver image, like mount and umount
+	mmcloneCmd = "mmclone"
+	// hostMmclonePath is the path of the mmclone command on the host
+	hostMmclonePath = "/usr/lpp/mmfs/bin/mmclone"
+)
+
+// checkLocalFileClones returns an error if the file clones of the files under
+// srcDir can not be created in targetDir on this node. The file clones are
+// created by the mmclone command of the node, so both paths must be in a
+// filesystem mounted on the node, which is not the case on a CNSA deployment.
+// The paths are the paths on the host.
+func checkLocalFileClones(srcDir string, targetDir string) error {
+	if _, err := os.Stat(hostDir + hostMmclonePath); err != nil {
+		return fmt.Errorf("the mmclone command is not available on the node: %v", err)
+	}
+	for _, path := range []string{srcDir, targetDir} {
+		if _, err := os.Stat(hostDir + path); err != nil {
+			return fmt.Errorf("the path %s is not accessible on the node: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// cloneFiles creates copy-on-write file clones of the regular files under
+// srcDir in targetDir, with mmclone copy. The files of srcDir are in a
+// snapshot and are the clone parents. The directories and the symbolic links
+// are created in targetDir, the ownership and the mode of all of them are
+// kept, the other files are skipped. The files already in targetDir, e.g. from
+// a previous attempt, are replaced. The paths are the paths on the host.
+func cloneFiles(ctx context.Context, srcDir string, targetDir string) error {
+	loggerId := utils.GetLoggerId(ctx)
+	hostSrcDir := hostDir + srcDir
+	return filepath.WalkDir(hostSrcDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(hostSrcDir, path)
+		if err != nil {
+			return err
+		}
+		// The root directory of the volume is created with the volume
+		if relPath == "." {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(targetDir, relPath)
+		hostTarget := hostDir + target
+
+		switch mode := info.Mode(); {
+		case mode.IsDir():
+			if err := os.Mkdir(hostTarget, mode.Perm()); err != nil && !os.IsExist(err) {
+				return err
+			}
+		case mode&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := removeExisting(hostTarget); err != nil {
+				return err
+			}
+			if err := os.Symlink(link, hostTarget); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			if err := removeExisting(hostTarget); err != nil {
+				return err
+			}
+			source := filepath.Join(srcDir, relPath)
+			// #nosec G204 -- the paths are the paths of the volumes
+			out, err := exec.Command(mmcloneCmd, "copy", source, target).CombinedOutput()
+			if err != nil {
+				return fmt.Errorf("mmclone copy %s %s failed: %v: %s", source, target, err, strings.TrimSpace(string(out)))
+			}
+		default:
+			klog.Warningf("[%s] [%s] is not a regular file, a directory or a symbolic link, it is not cloned", loggerId, filepath.Join(srcDir, relPath))
+			return nil
+		}
+
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			if err := os.Lchown(hostTarget, int(stat.Uid), int(stat.Gid)); err != nil {
+				return err
+			}
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			if err := os.Chmod(hostTarget, info.Mode()&(fs.ModePerm|fs.ModeSetuid|fs.ModeSetgid|fs.ModeSticky)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// removeExisting removes a file or a symbolic link if it exists.
+func removeExisting(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// checkCloneParentSnapshot returns a FailedPrecondition error if a volume
+// restored from the snapshot with cloneMode clone still exists. The files of
+// the snapshot are the clone parents of the files of the volume, the snapshot
+// can not be deleted before the volume.
+func checkCloneParentSnapshot(ctx context.Context, snapID string) error {
+	client, err := getKubeClient()
+	if err != nil {
+		return status.Error(codes.Internal, fmt.Sprintf("failed to create Kubernetes client: %v", err))
+	}
+	pvList, err := client.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return status.Error(codes.Unavailable, fmt.Sprintf("failed to list the PersistentVolumes: %v", err))
+	}
+	for _, pv := range pvList.Items {
+		if pv.Spec.CSI == nil || pv.Spec.CSI.VolumeAttributes[cloneParentSnapshotKey] != snapID {
+			continue
+		}
+		klog.Errorf("[%s] snapshot [%v] holds the clone parents of the files of volume [%v]", utils.GetLoggerId(ctx), snapID, pv.Name)
+		return status.Error(codes.FailedPrecondition, fmt.Sprintf("snapshot [%v] can not be deleted, it holds the clone parents of the files of volume [%v] restored with cloneMode clone, delete the volume first", snapID, pv.Name))
+	}
+	return nil
+}
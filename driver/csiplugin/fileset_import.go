@@ -0,0 +1,168 @@
+/**
+ * Copyright 2024 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scale
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/utils"
+)
+
+const (
+	// DefaultImportAccessMode is the access mode of an imported fileset
+	// when none is requested.
+	DefaultImportAccessMode = "ReadWriteMany"
+
+	filesetImportPollInterval = 2 * time.Second
+)
+
+var scaleFilesetImportResource = schema.GroupVersionResource{Group: "csi.ibm.com", Version: "v1", Resource: "scalefilesetimports"}
+
+// ImportFilesetRequest describes an existing fileset to be adopted as a
+// statically provisioned PV and the PVC bound to it.
+type ImportFilesetRequest struct {
+	// ClusterID is the ID of the IBM Storage Scale cluster owning the
+	// filesystem. The primary cluster is used when empty.
+	ClusterID        string
+	Filesystem       string
+	Fileset          string
+	PVName           string
+	PVCNamespace     string
+	PVCName          string
+	StorageClassName string
+	AccessMode       string
+	// Size is the capacity of the PV. The block quota of the fileset is used
+	// when empty.
+	Size string
+}
+
+// ImportedFileset is a fileset adopted by ImportFileset.
+type ImportedFileset struct {
+	Name    string
+	Phase   string
+	Message string
+	PVName  string
+}
+
+// ValidateImportFilesetRequest checks that the names of an import request
+// are valid Kubernetes object names.
+func ValidateImportFilesetRequest(req ImportFilesetRequest) error {
+	if req.Filesystem == "" || req.Fileset == "" {
+		return fmt.Errorf("filesystem and fileset are required")
+	}
+	names := map[string]string{"PersistentVolumeClaim": req.PVCName}
+	if req.PVName != "" {
+		names["PersistentVolume"] = req.PVName
+	}
+	for kind, name := range names {
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fmt.Errorf("invalid %s name [%s]: %s", kind, name, strings.Join(errs, ", "))
+		}
+	}
+	if errs := validation.IsDNS1123Label(req.PVCNamespace); len(errs) > 0 {
+		return fmt.Errorf("invalid namespace name [%s]: %s", req.PVCNamespace, strings.Join(errs, ", "))
+	}
+	switch req.AccessMode {
+	case "ReadWriteMany", "ReadWriteOnce", "ReadOnlyMany", "ReadWriteOncePod":
+	default:
+		return fmt.Errorf("invalid access mode [%s]", req.AccessMode)
+	}
+	if req.Size != "" {
+		if _, err := resource.ParseQuantity(req.Size); err != nil {
+			return fmt.Errorf("invalid size [%s]: %v", req.Size, err)
+		}
+	}
+	return nil
+}
+
+// ImportFileset adopts an existing fileset as a statically provisioned
+// volume. A ScaleFilesetImport is created in the namespace of the driver and
+// the fileset is imported by the operator, ImportFileset waits until the
+// import succeeds or fails, or the timeout expires.
+func ImportFileset(ctx context.Context, req ImportFilesetRequest, timeout time.Duration) (ImportedFileset, error) {
+	loggerId := utils.GetLoggerId(ctx)
+	if err := ValidateImportFilesetRequest(req); err != nil {
+		return ImportedFileset{}, err
+	}
+	client, err := getKubeDynamicClient()
+	if err != nil {
+		return ImportedFileset{}, fmt.Errorf("unable to create the Kubernetes client: %v", err)
+	}
+	namespace, err := os.ReadFile(serviceAccountNamespacePath)
+	if err != nil {
+		return ImportedFileset{}, fmt.Errorf("unable to get the namespace of the driver: %v", err)
+	}
+
+	spec := map[string]interface{}{
+		"filesystem":                req.Filesystem,
+		"fileset":                   req.Fileset,
+		"namespace":                 req.PVCNamespace,
+		"persistentVolumeClaimName": req.PVCName,
+		"accessMode":                req.AccessMode,
+	}
+	for key, value := range map[string]string{"clusterId": req.ClusterID, "persistentVolumeName": req.PVName, "storageClassName": req.StorageClassName, "size": req.Size} {
+		if value != "" {
+			spec[key] = value
+		}
+	}
+	filesetImport := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": scaleFilesetImportResource.GroupVersion().String(),
+		"kind":       "ScaleFilesetImport",
+		"metadata": map[string]interface{}{
+			"generateName": "import-",
+			"namespace":    strings.TrimSpace(string(namespace)),
+		},
+		"spec": spec,
+	}}
+	resources := client.Resource(scaleFilesetImportResource).Namespace(filesetImport.GetNamespace())
+	filesetImport, err = resources.Create(ctx, filesetImport, metav1.CreateOptions{})
+	if err != nil {
+		return ImportedFileset{}, fmt.Errorf("unable to create the ScaleFilesetImport: %v", err)
+	}
+	klog.Infof("[%s] importing fileset [%s] of filesystem [%s] as PVC [%s/%s] with ScaleFilesetImport [%s/%s]", loggerId, req.Fileset, req.Filesystem, req.PVCNamespace, req.PVCName, filesetImport.GetNamespace(), filesetImport.GetName())
+
+	imported := ImportedFileset{Name: filesetImport.GetName()}
+	err = wait.PollUntilContextTimeout(ctx, filesetImportPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		current, err := resources.Get(ctx, imported.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		imported.Phase, _, _ = unstructured.NestedString(current.Object, "status", "phase")
+		imported.Message, _, _ = unstructured.NestedString(current.Object, "status", "message")
+		imported.PVName, _, _ = unstructured.NestedString(current.Object, "status", "persistentVolumeName")
+		return imported.Phase == "Succeeded" || imported.Phase == "Failed", nil
+	})
+	if err != nil {
+		return imported, fmt.Errorf("ScaleFilesetImport [%s/%s] is not completed, phase [%s] message [%s]: %v", filesetImport.GetNamespace(), imported.Name, imported.Phase, imported.Message, err)
+	}
+	if imported.Phase == "Failed" {
+		return imported, fmt.Errorf("ScaleFilesetImport [%s/%s] failed: %s", filesetImport.GetNamespace(), imported.Name, imported.Message)
+	}
+	return imported, nil
+}
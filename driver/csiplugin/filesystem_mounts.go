@@ -0,0 +1,367 @@
+/**
+ * Copyright 2024 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scale
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/utils"
+)
+
+const (
+	// mountedFilesystemsKey is the annotation of a Kubernetes node with the
+	// comma separated filesystems mounted on the node by
+	// ControllerPublishVolume. Only those are unmounted by the driver, the
+	// filesystems mounted otherwise are left mounted.
+	mountedFilesystemsKey = "csi.ibm.com/scale-mounted-filesystems"
+
+	// UNMOUNT_LINGER_PERIOD is the duration a filesystem stays mounted on a
+	// node after the last volume of the filesystem is unpublished from the
+	// node, e.g. 10m
+	UNMOUNT_LINGER_PERIOD        = "UNMOUNT_LINGER_PERIOD"
+	defaultUnmountLingerDuration = 10 * time.Minute
+)
+
+// filesystemMounts unmounts the filesystems mounted by ControllerPublishVolume
+// once no volume of the filesystem is published to the node. The volumes
+// published to a node are counted from the attachments, which are synced
+// with the VolumeAttachments. The pending unmounts are not persisted, they
+// are scheduled again from the filesystems recorded on the nodes by the
+// first ControllerPublishVolume or ControllerUnpublishVolume served after a
+// restart, as only the driver instance serving the attacher gets them.
+type filesystemMounts struct {
+	// mutex protects the maps, it is not held during GUI or Kubernetes calls
+	mutex sync.Mutex
+	// node ID/filesystem name -> pending unmount
+	timers map[string]*pendingUnmount
+	// node ID/filesystem name -> lock serializing the unmount of the
+	// filesystem from the node with the mount checks of ControllerPublishVolume
+	locks map[string]*sync.Mutex
+	// rescheduled is set once the pending unmounts are scheduled again after
+	// a restart
+	rescheduled bool
+}
+
+// pendingUnmount is the timer of an unmount.
+type pendingUnmount struct {
+	timer *time.Timer
+}
+
+// filesystemMount is a filesystem mounted on a node by the driver.
+type filesystemMount struct {
+	// nodeID is the Kubernetes node
+	nodeID string
+	fsName string
+	// fsUUID is the filesystem of the volumes using the mount, empty for the
+	// primary filesystem which all volumes use
+	fsUUID string
+}
+
+func (m filesystemMount) key() string {
+	return m.nodeID + "/" + m.fsName
+}
+
+func getUnmountLingerPeriod(ctx context.Context) time.Duration {
+	value := utils.GetEnv(UNMOUNT_LINGER_PERIOD, "")
+	if value == "" {
+		return defaultUnmountLingerDuration
+	}
+	linger, err := time.ParseDuration(value)
+	if err != nil || linger <= 0 {
+		klog.Warningf("[%s] invalid %s [%s], using %v", utils.GetLoggerId(ctx), UNMOUNT_LINGER_PERIOD, value, defaultUnmountLingerDuration)
+		return defaultUnmountLingerDuration
+	}
+	return linger
+}
+
+// countVolumes returns the number of volumes of a filesystem published to a
+// node, or of all filesystems if fsUUID is empty.
+func (a *volumeAttachments) countVolumes(ctx context.Context, driverName string, nodeID string, fsUUID string) int {
+	a.sync(ctx, driverName)
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	count := 0
+	for volumeID, nodes := range a.nodes {
+		if _, ok := nodes[nodeID]; !ok {
+			continue
+		}
+		if fsUUID != "" {
+			volumeIDMembers, err := getVolIDMembers(volumeID)
+			if err != nil || volumeIDMembers.FsUUID != fsUUID {
+				continue
+			}
+		}
+		count++
+	}
+	return count
+}
+
+// lock returns the lock of a filesystem on a node.
+func (mounts *filesystemMounts) lock(key string) *sync.Mutex {
+	mounts.mutex.Lock()
+	defer mounts.mutex.Unlock()
+	if mounts.locks == nil {
+		mounts.locks = make(map[string]*sync.Mutex)
+	}
+	if mounts.locks[key] == nil {
+		mounts.locks[key] = &sync.Mutex{}
+	}
+	return mounts.locks[key]
+}
+
+// lockMounts cancels the pending unmounts of filesystems on a node and waits
+// for a running unmount. The returned function must be called once the
+// filesystems are checked and mounted.
+func (driver *ScaleDriver) lockMounts(nodeID string, fsNames ...string) func() {
+	mounts := &driver.fsMounts
+	// The locks are taken in order, the primary and the volume filesystem
+	// may be the same
+	keys := []string{}
+	for _, fsName := range fsNames {
+		key := filesystemMount{nodeID: nodeID, fsName: fsName}.key()
+		if !utils.StringInSlice(key, keys) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	locks := []*sync.Mutex{}
+	for _, key := range keys {
+		lock := mounts.lock(key)
+		lock.Lock()
+		locks = append(locks, lock)
+	}
+
+	mounts.mutex.Lock()
+	for _, key := range keys {
+		if pending, ok := mounts.timers[key]; ok {
+			pending.timer.Stop()
+			delete(mounts.timers, key)
+		}
+	}
+	mounts.mutex.Unlock()
+	return func() {
+		for i := len(locks) - 1; i >= 0; i-- {
+			locks[i].Unlock()
+		}
+	}
+}
+
+// scheduleUnmounts schedules the unmount of the filesystems of a volume
+// unpublished from a node after the linger period, if no other volume of the
+// filesystems is published to the node by then.
+func (driver *ScaleDriver) scheduleUnmounts(ctx context.Context, nodeID string, fsUUID string) {
+	loggerId := utils.GetLoggerId(ctx)
+	driver.rescheduleUnmounts(ctx)
+	mounted, err := getMountedFilesystems(ctx, nodeID)
+	if err != nil {
+		klog.Warningf("[%s] unable to get the filesystems mounted on node [%s] by the driver: %v", loggerId, nodeID, err)
+		return
+	}
+	if len(mounted) == 0 {
+		return
+	}
+
+	fsName, err := driver.connmap["primary"].GetFilesystemName(ctx, fsUUID)
+	if err != nil {
+		klog.Warningf("[%s] unable to get the name of filesystem [%s] to unmount: %v", loggerId, fsUUID, err)
+		return
+	}
+	primaryFs := driver.primary.GetPrimaryFs()
+	var unmounts []filesystemMount
+	if fsName != primaryFs && utils.StringInSlice(fsName, mounted) {
+		unmounts = append(unmounts, filesystemMount{nodeID: nodeID, fsName: fsName, fsUUID: fsUUID})
+	}
+	if utils.StringInSlice(primaryFs, mounted) {
+		unmounts = append(unmounts, filesystemMount{nodeID: nodeID, fsName: primaryFs})
+	}
+	driver.fsMounts.schedule(ctx, driver, unmounts)
+}
+
+// rescheduleUnmounts schedules the unmounts of the filesystems recorded as
+// mounted by the driver on the nodes, once after the driver starts. The
+// filesystems still used are not unmounted when the unmounts run.
+func (driver *ScaleDriver) rescheduleUnmounts(ctx context.Context) {
+	mounts := &driver.fsMounts
+	mounts.mutex.Lock()
+	defer mounts.mutex.Unlock()
+	if mounts.rescheduled {
+		return
+	}
+	mounts.rescheduled = true
+	go driver.rescheduleRecordedUnmounts(utils.SetLoggerId(context.Background()))
+}
+
+func (driver *ScaleDriver) rescheduleRecordedUnmounts(ctx context.Context) {
+	loggerId := utils.GetLoggerId(ctx)
+	client, err := getKubeClient()
+	if err != nil {
+		klog.Warningf("[%s] unable to schedule the unmounts of the filesystems mounted by the driver: %v", loggerId, err)
+		return
+	}
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.Warningf("[%s] unable to schedule the unmounts of the filesystems mounted by the driver: %v", loggerId, err)
+		return
+	}
+	primaryFs := driver.primary.GetPrimaryFs()
+	// filesystem name -> UUID
+	fsUUIDs := map[string]string{primaryFs: ""}
+	var unmounts []filesystemMount
+	for _, node := range nodes.Items {
+		for _, fsName := range strings.Split(node.Annotations[mountedFilesystemsKey], ",") {
+			if fsName == "" {
+				continue
+			}
+			fsUUID, ok := fsUUIDs[fsName]
+			if !ok {
+				fsDetails, err := driver.connmap["primary"].GetFilesystemDetails(ctx, fsName)
+				if err != nil {
+					klog.Warningf("[%s] unable to get the details of filesystem [%s] mounted on node [%s] by the driver: %v", loggerId, fsName, node.Name, err)
+					continue
+				}
+				fsUUID = fsDetails.UUID
+				fsUUIDs[fsName] = fsUUID
+			}
+			unmounts = append(unmounts, filesystemMount{nodeID: node.Name, fsName: fsName, fsUUID: fsUUID})
+		}
+	}
+	if len(unmounts) > 0 {
+		klog.Infof("[%s] scheduling the unmounts of %d filesystems mounted on the nodes by the driver", loggerId, len(unmounts))
+		driver.fsMounts.schedule(ctx, driver, unmounts)
+	}
+}
+
+// schedule starts the timers of the unmounts which are not pending already.
+func (mounts *filesystemMounts) schedule(ctx context.Context, driver *ScaleDriver, unmounts []filesystemMount) {
+	loggerId := utils.GetLoggerId(ctx)
+	linger := getUnmountLingerPeriod(ctx)
+	mounts.mutex.Lock()
+	defer mounts.mutex.Unlock()
+	if mounts.timers == nil {
+		mounts.timers = make(map[string]*pendingUnmount)
+	}
+	for _, mount := range unmounts {
+		if _, ok := mounts.timers[mount.key()]; ok {
+			continue
+		}
+		klog.V(4).Infof("[%s] filesystem [%s] is unmounted from node [%s] in %v if no volume of it is published to the node", loggerId, mount.fsName, mount.nodeID, linger)
+		mount := mount
+		pending := &pendingUnmount{}
+		pending.timer = time.AfterFunc(linger, func() {
+			driver.unmountIfUnused(utils.SetLoggerId(context.Background()), mount, pending)
+		})
+		mounts.timers[mount.key()] = pending
+	}
+}
+
+// unmountIfUnused unmounts a filesystem mounted by the driver from a node if
+// no volume using it is published to the node. The GUI is called with the
+// lock of the filesystem on the node held only.
+func (driver *ScaleDriver) unmountIfUnused(ctx context.Context, mount filesystemMount, pending *pendingUnmount) {
+	loggerId := utils.GetLoggerId(ctx)
+	mounts := &driver.fsMounts
+	lock := mounts.lock(mount.key())
+	lock.Lock()
+	defer lock.Unlock()
+
+	mounts.mutex.Lock()
+	current := mounts.timers[mount.key()]
+	if current == pending {
+		delete(mounts.timers, mount.key())
+	}
+	mounts.mutex.Unlock()
+	if current != pending {
+		// Cancelled by a publish or replaced by a later unpublish
+		return
+	}
+
+	if count := driver.attachments.countVolumes(ctx, driver.name, mount.nodeID, mount.fsUUID); count > 0 {
+		klog.V(4).Infof("[%s] filesystem [%s] is not unmounted from node [%s], %d volumes using it are published to the node", loggerId, mount.fsName, mount.nodeID, count)
+		return
+	}
+
+	scaleNode := driver.getScaleNodeName(ctx, mount.nodeID)
+	klog.Infof("[%s] unmounting filesystem [%s] from node [%s], no volume using it is published to the node", loggerId, mount.fsName, scaleNode)
+	if err := driver.connmap["primary"].UnmountFilesystem(ctx, mount.fsName, scaleNode); err != nil {
+		klog.Errorf("[%s] unable to unmount filesystem [%s] from node [%s]: %v", loggerId, mount.fsName, scaleNode, err)
+		return
+	}
+	if err := updateMountedFilesystems(ctx, mount.nodeID, mount.fsName, false); err != nil {
+		klog.Warningf("[%s] unable to remove filesystem [%s] from the filesystems mounted on node [%s] by the driver: %v", loggerId, mount.fsName, mount.nodeID, err)
+	}
+}
+
+// getMountedFilesystems returns the filesystems mounted on a node by the
+// driver.
+func getMountedFilesystems(ctx context.Context, nodeID string) ([]string, error) {
+	client, err := getKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	node, err := client.CoreV1().Nodes().Get(ctx, nodeID, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	value := node.Annotations[mountedFilesystemsKey]
+	if value == "" {
+		return nil, nil
+	}
+	return strings.Split(value, ","), nil
+}
+
+// updateMountedFilesystems adds or removes a filesystem from the filesystems
+// mounted on a node by the driver.
+func updateMountedFilesystems(ctx context.Context, nodeID string, fsName string, mounted bool) error {
+	client, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node, err := client.CoreV1().Nodes().Get(ctx, nodeID, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		var filesystems []string
+		for _, name := range strings.Split(node.Annotations[mountedFilesystemsKey], ",") {
+			if name != "" && name != fsName {
+				filesystems = append(filesystems, name)
+			}
+		}
+		if mounted {
+			filesystems = append(filesystems, fsName)
+		}
+		if node.Annotations == nil {
+			node.Annotations = make(map[string]string)
+		}
+		if len(filesystems) == 0 {
+			delete(node.Annotations, mountedFilesystemsKey)
+		} else {
+			node.Annotations[mountedFilesystemsKey] = strings.Join(filesystems, ",")
+		}
+		_, err = client.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{})
+		return err
+	})
+}
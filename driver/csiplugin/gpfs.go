@@ -30,6 +30,7 @@ import (
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 )
 
@@ -64,11 +65,23 @@ const (
 type SnapCopyJobDetails struct {
 	jobStatus int
 	volID     string
+	// GUI job copying the snapshot and the cluster running it
+	jobID     uint64
+	clusterID string
+	// last reported progress of the running job
+	progress string
+	// the files of the volume are file clones of the files of the snapshot
+	cloned bool
 }
 
 type VolCopyJobDetails struct {
 	jobStatus int
 	volID     string
+	// GUI job copying the volume and the cluster running it
+	jobID     uint64
+	clusterID string
+	// last reported progress of the running job
+	progress string
 }
 
 // ClusterDetails stores information of the cluster.
@@ -111,10 +124,30 @@ type ScaleDriver struct {
 
 	snapjobstatusmap    sync.Map
 	volcopyjobstatusmap sync.Map
+	// copyjobscancelled maps the volumes whose content copy was cancelled,
+	// as their PVC was deleted, to their volume ID.
+	copyjobscancelled sync.Map
+
+	// recorder records the events of the driver, nil if there is no
+	// Kubernetes client.
+	recorder record.EventRecorder
 
 	// clusterMap map stores the cluster name as key and cluster details as value.
 	clusterMap sync.Map
 
+	// attachments tracks the nodes the volumes are published to.
+	attachments volumeAttachments
+
+	// nodeMapper maps the Kubernetes nodes to IBM Storage Scale nodes.
+	nodeMapper nodeMapper
+
+	// nodeHealth caches the health states of the nodes checked at publish.
+	nodeHealth nodeHealthCache
+
+	// fsMounts unmounts the filesystems mounted by ControllerPublishVolume
+	// once they are not used.
+	fsMounts filesystemMounts
+
 	vcap  []*csi.VolumeCapability_AccessMode
 	cscap []*csi.ControllerServiceCapability
 	nscap []*csi.NodeServiceCapability
@@ -161,6 +194,17 @@ func (driver *ScaleDriver) AddVolumeCapabilityAccessModes(ctx context.Context, v
 	return nil
 }
 
+// isVolumeCapabilityAccessModeSupported returns true if an access mode is
+// enabled in the driver.
+func (driver *ScaleDriver) isVolumeCapabilityAccessModeSupported(mode csi.VolumeCapability_AccessMode_Mode) bool {
+	for _, vcap := range driver.vcap {
+		if vcap.GetMode() == mode {
+			return true
+		}
+	}
+	return false
+}
+
 func (driver *ScaleDriver) AddControllerServiceCapabilities(ctx context.Context, cl []csi.ControllerServiceCapability_RPC_Type) error {
 	klog.V(4).Infof("[%s] AddControllerServiceCapabilities", utils.GetLoggerId(ctx))
 	var csc []*csi.ControllerServiceCapability
@@ -215,6 +259,9 @@ func (driver *ScaleDriver) SetupScaleDriver(ctx context.Context, name, vendorVer
 	// Adding Capabilities
 	vcam := []csi.VolumeCapability_AccessMode_Mode{
 		csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_SINGLE_WRITER,
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_MULTI_WRITER,
 	}
 	_ = driver.AddVolumeCapabilityAccessModes(ctx, vcam)
 
@@ -224,10 +271,15 @@ func (driver *ScaleDriver) SetupScaleDriver(ctx context.Context, name, vendorVer
 		csi.ControllerServiceCapability_RPC_CREATE_DELETE_SNAPSHOT,
 		csi.ControllerServiceCapability_RPC_EXPAND_VOLUME,
 		csi.ControllerServiceCapability_RPC_CLONE_VOLUME,
+		csi.ControllerServiceCapability_RPC_GET_VOLUME,
+		csi.ControllerServiceCapability_RPC_VOLUME_CONDITION,
 	}
 	_ = driver.AddControllerServiceCapabilities(ctx, csc)
 
-	ns := []csi.NodeServiceCapability_RPC_Type{}
+	ns := []csi.NodeServiceCapability_RPC_Type{
+		csi.NodeServiceCapability_RPC_VOLUME_MOUNT_GROUP,
+		csi.NodeServiceCapability_RPC_SINGLE_NODE_MULTI_WRITER,
+	}
 	statsCapability := os.Getenv(volumeStatsCapability)
 	if strings.ToUpper(statsCapability) != "DISABLED" {
 		klog.Infof("[%s] volume stats capability is enabled", utils.GetLoggerId(ctx))
@@ -286,6 +338,9 @@ func (driver *ScaleDriver) PluginInitialize(ctx context.Context) (map[string]con
 }
 
 func (driver *ScaleDriver) Run(ctx context.Context, endpoint string) {
+	driver.recorder = newEventRecorder(ctx, driver.name)
+	driver.startBindMountRepair(ctx, driver.recorder)
+	driver.startVolumeGC(ctx, driver.recorder)
 	s := NewNonBlockingGRPCServer()
 	s.Start(endpoint, driver.ids, driver.cs, driver.ns)
 	s.Wait()
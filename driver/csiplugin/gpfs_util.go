@@ -19,6 +19,7 @@ package scale
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -30,6 +31,9 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// encryptionKeyRegex matches a key of an RKM stanza, <key ID>:<RKM ID>
+var encryptionKeyRegex = regexp.MustCompile(`^[^:,'\s]+:[^:,'\s]+$`)
+
 const (
 	dependentFileset   = "dependent"
 	independentFileset = "independent"
@@ -38,6 +42,34 @@ const (
 	sharedPermissions  = "777"
 )
 
+// Modes of copying the content of a volume clone or a snapshot restore
+const (
+	// Full data copy
+	cloneModeCopy = "copy"
+	// Copy-on-write file clones of a snapshot
+	cloneModeClone = "clone"
+
+	// Prefix of the snapshot of the source volume holding the clone parents of a volume clone
+	cloneSnapPrefix = "clone-"
+	// Volume context key of the ID of the snapshot holding the clone parents of a restored volume
+	cloneParentSnapshotKey = "cloneParentSnapshot"
+
+	// Snapshot of an immutable volume expiring at the end of its retention period
+	retentionSnapName = "csi-retention"
+)
+
+// Immutability of the volumes, backed by the integrated archive mode of the fileset
+const (
+	immutabilityNone       = "none"
+	immutabilityAppendOnly = "append-only"
+	immutabilityWORM       = "worm"
+)
+
+var immutabilityToIAMMode = map[string]string{
+	immutabilityAppendOnly: connectors.IAMModeNonCompliant,
+	immutabilityWORM:       connectors.IAMModeCompliant,
+}
+
 // AFM caching constants
 const (
 	cacheVolume = "cache"
@@ -48,6 +80,15 @@ const (
 	afmModeSW = "sw" // Single-Writer
 	afmModeLU = "lu" // Local-Update
 
+	// Policies of deleting a cache volume with operations queued for the home
+	cacheDeletePolicyFlush = "flush"
+	cacheDeletePolicyFail  = "fail"
+	cacheDeletePolicyForce = "force"
+
+	// Schemes of the cache volume homes which are not object storage
+	cacheHomeSchemeNFS  = "nfs"
+	cacheHomeSchemeGPFS = "gpfs"
+
 	// User input cache modes
 	inputModeRO = "readonly"
 	inputModeIW = "parallel"
@@ -55,6 +96,9 @@ const (
 	inputModeLU = "detached"
 )
 
+// Prefix of the RPO snapshots taken by AFM-DR on the primary fileset
+const afmRPOSnapPrefix = "psnap"
+
 // A map for mapping the user input mode to actual AFM mode
 var inputToAFMMode = map[string]string{
 	inputModeRO: afmModeRO,
@@ -64,36 +108,76 @@ var inputToAFMMode = map[string]string{
 }
 
 type scaleVolume struct {
-	VolName            string                            `json:"volName"`
-	VolSize            uint64                            `json:"volSize"`
-	VolBackendFs       string                            `json:"volBackendFs"`
-	IsFilesetBased     bool                              `json:"isFilesetBased"`
-	VolDirBasePath     string                            `json:"volDirBasePath"`
-	VolUid             string                            `json:"volUid"`
-	VolGid             string                            `json:"volGid"`
-	VolPermissions     string                            `json:"volPermissions"`
-	ClusterId          string                            `json:"clusterId"`
-	FilesetType        string                            `json:"filesetType"`
-	InodeLimit         string                            `json:"inodeLimit"`
-	Connector          connectors.SpectrumScaleConnector `json:"connector"`
-	PrimaryConnector   connectors.SpectrumScaleConnector `json:"primaryConnector"`
-	PrimarySLnkRelPath string                            `json:"primarySLnkRelPath"`
-	PrimarySLnkPath    string                            `json:"primarySLnkPath"`
-	PrimaryFS          string                            `json:"primaryFS"`
-	PrimaryFSMount     string                            `json:"primaryFSMount"`
-	ParentFileset      string                            `json:"parentFileset"`
-	LocalFS            string                            `json:"localFS"`
-	TargetPath         string                            `json:"targetPath"`
-	FsetLinkPath       string                            `json:"fsetLinkPath"`
-	FsMountPoint       string                            `json:"fsMountPoint"`
-	NodeClass          string                            `json:"nodeClass"`
-	StorageClassType   string                            `json:"storageClassType"`
-	ConsistencyGroup   string                            `json:"consistencyGroup"`
-	Compression        string                            `json:"compression"`
-	Tier               string                            `json:"tier"`
-	Shared             bool                              `json:"shared"`
-	VolumeType         string                            `json:"volumeType"`
-	CacheMode          string                            `json:"cacheMode"`
+	VolName              string                            `json:"volName"`
+	VolSize              uint64                            `json:"volSize"`
+	VolBackendFs         string                            `json:"volBackendFs"`
+	IsFilesetBased       bool                              `json:"isFilesetBased"`
+	VolDirBasePath       string                            `json:"volDirBasePath"`
+	VolUid               string                            `json:"volUid"`
+	VolGid               string                            `json:"volGid"`
+	VolPermissions       string                            `json:"volPermissions"`
+	ClusterId            string                            `json:"clusterId"`
+	FilesetType          string                            `json:"filesetType"`
+	InodeLimit           string                            `json:"inodeLimit"`
+	Connector            connectors.SpectrumScaleConnector `json:"connector"`
+	PrimaryConnector     connectors.SpectrumScaleConnector `json:"primaryConnector"`
+	PrimarySLnkRelPath   string                            `json:"primarySLnkRelPath"`
+	PrimarySLnkPath      string                            `json:"primarySLnkPath"`
+	PrimaryFS            string                            `json:"primaryFS"`
+	PrimaryFSMount       string                            `json:"primaryFSMount"`
+	ParentFileset        string                            `json:"parentFileset"`
+	LocalFS              string                            `json:"localFS"`
+	TargetPath           string                            `json:"targetPath"`
+	FsetLinkPath         string                            `json:"fsetLinkPath"`
+	FsMountPoint         string                            `json:"fsMountPoint"`
+	NodeClass            string                            `json:"nodeClass"`
+	StorageClassType     string                            `json:"storageClassType"`
+	ConsistencyGroup     string                            `json:"consistencyGroup"`
+	Compression          string                            `json:"compression"`
+	Tier                 string                            `json:"tier"`
+	Shared               bool                              `json:"shared"`
+	VolumeType           string                            `json:"volumeType"`
+	CacheMode            string                            `json:"cacheMode"`
+	CachePrefetch        string                            `json:"cachePrefetch"`
+	CloneMode            string                            `json:"cloneMode"`
+	ReplicationPeer      *replicationPeer                  `json:"replicationPeer"`
+	Migration            *migrationPolicy                  `json:"migration"`
+	Encryption           string                            `json:"encryption"`
+	Immutability         string                            `json:"immutability"`
+	RetentionDays        int                               `json:"retentionDays"`
+	ACLTemplate          string                            `json:"aclTemplate"`
+	ACLTemplateConfigMap string                            `json:"aclTemplateConfigMap"`
+	CopyCluster          *copyCluster                      `json:"copyCluster"`
+}
+
+// replicationPeer is the AFM-DR secondary of a replicated volume
+type replicationPeer struct {
+	ClusterId    string `json:"clusterId"`
+	VolBackendFs string `json:"volBackendFs"`
+	RPO          int    `json:"rpo"`
+	TargetHost   string `json:"targetHost"`
+}
+
+const (
+	// MIGRATION_POLICY enables the migration parameters of the storageClass,
+	// DISABLED by default. The driver only installs the migration rules, it is
+	// set to ENABLED once the administrator runs them on the filesystems, by
+	// a lowDiskSpace callback for the threshold rules and by a schedule of
+	// mmapplypolicy for the access time rules.
+	MIGRATION_POLICY        = "MIGRATION_POLICY"
+	migrationPolicyEnabled  = "ENABLED"
+	migrationPolicyDisabled = "DISABLED"
+)
+
+// migrationPolicy is the ILM migration of the files of a volume to another pool
+type migrationPolicy struct {
+	TargetPool string `json:"targetPool"`
+	SourcePool string `json:"sourcePool"`
+	// migrate files not accessed for more than AccessDays days
+	AccessDays int `json:"accessDays"`
+	// migrate the least recently accessed files when the occupancy of the
+	// source pool passes the threshold, "<high>[,<low>]" in percent
+	Threshold string `json:"threshold"`
 }
 
 type scaleVolId struct {
@@ -165,6 +249,27 @@ func getScaleVolumeOptions(ctx context.Context, volOptions map[string]string) (*
 
 	volumeType, volumeTypeSpecified := volOptions[connectors.UserSpecifiedVolumeType]
 	cacheMode, cacheModeSpecified := volOptions[connectors.UserSpecifiedCacheMode]
+	cachePrefetch, cachePrefetchSpecified := volOptions[connectors.UserSpecifiedCachePrefetch]
+	cacheDeletePolicy, cacheDeletePolicySpecified := volOptions[connectors.UserSpecifiedCacheDeletePolicy]
+	cloneMode, cloneModeSpecified := volOptions[connectors.UserSpecifiedCloneMode]
+
+	replClusterID, isReplClusterIDSpecified := volOptions[connectors.UserSpecifiedReplicationClusterId]
+	replVolBckFs, isReplVolBckFsSpecified := volOptions[connectors.UserSpecifiedReplicationVolBackendFs]
+	replRPO, isReplRPOSpecified := volOptions[connectors.UserSpecifiedReplicationRPO]
+	replTargetHost, isReplTargetHostSpecified := volOptions[connectors.UserSpecifiedReplicationTargetHost]
+
+	migrationPool, isMigrationPoolSpecified := volOptions[connectors.UserSpecifiedMigrationPool]
+	migrationSourcePool, isMigrationSourcePoolSpecified := volOptions[connectors.UserSpecifiedMigrationSourcePool]
+	migrationAccessDays, isMigrationAccessDaysSpecified := volOptions[connectors.UserSpecifiedMigrationAccessDays]
+	migrationThreshold, isMigrationThresholdSpecified := volOptions[connectors.UserSpecifiedMigrationThreshold]
+
+	encryption, isEncryptionSpecified := volOptions[connectors.UserSpecifiedEncryption]
+
+	immutability, isImmutabilitySpecified := volOptions[connectors.UserSpecifiedImmutability]
+	retentionPeriod, isRetentionPeriodSpecified := volOptions[connectors.UserSpecifiedRetentionPeriod]
+
+	aclTemplate, isACLTemplateSpecified := volOptions[connectors.UserSpecifiedACLTemplate]
+	aclTemplateConfigMap, isACLTemplateConfigMapSpecified := volOptions[connectors.UserSpecifiedACLTemplateConfigMap]
 
 	// Handling empty values
 	scaleVol.VolDirBasePath = ""
@@ -464,9 +569,199 @@ func getScaleVolumeOptions(ctx context.Context, volOptions map[string]string) (*
 		}
 	}
 
+	if cacheDeletePolicySpecified {
+		if scaleVol.VolumeType != cacheVolume {
+			return &scaleVolume{}, status.Errorf(codes.InvalidArgument,
+				"The storage class parameter cacheDeletePolicy can only be specified with volumeType=\"cache\"")
+		}
+		switch strings.ToLower(cacheDeletePolicy) {
+		case cacheDeletePolicyFlush, cacheDeletePolicyFail, cacheDeletePolicyForce:
+		default:
+			return &scaleVolume{}, status.Error(codes.InvalidArgument, fmt.Sprintf("Invalid cache delete policy is specified: %s, allowed values are: %s, %s or %s", cacheDeletePolicy, cacheDeletePolicyFlush, cacheDeletePolicyFail, cacheDeletePolicyForce))
+		}
+	}
+
+	if cachePrefetchSpecified && cachePrefetch != "" {
+		if scaleVol.VolumeType != cacheVolume {
+			return &scaleVolume{}, status.Errorf(codes.InvalidArgument,
+				"The storage class parameter cachePrefetch can only be specified with volumeType=\"cache\"")
+		}
+		scaleVol.CachePrefetch = cachePrefetch
+	}
+
+	if cloneModeSpecified && cloneMode != "" {
+		cloneMode = strings.ToLower(cloneMode)
+		switch cloneMode {
+		case cloneModeCopy, cloneModeClone:
+			scaleVol.CloneMode = cloneMode
+		default:
+			return &scaleVolume{}, status.Error(codes.InvalidArgument, fmt.Sprintf("Invalid clone mode is specified: %s, allowed clone modes are: %s or %s", cloneMode, cloneModeCopy, cloneModeClone))
+		}
+	}
+
+	if isReplClusterIDSpecified && replClusterID == "" {
+		isReplClusterIDSpecified = false
+	}
+	if !isReplClusterIDSpecified {
+		if isReplVolBckFsSpecified || isReplRPOSpecified || isReplTargetHostSpecified {
+			return &scaleVolume{}, status.Error(codes.InvalidArgument, "The parameters \""+connectors.UserSpecifiedReplicationVolBackendFs+"\", \""+connectors.UserSpecifiedReplicationRPO+
+				"\" and \""+connectors.UserSpecifiedReplicationTargetHost+"\" can only be specified with \""+connectors.UserSpecifiedReplicationClusterId+"\" in storageClass")
+		}
+	} else {
+		// AFM-DR replication is supported only for independent filesets of classic storageClass
+		if scaleVol.VolumeType == cacheVolume || isSCAdvanced || !scaleVol.IsFilesetBased || scaleVol.FilesetType != independentFileset {
+			return &scaleVolume{}, status.Error(codes.InvalidArgument, "The parameter \""+connectors.UserSpecifiedReplicationClusterId+"\" is only supported for independent fileset based volumes of classic storageClass")
+		}
+		peer := &replicationPeer{ClusterId: replClusterID, VolBackendFs: volBckFs}
+		if isReplVolBckFsSpecified && replVolBckFs != "" {
+			peer.VolBackendFs = replVolBckFs
+		}
+		if isReplRPOSpecified && replRPO != "" {
+			rpo, err := strconv.Atoi(replRPO)
+			if err != nil || rpo <= 0 {
+				return &scaleVolume{}, status.Error(codes.InvalidArgument, "Invalid value specified for "+connectors.UserSpecifiedReplicationRPO+" in storageClass, it must be a number of minutes")
+			}
+			peer.RPO = rpo
+		}
+		if isReplTargetHostSpecified {
+			peer.TargetHost = replTargetHost
+		}
+		scaleVol.ReplicationPeer = peer
+	}
+
+	if isMigrationPoolSpecified && migrationPool == "" {
+		isMigrationPoolSpecified = false
+	}
+	if !isMigrationPoolSpecified {
+		if isMigrationSourcePoolSpecified || isMigrationAccessDaysSpecified || isMigrationThresholdSpecified {
+			return &scaleVolume{}, status.Error(codes.InvalidArgument, "The parameters \""+connectors.UserSpecifiedMigrationSourcePool+"\", \""+connectors.UserSpecifiedMigrationAccessDays+
+				"\" and \""+connectors.UserSpecifiedMigrationThreshold+"\" can only be specified with \""+connectors.UserSpecifiedMigrationPool+"\" in storageClass")
+		}
+	} else {
+		if strings.ToUpper(utils.GetEnv(MIGRATION_POLICY, migrationPolicyDisabled)) != migrationPolicyEnabled {
+			return &scaleVolume{}, status.Error(codes.InvalidArgument, "The parameter \""+connectors.UserSpecifiedMigrationPool+"\" is not enabled, the migration rules are only evaluated when the administrator runs mmapplypolicy on the filesystem and sets "+MIGRATION_POLICY+" to "+migrationPolicyEnabled)
+		}
+		// The migration rules are scoped to the fileset of the volume
+		if scaleVol.VolumeType == cacheVolume || isSCAdvanced || !scaleVol.IsFilesetBased {
+			return &scaleVolume{}, status.Error(codes.InvalidArgument, "The parameter \""+connectors.UserSpecifiedMigrationPool+"\" is only supported for fileset based volumes of classic storageClass")
+		}
+		migration := &migrationPolicy{TargetPool: migrationPool, SourcePool: tier}
+		if isMigrationSourcePoolSpecified && migrationSourcePool != "" {
+			migration.SourcePool = migrationSourcePool
+		}
+		if isMigrationAccessDaysSpecified && migrationAccessDays != "" {
+			days, err := strconv.Atoi(migrationAccessDays)
+			if err != nil || days <= 0 {
+				return &scaleVolume{}, status.Error(codes.InvalidArgument, "Invalid value specified for "+connectors.UserSpecifiedMigrationAccessDays+" in storageClass, it must be a number of days")
+			}
+			migration.AccessDays = days
+		}
+		if isMigrationThresholdSpecified && migrationThreshold != "" {
+			threshold, err := parseMigrationThreshold(migrationThreshold)
+			if err != nil {
+				return &scaleVolume{}, status.Error(codes.InvalidArgument, fmt.Sprintf("Invalid value specified for %s in storageClass: %v", connectors.UserSpecifiedMigrationThreshold, err))
+			}
+			if migration.SourcePool == "" {
+				return &scaleVolume{}, status.Error(codes.InvalidArgument, "The parameter \""+connectors.UserSpecifiedMigrationThreshold+"\" requires \""+connectors.UserSpecifiedMigrationSourcePool+"\" or \""+connectors.UserSpecifiedTier+"\" in storageClass")
+			}
+			migration.Threshold = threshold
+		}
+		if migration.AccessDays == 0 && migration.Threshold == "" {
+			return &scaleVolume{}, status.Error(codes.InvalidArgument, "The parameter \""+connectors.UserSpecifiedMigrationPool+"\" requires \""+connectors.UserSpecifiedMigrationAccessDays+"\" or \""+connectors.UserSpecifiedMigrationThreshold+"\" in storageClass")
+		}
+		if migration.SourcePool == migration.TargetPool {
+			return &scaleVolume{}, status.Error(codes.InvalidArgument, "The parameters \""+connectors.UserSpecifiedMigrationPool+"\" and \""+connectors.UserSpecifiedMigrationSourcePool+"\" must not be the same pool")
+		}
+		scaleVol.Migration = migration
+	}
+
+	if isEncryptionSpecified && encryption != "" {
+		// The encryption rules are scoped to the fileset of the volume
+		if scaleVol.VolumeType == cacheVolume || isSCAdvanced || !scaleVol.IsFilesetBased {
+			return &scaleVolume{}, status.Error(codes.InvalidArgument, "The parameter \""+connectors.UserSpecifiedEncryption+"\" is only supported for fileset based volumes of classic storageClass")
+		}
+		if !encryptionKeyRegex.MatchString(encryption) {
+			return &scaleVolume{}, status.Error(codes.InvalidArgument, "Invalid value specified for "+connectors.UserSpecifiedEncryption+" in storageClass, it must be <key ID>:<RKM ID> of the key server stanza")
+		}
+		// File clones share the data blocks of their unencrypted parents
+		if scaleVol.CloneMode == cloneModeClone {
+			return &scaleVolume{}, status.Error(codes.InvalidArgument, "The parameters \""+connectors.UserSpecifiedEncryption+"\" and \""+connectors.UserSpecifiedCloneMode+"\"=\""+cloneModeClone+"\" must not be specified together in storageClass")
+		}
+		scaleVol.Encryption = encryption
+	}
+
+	if isImmutabilitySpecified && immutability != "" {
+		immutability = strings.ToLower(immutability)
+		switch immutability {
+		case immutabilityNone:
+		case immutabilityAppendOnly, immutabilityWORM:
+			// The integrated archive mode is set on independent filesets
+			if scaleVol.VolumeType == cacheVolume || isSCAdvanced || !scaleVol.IsFilesetBased || scaleVol.FilesetType != independentFileset {
+				return &scaleVolume{}, status.Error(codes.InvalidArgument, "The parameter \""+connectors.UserSpecifiedImmutability+"\" is only supported for independent fileset based volumes of classic storageClass")
+			}
+			scaleVol.Immutability = immutability
+		default:
+			return &scaleVolume{}, status.Error(codes.InvalidArgument, fmt.Sprintf("Invalid immutability is specified: %s, allowed values are: %s, %s or %s", immutability, immutabilityNone, immutabilityAppendOnly, immutabilityWORM))
+		}
+	}
+
+	if isRetentionPeriodSpecified && retentionPeriod != "" {
+		if scaleVol.Immutability == "" {
+			return &scaleVolume{}, status.Error(codes.InvalidArgument, "The parameter \""+connectors.UserSpecifiedRetentionPeriod+"\" can only be specified with \""+connectors.UserSpecifiedImmutability+"\"=\""+immutabilityAppendOnly+"\" or \""+immutabilityWORM+"\" in storageClass")
+		}
+		days, err := strconv.Atoi(retentionPeriod)
+		if err != nil || days <= 0 {
+			return &scaleVolume{}, status.Error(codes.InvalidArgument, "Invalid value specified for "+connectors.UserSpecifiedRetentionPeriod+" in storageClass, it must be a number of days")
+		}
+		scaleVol.RetentionDays = days
+	}
+
+	if isACLTemplateSpecified && isACLTemplateConfigMapSpecified {
+		return &scaleVolume{}, status.Error(codes.InvalidArgument, "The parameters \""+connectors.UserSpecifiedACLTemplate+"\" and \""+connectors.UserSpecifiedACLTemplateConfigMap+"\" are mutually exclusive")
+	}
+	if isACLTemplateSpecified {
+		if strings.TrimSpace(aclTemplate) == "" {
+			return &scaleVolume{}, status.Error(codes.InvalidArgument, "The parameter \""+connectors.UserSpecifiedACLTemplate+"\" is empty")
+		}
+		scaleVol.ACLTemplate = aclTemplate
+	}
+	if isACLTemplateConfigMapSpecified {
+		if err := validateConfigMapName(aclTemplateConfigMap); err != nil {
+			return &scaleVolume{}, status.Error(codes.InvalidArgument, fmt.Sprintf("Invalid value specified for %s in storageClass: %v", connectors.UserSpecifiedACLTemplateConfigMap, err))
+		}
+		scaleVol.ACLTemplateConfigMap = aclTemplateConfigMap
+	}
+
 	return scaleVol, nil
 }
 
+// parseMigrationThreshold validates a migration threshold "<high>[,<low>]"
+// in percent of the source pool occupancy and returns it without spaces.
+func parseMigrationThreshold(threshold string) (string, error) {
+	parts := strings.Split(threshold, ",")
+	if len(parts) > 2 {
+		return "", fmt.Errorf("threshold must be <high>[,<low>] in percent")
+	}
+	percents := []int{}
+	for _, part := range parts {
+		percent, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || percent < 0 || percent > 100 {
+			return "", fmt.Errorf("threshold must be <high>[,<low>] in percent")
+		}
+		percents = append(percents, percent)
+	}
+	if percents[0] == 0 {
+		return "", fmt.Errorf("high threshold must be greater than 0")
+	}
+	if len(percents) == 2 {
+		if percents[1] >= percents[0] {
+			return "", fmt.Errorf("low threshold must be less than high threshold")
+		}
+		return fmt.Sprintf("%d,%d", percents[0], percents[1]), nil
+	}
+	return strconv.Itoa(percents[0]), nil
+}
+
 /*func executeCmd(command string, args []string) ([]byte, error) {
 	klog.V(6).Infof("gpfs_util executeCmd")
 
@@ -542,19 +837,21 @@ const (
 	DefaultScaleNodeMapPrefix = "K8sNodePrefix_"
 )
 
-// getNodeMapping returns the configured mapping to GPFS Admin Node Name given Kubernetes Node ID.
-func getNodeMapping(kubernetesNodeID string) (gpfsAdminName string) {
-	gpfsAdminName = utils.GetEnv(kubernetesNodeID, notFound)
+// getStaticNodeMapping returns the IBM Storage Scale node of a Kubernetes
+// node from the nodeMapping of the CSIScaleOperator, passed as environment
+// variables.
+func getStaticNodeMapping(kubernetesNodeID string) (string, bool) {
+	gpfsAdminName := utils.GetEnv(kubernetesNodeID, notFound)
 	// Additional node mapping check in case of k8s node id start with number.
 	if gpfsAdminName == notFound {
 		prefix := utils.GetEnv(SCALE_NODE_MAPPING_PREFIX, DefaultScaleNodeMapPrefix)
 		gpfsAdminName = utils.GetEnv(prefix+kubernetesNodeID, notFound)
 		if gpfsAdminName == notFound {
-			klog.V(4).Infof("getNodeMapping: scale node mapping not found for %s using %s", prefix+kubernetesNodeID, kubernetesNodeID)
-			gpfsAdminName = kubernetesNodeID
+			klog.V(4).Infof("getStaticNodeMapping: scale node mapping not found for %s using %s", prefix+kubernetesNodeID, kubernetesNodeID)
+			return "", false
 		}
 	}
-	return gpfsAdminName
+	return gpfsAdminName, true
 }
 
 const (
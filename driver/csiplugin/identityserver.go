@@ -49,8 +49,8 @@ func (is *ScaleIdentityServer) Probe(ctx context.Context, req *csi.ProbeRequest)
 	loggerId := utils.GetLoggerId(ctx)
 	klog.V(4).Infof("[%s] Probe called with args: %#v", loggerId, req)
 
-	// Node mapping check
-	scalenodeID := getNodeMapping(is.Driver.nodeID)
+	// Node mapping check, the probe does not wait for the mapping to be resolved
+	scalenodeID := is.Driver.getCachedScaleNodeName(ctx, is.Driver.nodeID)
 	klog.V(6).Infof("[%s] Probe: scalenodeID:%s --known as-- k8snodeName: %s", loggerId, scalenodeID, is.Driver.nodeID)
 	// IsNodeComponentHealthy accepts nodeName as admin node name, daemon node name, etc.
 	ghealthy, err := is.Driver.connmap["primary"].IsNodeComponentHealthy(ctx, scalenodeID, "GPFS")
@@ -0,0 +1,314 @@
+/**
+ * Copyright 2024 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scale
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"k8s.io/klog/v2"
+
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/utils"
+)
+
+const gpfsFsType = "gpfs"
+
+// mountTableResyncInterval is the interval at which the GPFS mount table is
+// reread even if no change of the mounts is notified
+const mountTableResyncInterval = time.Minute
+
+// mountInfo is a mount of /proc/self/mountinfo, see proc(5)
+type mountInfo struct {
+	ID           int
+	ParentID     int
+	Major        int
+	Minor        int
+	Root         string
+	MountPoint   string
+	Options      []string
+	Optional     []string
+	FsType       string
+	Source       string
+	SuperOptions []string
+}
+
+// unescapeMountInfo decodes the octal escapes of the space, tab, newline and
+// backslash characters of a mountinfo field, e.g. \040 for a space.
+func unescapeMountInfo(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+	var out strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+3 < len(field) {
+			if c, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				out.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		out.WriteByte(field[i])
+	}
+	return out.String()
+}
+
+// parseMountInfoLine parses a line of /proc/self/mountinfo, e.g.
+// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+func parseMountInfoLine(line string) (mountInfo, error) {
+	var info mountInfo
+	fields := strings.Fields(line)
+	separator := -1
+	for i := 6; i < len(fields); i++ {
+		if fields[i] == "-" {
+			separator = i
+			break
+		}
+	}
+	if separator < 0 || len(fields) < separator+3 {
+		return info, fmt.Errorf("invalid mountinfo line [%s]", line)
+	}
+
+	var err error
+	if info.ID, err = strconv.Atoi(fields[0]); err != nil {
+		return info, fmt.Errorf("invalid mount ID in mountinfo line [%s]: %v", line, err)
+	}
+	if info.ParentID, err = strconv.Atoi(fields[1]); err != nil {
+		return info, fmt.Errorf("invalid parent ID in mountinfo line [%s]: %v", line, err)
+	}
+	major, minor, found := strings.Cut(fields[2], ":")
+	if !found {
+		return info, fmt.Errorf("invalid device in mountinfo line [%s]", line)
+	}
+	if info.Major, err = strconv.Atoi(major); err != nil {
+		return info, fmt.Errorf("invalid device in mountinfo line [%s]: %v", line, err)
+	}
+	if info.Minor, err = strconv.Atoi(minor); err != nil {
+		return info, fmt.Errorf("invalid device in mountinfo line [%s]: %v", line, err)
+	}
+	info.Root = unescapeMountInfo(fields[3])
+	info.MountPoint = unescapeMountInfo(fields[4])
+	info.Options = strings.Split(fields[5], ",")
+	info.Optional = fields[6:separator]
+	info.FsType = fields[separator+1]
+	info.Source = unescapeMountInfo(fields[separator+2])
+	if len(fields) > separator+3 {
+		info.SuperOptions = strings.Split(fields[separator+3], ",")
+	}
+	return info, nil
+}
+
+// parseMountInfo parses the mounts of /proc/self/mountinfo.
+func parseMountInfo(r io.Reader) ([]mountInfo, error) {
+	var infos []mountInfo
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		info, err := parseMountInfoLine(line)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+// gpfsMountPoints returns the mount points of the GPFS filesystems. On a
+// CNSA deployment the filesystems mounted under /host/var/mnt are returned
+// under /host/mnt as the volume paths refer to them.
+func gpfsMountPoints(infos []mountInfo) []string {
+	var paths []string
+	cnsaPresence, ok := os.LookupEnv(ENVClusterCNSAPresenceCheck)
+	for _, info := range infos {
+		if info.FsType != gpfsFsType || info.MountPoint == "" {
+			continue
+		}
+		if ok && cnsaPresence == "True" {
+			before, after, found := strings.Cut(info.MountPoint, "/var")
+			if found && before == hostDir && strings.HasPrefix(after, mountPath) {
+				paths = append(paths, before+after)
+				continue
+			}
+		}
+		paths = append(paths, info.MountPoint)
+	}
+	return paths
+}
+
+// gpfsFilesystemMounts returns the sorted devices of the mounts of the GPFS
+// filesystems, e.g. "fs1 0:52". A device is new each time a filesystem is
+// mounted, and the bind mounts of the volumes have the device of their
+// filesystem, so the devices change only when a filesystem is mounted or
+// unmounted.
+func gpfsFilesystemMounts(infos []mountInfo) string {
+	devices := make(map[string]struct{})
+	for _, info := range infos {
+		if info.FsType == gpfsFsType {
+			devices[fmt.Sprintf("%s %d:%d", info.Source, info.Major, info.Minor)] = struct{}{}
+		}
+	}
+	sorted := make([]string, 0, len(devices))
+	for device := range devices {
+		sorted = append(sorted, device)
+	}
+	sort.Strings(sorted)
+	return strings.Join(sorted, "\n")
+}
+
+// gpfsMountTable is the table of the GPFS mounts of the node, which is kept
+// in memory and refreshed when the kernel notifies a change of the mounts by
+// polling /proc/self/mountinfo. The table is read from /proc/self/mountinfo
+// on each lookup while it is not watched.
+type gpfsMountTable struct {
+	once     sync.Once
+	mutex    sync.RWMutex
+	watching bool
+	paths    []string
+	// filesystems identifies the mounts of the GPFS filesystems, without the
+	// bind mounts of the volumes
+	filesystems string
+	// listeners are called when a GPFS filesystem is mounted or unmounted
+	listeners []func()
+}
+
+var gpfsMounts gpfsMountTable
+
+func (t *gpfsMountTable) startWatching() {
+	t.once.Do(func() {
+		go t.watch()
+	})
+}
+
+// subscribe registers a function called when a GPFS filesystem is mounted or
+// unmounted. The bind mounts of the volumes do not call it.
+func (t *gpfsMountTable) subscribe(listener func()) {
+	t.mutex.Lock()
+	t.listeners = append(t.listeners, listener)
+	t.mutex.Unlock()
+	t.startWatching()
+}
+
+// getPaths returns the mount points of the GPFS filesystems.
+func (t *gpfsMountTable) getPaths(ctx context.Context) []string {
+	t.startWatching()
+
+	t.mutex.RLock()
+	if t.watching {
+		paths := t.paths
+		t.mutex.RUnlock()
+		return paths
+	}
+	t.mutex.RUnlock()
+	return readGpfsMountPoints(ctx)
+}
+
+// readGpfsMountPoints reads the mount points of the GPFS filesystems from
+// /proc/self/mountinfo.
+func readGpfsMountPoints(ctx context.Context) []string {
+	file, err := os.Open(procMountInfoPath)
+	if err != nil {
+		klog.Errorf("[%s] unable to open [%s]: %v", utils.GetLoggerId(ctx), procMountInfoPath, err)
+		return nil
+	}
+	defer file.Close()
+	infos, err := parseMountInfo(file)
+	if err != nil {
+		klog.Errorf("[%s] unable to parse [%s]: %v", utils.GetLoggerId(ctx), procMountInfoPath, err)
+		return nil
+	}
+	return gpfsMountPoints(infos)
+}
+
+// refresh rereads the GPFS mounts from the start of the opened mountinfo.
+func (t *gpfsMountTable) refresh(file *os.File) error {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	infos, err := parseMountInfo(file)
+	if err != nil {
+		return err
+	}
+	paths := gpfsMountPoints(infos)
+	filesystems := gpfsFilesystemMounts(infos)
+
+	t.mutex.Lock()
+	changed := t.watching && strings.Join(paths, "\n") != strings.Join(t.paths, "\n")
+	if !t.watching || changed {
+		klog.V(4).Infof("GPFS mount table refreshed, GPFS filesystems are mounted on %v", paths)
+	}
+	remounted := t.watching && filesystems != t.filesystems
+	t.paths = paths
+	t.filesystems = filesystems
+	t.watching = true
+	listeners := t.listeners
+	t.mutex.Unlock()
+
+	if remounted {
+		for _, listener := range listeners {
+			listener()
+		}
+	}
+	return nil
+}
+
+func (t *gpfsMountTable) stopWatching(err error) {
+	klog.Errorf("unable to watch [%s], the GPFS mounts are read on each lookup: %v", procMountInfoPath, err)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.watching = false
+}
+
+// watch refreshes the GPFS mount table each time the mounts change. The
+// kernel reports a change of the mounts of the mount namespace as an
+// exceptional condition (POLLPRI) on an open /proc/self/mountinfo, which is
+// cleared by rereading the file.
+func (t *gpfsMountTable) watch() {
+	file, err := os.Open(procMountInfoPath)
+	if err != nil {
+		t.stopWatching(err)
+		return
+	}
+	defer file.Close()
+
+	for {
+		if err := t.refresh(file); err != nil {
+			t.stopWatching(err)
+			return
+		}
+		fds := []unix.PollFd{{Fd: int32(file.Fd()), Events: unix.POLLPRI}} // #nosec G115 -- file descriptors fit in int32
+		if _, err := unix.Poll(fds, int(mountTableResyncInterval.Milliseconds())); err != nil && !errors.Is(err, unix.EINTR) {
+			t.stopWatching(err)
+			return
+		}
+	}
+}
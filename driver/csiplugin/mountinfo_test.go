@@ -0,0 +1,230 @@
+/**
+ * Copyright 2024 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scale
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnescapeMountInfo(t *testing.T) {
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{field: "/mnt/fs1", want: "/mnt/fs1"},
+		{field: `/mnt/my\040fs`, want: "/mnt/my fs"},
+		{field: `/mnt/tab\011and\012newline`, want: "/mnt/tab\tand\nnewline"},
+		{field: `/mnt/back\134slash`, want: `/mnt/back\slash`},
+		{field: `\040leading`, want: " leading"},
+		{field: `trailing\040`, want: "trailing "},
+		{field: `/mnt/a\040b\040c`, want: "/mnt/a b c"},
+		// Not an octal escape, kept as it is
+		{field: `/mnt/not\08escape`, want: `/mnt/not\08escape`},
+		{field: `/mnt/too\777big`, want: `/mnt/too\777big`},
+		{field: `/mnt/short\04`, want: `/mnt/short\04`},
+		{field: `/mnt/end\`, want: `/mnt/end\`},
+	}
+
+	for _, tc := range tests {
+		if got := unescapeMountInfo(tc.field); got != tc.want {
+			t.Errorf("unescapeMountInfo(%q) = %q, want %q", tc.field, got, tc.want)
+		}
+	}
+}
+
+func TestParseMountInfoLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    mountInfo
+		wantErr bool
+	}{
+		{
+			name: "optional field",
+			line: "36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue",
+			want: mountInfo{
+				ID: 36, ParentID: 35, Major: 98, Minor: 0,
+				Root: "/mnt1", MountPoint: "/mnt2",
+				Options:      []string{"rw", "noatime"},
+				Optional:     []string{"master:1"},
+				FsType:       "ext3",
+				Source:       "/dev/root",
+				SuperOptions: []string{"rw", "errors=continue"},
+			},
+		},
+		{
+			name: "no optional fields",
+			line: "120 25 0:52 / /ibm/fs1 rw,relatime - gpfs fs1 rw",
+			want: mountInfo{
+				ID: 120, ParentID: 25, Major: 0, Minor: 52,
+				Root: "/", MountPoint: "/ibm/fs1",
+				Options:      []string{"rw", "relatime"},
+				Optional:     []string{},
+				FsType:       "gpfs",
+				Source:       "fs1",
+				SuperOptions: []string{"rw"},
+			},
+		},
+		{
+			name: "several optional fields",
+			line: "121 25 0:52 / /ibm/fs1 rw shared:7 master:3 propagate_from:2 - gpfs fs1 rw",
+			want: mountInfo{
+				ID: 121, ParentID: 25, Major: 0, Minor: 52,
+				Root: "/", MountPoint: "/ibm/fs1",
+				Options:      []string{"rw"},
+				Optional:     []string{"shared:7", "master:3", "propagate_from:2"},
+				FsType:       "gpfs",
+				Source:       "fs1",
+				SuperOptions: []string{"rw"},
+			},
+		},
+		{
+			name: "bind mount of a volume with escapes",
+			line: `300 120 0:52 /spectrum-scale-csi-volume-store/.volumes/pvc\0401 /var/lib/kubelet/pods/uid/volumes/kubernetes.io~csi/pvc\0401/mount rw,relatime shared:9 - gpfs fs1 rw`,
+			want: mountInfo{
+				ID: 300, ParentID: 120, Major: 0, Minor: 52,
+				Root:         "/spectrum-scale-csi-volume-store/.volumes/pvc 1",
+				MountPoint:   "/var/lib/kubelet/pods/uid/volumes/kubernetes.io~csi/pvc 1/mount",
+				Options:      []string{"rw", "relatime"},
+				Optional:     []string{"shared:9"},
+				FsType:       "gpfs",
+				Source:       "fs1",
+				SuperOptions: []string{"rw"},
+			},
+		},
+		{
+			name: "no super options",
+			line: "40 35 0:5 / /dev rw - devtmpfs devtmpfs",
+			want: mountInfo{
+				ID: 40, ParentID: 35, Major: 0, Minor: 5,
+				Root: "/", MountPoint: "/dev",
+				Options:  []string{"rw"},
+				Optional: []string{},
+				FsType:   "devtmpfs",
+				Source:   "devtmpfs",
+			},
+		},
+		{
+			name: "dash as mount point is not the separator",
+			line: `41 35 0:6 / - rw - tmpfs tmpfs rw`,
+			want: mountInfo{
+				ID: 41, ParentID: 35, Major: 0, Minor: 6,
+				Root: "/", MountPoint: "-",
+				Options:      []string{"rw"},
+				Optional:     []string{},
+				FsType:       "tmpfs",
+				Source:       "tmpfs",
+				SuperOptions: []string{"rw"},
+			},
+		},
+		{
+			name:    "no separator",
+			line:    "36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 ext3 /dev/root rw",
+			wantErr: true,
+		},
+		{
+			name:    "no source after the separator",
+			line:    "36 35 98:0 /mnt1 /mnt2 rw - ext3",
+			wantErr: true,
+		},
+		{
+			name:    "invalid mount ID",
+			line:    "x 35 98:0 /mnt1 /mnt2 rw - ext3 /dev/root rw",
+			wantErr: true,
+		},
+		{
+			name:    "invalid parent ID",
+			line:    "36 y 98:0 /mnt1 /mnt2 rw - ext3 /dev/root rw",
+			wantErr: true,
+		},
+		{
+			name:    "device without minor",
+			line:    "36 35 98 /mnt1 /mnt2 rw - ext3 /dev/root rw",
+			wantErr: true,
+		},
+		{
+			name:    "invalid device number",
+			line:    "36 35 98:a /mnt1 /mnt2 rw - ext3 /dev/root rw",
+			wantErr: true,
+		},
+		{
+			name:    "too few fields",
+			line:    "36 35 98:0 /mnt1",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseMountInfoLine(tc.line)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("parseMountInfoLine() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseMountInfoLine() failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("parseMountInfoLine() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseMountInfo(t *testing.T) {
+	mountinfo := strings.Join([]string{
+		"25 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw",
+		"",
+		"120 25 0:52 / /ibm/fs1 rw,relatime shared:7 - gpfs fs1 rw",
+		"",
+	}, "\n")
+	infos, err := parseMountInfo(strings.NewReader(mountinfo))
+	if err != nil {
+		t.Fatalf("parseMountInfo() failed: %v", err)
+	}
+	if len(infos) != 2 || infos[0].MountPoint != "/" || infos[1].MountPoint != "/ibm/fs1" {
+		t.Errorf("parseMountInfo() = %+v, want the mounts of / and /ibm/fs1", infos)
+	}
+
+	if _, err := parseMountInfo(strings.NewReader("25 1 8:1 / / rw\n")); err == nil {
+		t.Errorf("parseMountInfo() of an invalid line succeeded, want an error")
+	}
+}
+
+func TestGpfsFilesystemMounts(t *testing.T) {
+	fs1 := mountInfo{FsType: gpfsFsType, Source: "fs1", Major: 0, Minor: 52, Root: "/", MountPoint: "/ibm/fs1"}
+	fs2 := mountInfo{FsType: gpfsFsType, Source: "fs2", Major: 0, Minor: 53, Root: "/", MountPoint: "/ibm/fs2"}
+	bindMount := mountInfo{FsType: gpfsFsType, Source: "fs1", Major: 0, Minor: 52, Root: "/fset1", MountPoint: "/var/lib/kubelet/pods/uid/mount"}
+	ext4 := mountInfo{FsType: "ext4", Source: "/dev/sda1", Major: 8, Minor: 1, Root: "/", MountPoint: "/"}
+
+	mounts := gpfsFilesystemMounts([]mountInfo{ext4, fs2, fs1})
+	if want := "fs1 0:52\nfs2 0:53"; mounts != want {
+		t.Errorf("gpfsFilesystemMounts() = %q, want %q", mounts, want)
+	}
+	if withBindMount := gpfsFilesystemMounts([]mountInfo{ext4, fs2, fs1, bindMount}); withBindMount != mounts {
+		t.Errorf("gpfsFilesystemMounts() with a bind mount = %q, want %q", withBindMount, mounts)
+	}
+	remounted := fs1
+	remounted.Minor = 60
+	if got := gpfsFilesystemMounts([]mountInfo{ext4, fs2, remounted, bindMount}); got == mounts {
+		t.Errorf("gpfsFilesystemMounts() after a remount = %q, want a change", got)
+	}
+}
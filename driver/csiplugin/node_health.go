@@ -0,0 +1,166 @@
+/**
+ * Copyright 2024 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scale
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/connectors"
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/utils"
+)
+
+const (
+	publishNodeHealthCheck         = "PUBLISH_NODE_HEALTH_CHECK"
+	publishNodeHealthCheckDisabled = "DISABLED"
+
+	healthComponentGPFS       = "GPFS"
+	healthComponentFilesystem = "FILESYSTEM"
+	healthEntityNode          = "NODE"
+	healthEntityFilesystem    = "FILESYSTEM"
+
+	// nodeHealthCacheTTL is how long the health states of a node are reused
+	// by the publishes to the node
+	nodeHealthCacheTTL = 30 * time.Second
+)
+
+// Health states of the GPFS daemon and of the filesystems of a node in
+// which volumes are published to the node. The daemon is not healthy while
+// it is down, arbitrating or waiting for quorum, and a mount on the node
+// would hang then.
+var (
+	publishableGPFSStates       = []string{"HEALTHY", "TIPS"}
+	publishableFilesystemStates = []string{"HEALTHY", "TIPS", "DEGRADED"}
+)
+
+type nodeHealthEntry struct {
+	states []connectors.State
+	expiry time.Time
+}
+
+// nodeHealthCache caches the health states of the components of the IBM
+// Storage Scale nodes, so that the publishes of the volumes of a pod do not
+// query the GUI each.
+type nodeHealthCache struct {
+	mutex sync.Mutex
+	cache map[string]nodeHealthEntry
+}
+
+// getNodeHealthStates returns the health states of a component of a node,
+// from the cache if they were queried within nodeHealthCacheTTL. The states
+// are queried without holding the mutex, and failed queries are not cached.
+func (healthCache *nodeHealthCache) getNodeHealthStates(ctx context.Context, conn connectors.SpectrumScaleConnector, scaleNode string, component string) ([]connectors.State, error) {
+	key := scaleNode + "/" + component
+	healthCache.mutex.Lock()
+	entry, ok := healthCache.cache[key]
+	healthCache.mutex.Unlock()
+	if ok && time.Now().Before(entry.expiry) {
+		return entry.states, nil
+	}
+
+	states, err := conn.GetNodeHealthStates(ctx, scaleNode, component)
+	if err != nil {
+		return nil, err
+	}
+	healthCache.mutex.Lock()
+	defer healthCache.mutex.Unlock()
+	if healthCache.cache == nil {
+		healthCache.cache = make(map[string]nodeHealthEntry)
+	}
+	healthCache.cache[key] = nodeHealthEntry{states: states, expiry: time.Now().Add(nodeHealthCacheTTL)}
+	return states, nil
+}
+
+// invalidate drops the cached health states of a component of a node, e.g.
+// after a filesystem was mounted on the node.
+func (healthCache *nodeHealthCache) invalidate(scaleNode string, component string) {
+	healthCache.mutex.Lock()
+	defer healthCache.mutex.Unlock()
+	delete(healthCache.cache, scaleNode+"/"+component)
+}
+
+// checkPublishNodeHealth checks the health of the GPFS daemon on the IBM
+// Storage Scale node a volume is published to, before its filesystems are
+// mounted on the node. Unavailable is returned with the reason if it is not
+// healthy, so that the publish is retried. The publish is not blocked if the
+// health states cannot be queried, e.g. when the health monitoring is not
+// running on the node. The health states are cached for nodeHealthCacheTTL.
+func (driver *ScaleDriver) checkPublishNodeHealth(ctx context.Context, scaleNode string) error {
+	loggerId := utils.GetLoggerId(ctx)
+	if strings.ToUpper(os.Getenv(publishNodeHealthCheck)) == publishNodeHealthCheckDisabled {
+		return nil
+	}
+	conn := driver.connmap["primary"]
+
+	states, err := driver.nodeHealth.getNodeHealthStates(ctx, conn, scaleNode, healthComponentGPFS)
+	if err != nil {
+		klog.Warningf("[%s] unable to check the health of IBM Storage Scale on node [%s], skipping the check: %v", loggerId, scaleNode, err)
+		return nil
+	}
+	for _, state := range states {
+		if state.EntityType == healthEntityNode && !utils.StringInSlice(state.State, publishableGPFSStates) {
+			klog.Errorf("[%s] IBM Storage Scale on node [%s] is %s: %+v", loggerId, scaleNode, state.State, state)
+			return status.Error(codes.Unavailable, fmt.Sprintf("IBM Storage Scale on node [%s] is %s%s", scaleNode, state.State, healthStateReason(state)))
+		}
+	}
+	return nil
+}
+
+// checkPublishFilesystemHealth checks the health of the filesystems of a
+// volume on the IBM Storage Scale node the volume is published to, once they
+// are mounted on the node. It is skipped and fails the same way as
+// checkPublishNodeHealth.
+func (driver *ScaleDriver) checkPublishFilesystemHealth(ctx context.Context, scaleNode string, filesystems []string) error {
+	loggerId := utils.GetLoggerId(ctx)
+	if strings.ToUpper(os.Getenv(publishNodeHealthCheck)) == publishNodeHealthCheckDisabled {
+		return nil
+	}
+	conn := driver.connmap["primary"]
+
+	states, err := driver.nodeHealth.getNodeHealthStates(ctx, conn, scaleNode, healthComponentFilesystem)
+	if err != nil {
+		klog.Warningf("[%s] unable to check the health of the filesystems on node [%s], skipping the check: %v", loggerId, scaleNode, err)
+		return nil
+	}
+	for _, state := range states {
+		if state.EntityType == healthEntityFilesystem && utils.StringInSlice(state.EntityName, filesystems) &&
+			!utils.StringInSlice(state.State, publishableFilesystemStates) {
+			klog.Errorf("[%s] filesystem [%s] on node [%s] is %s: %+v", loggerId, state.EntityName, scaleNode, state.State, state)
+			return status.Error(codes.Unavailable, fmt.Sprintf("filesystem [%s] on node [%s] is %s%s", state.EntityName, scaleNode, state.State, healthStateReason(state)))
+		}
+	}
+	return nil
+}
+
+// healthStateReason returns the reason of a health state reported by the
+// GUI, or refers to mmhealth when there is none.
+func healthStateReason(state connectors.State) string {
+	for _, reason := range []string{state.Message, state.Description, state.Name} {
+		if reason != "" {
+			return ": " + reason
+		}
+	}
+	return fmt.Sprintf(", check mmhealth node show %s", state.Component)
+}
@@ -0,0 +1,310 @@
+/**
+ * Copyright 2024 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scale
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/connectors"
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/utils"
+)
+
+const (
+	// scaleNodeNameKey is the annotation or the label of a Kubernetes node
+	// with the admin node name of its IBM Storage Scale node
+	scaleNodeNameKey = "csi.ibm.com/scale-node-name"
+	// mappedScaleNodeNameKey is the annotation of a Kubernetes node with
+	// the IBM Storage Scale node it is mapped to by the driver, read by the
+	// operator to map the node the same way
+	mappedScaleNodeNameKey = "csi.ibm.com/mapped-scale-node-name"
+
+	nodeMappingCacheTTL = 5 * time.Minute
+	// nodeMappingRetryTTL is used when a source could not be checked
+	nodeMappingRetryTTL = 30 * time.Second
+)
+
+// Sources of a node mapping, in order of precedence
+const (
+	nodeMappingSourceStatic     = "nodeMapping of the CSIScaleOperator"
+	nodeMappingSourceAnnotation = "annotation " + scaleNodeNameKey
+	nodeMappingSourceLabel      = "label " + scaleNodeNameKey
+	nodeMappingSourceCRD        = "ScaleNodeMapping"
+	nodeMappingSourceAddress    = "node addresses"
+)
+
+// scaleNodeMappingResource is the ScaleNodeMapping custom resource of the
+// operator, with spec.k8sNode and spec.scaleNode
+var scaleNodeMappingResource = schema.GroupVersionResource{Group: "csi.ibm.com", Version: "v1", Resource: "scalenodemappings"}
+
+type nodeMappingEntry struct {
+	scaleNode string
+	source    string
+	expiry    time.Time
+}
+
+// nodeMapper resolves the names of the Kubernetes nodes to the admin node
+// names of the IBM Storage Scale nodes and caches them. The mutex guards only
+// the cache, the nodes are resolved without holding it.
+type nodeMapper struct {
+	mutex sync.Mutex
+	cache map[string]nodeMappingEntry
+	// refreshing holds the nodes resolved in the background
+	refreshing map[string]bool
+}
+
+// get returns the cached mapping of a node, and false if it is not cached or expired
+func (mapper *nodeMapper) get(k8sNode string) (nodeMappingEntry, bool) {
+	mapper.mutex.Lock()
+	defer mapper.mutex.Unlock()
+	entry, ok := mapper.cache[k8sNode]
+	return entry, ok && time.Now().Before(entry.expiry)
+}
+
+// set caches the mapping of a node
+func (mapper *nodeMapper) set(k8sNode string, entry nodeMappingEntry) {
+	mapper.mutex.Lock()
+	defer mapper.mutex.Unlock()
+	if mapper.cache == nil {
+		mapper.cache = make(map[string]nodeMappingEntry)
+	}
+	mapper.cache[k8sNode] = entry
+}
+
+type nodeMappingCandidate struct {
+	scaleNode string
+	source    string
+}
+
+// getScaleNodeName returns the admin node name of the IBM Storage Scale node
+// of a Kubernetes node. It is resolved, in order of precedence, from the
+// nodeMapping of the CSIScaleOperator, the csi.ibm.com/scale-node-name
+// annotation or label of the node, a ScaleNodeMapping of the node, or by
+// matching the addresses of the node with the nodes of the primary cluster.
+// The Kubernetes node name is used if none of them maps the node. A warning
+// is logged when the sources map the node to different nodes. The resolved
+// node is recorded in the csi.ibm.com/mapped-scale-node-name annotation of
+// the Kubernetes node.
+func (driver *ScaleDriver) getScaleNodeName(ctx context.Context, k8sNode string) string {
+	if entry, ok := driver.nodeMapper.get(k8sNode); ok {
+		return entry.scaleNode
+	}
+	entry := driver.resolveScaleNodeName(ctx, k8sNode)
+	driver.nodeMapper.set(k8sNode, entry)
+	return entry.scaleNode
+}
+
+// getCachedScaleNodeName returns the IBM Storage Scale node of a Kubernetes
+// node without waiting for the GUI or the Kubernetes API. If the mapping is
+// not cached or expired it is resolved in the background, and the last
+// resolved node, or else the nodeMapping of the CSIScaleOperator or the
+// Kubernetes node name, is returned meanwhile.
+func (driver *ScaleDriver) getCachedScaleNodeName(ctx context.Context, k8sNode string) string {
+	mapper := &driver.nodeMapper
+	mapper.mutex.Lock()
+	defer mapper.mutex.Unlock()
+	entry, ok := mapper.cache[k8sNode]
+	if ok && time.Now().Before(entry.expiry) {
+		return entry.scaleNode
+	}
+	if !mapper.refreshing[k8sNode] {
+		if mapper.refreshing == nil {
+			mapper.refreshing = make(map[string]bool)
+		}
+		mapper.refreshing[k8sNode] = true
+		klog.V(4).Infof("[%s] node mapping: resolving node [%s] in the background", utils.GetLoggerId(ctx), k8sNode)
+		go func() {
+			driver.getScaleNodeName(utils.SetLoggerId(context.Background()), k8sNode)
+			mapper.mutex.Lock()
+			delete(mapper.refreshing, k8sNode)
+			mapper.mutex.Unlock()
+		}()
+	}
+	if ok {
+		return entry.scaleNode
+	}
+	if scaleNode, found := getStaticNodeMapping(k8sNode); found {
+		return scaleNode
+	}
+	return k8sNode
+}
+
+// resolveScaleNodeName resolves the IBM Storage Scale node of a Kubernetes
+// node from the sources of the mappings, see getScaleNodeName.
+func (driver *ScaleDriver) resolveScaleNodeName(ctx context.Context, k8sNode string) nodeMappingEntry {
+	loggerId := utils.GetLoggerId(ctx)
+
+	var candidates []nodeMappingCandidate
+	if scaleNode, ok := getStaticNodeMapping(k8sNode); ok {
+		candidates = append(candidates, nodeMappingCandidate{scaleNode, nodeMappingSourceStatic})
+	}
+
+	resolved := true
+	var node *corev1.Node
+	scaleNodes, err := driver.connmap["primary"].ListNodes(ctx)
+	if err != nil {
+		klog.Warningf("[%s] node mapping: unable to list the nodes of the primary cluster: %v", loggerId, err)
+		resolved = false
+	}
+
+	client, err := getKubeClient()
+	if err != nil {
+		klog.Warningf("[%s] node mapping: unable to create Kubernetes client: %v", loggerId, err)
+		resolved = false
+	} else {
+		node, err = client.CoreV1().Nodes().Get(ctx, k8sNode, metav1.GetOptions{})
+		if err != nil {
+			klog.Warningf("[%s] node mapping: unable to get node [%s]: %v", loggerId, k8sNode, err)
+			resolved = false
+		} else {
+			if scaleNode := node.Annotations[scaleNodeNameKey]; scaleNode != "" {
+				candidates = append(candidates, nodeMappingCandidate{scaleNode, nodeMappingSourceAnnotation})
+			}
+			if scaleNode := node.Labels[scaleNodeNameKey]; scaleNode != "" {
+				candidates = append(candidates, nodeMappingCandidate{scaleNode, nodeMappingSourceLabel})
+			}
+			if scaleNode, ok := getCRDNodeMapping(ctx, k8sNode); ok {
+				candidates = append(candidates, nodeMappingCandidate{scaleNode, nodeMappingSourceCRD})
+			}
+			if scaleNode := matchNodeAddresses(node, scaleNodes); scaleNode != "" {
+				candidates = append(candidates, nodeMappingCandidate{scaleNode, nodeMappingSourceAddress})
+			}
+		}
+	}
+
+	scaleNode := k8sNode
+	source := "Kubernetes node name"
+	if len(candidates) > 0 {
+		scaleNode, source = candidates[0].scaleNode, candidates[0].source
+		for _, candidate := range candidates[1:] {
+			if !sameNodeName(candidate.scaleNode, scaleNode) {
+				klog.Warningf("[%s] node mapping: node [%s] is mapped to [%s] by %s but to [%s] by %s, using [%s]", loggerId, k8sNode, scaleNode, source, candidate.scaleNode, candidate.source, scaleNode)
+			}
+		}
+	}
+	if scaleNodes != nil && !isScaleNode(scaleNode, scaleNodes) {
+		klog.Warningf("[%s] node mapping: node [%s] is mapped to [%s] by %s, which is not a node of the primary cluster", loggerId, k8sNode, scaleNode, source)
+	}
+	klog.V(4).Infof("[%s] node mapping: node [%s] is mapped to [%s] by %s", loggerId, k8sNode, scaleNode, source)
+
+	ttl := nodeMappingCacheTTL
+	if !resolved {
+		ttl = nodeMappingRetryTTL
+	} else if node.Annotations[mappedScaleNodeNameKey] != scaleNode {
+		if err := recordScaleNodeName(ctx, client, k8sNode, scaleNode); err != nil {
+			klog.Warningf("[%s] node mapping: unable to record the mapping of node [%s] in annotation %s: %v", loggerId, k8sNode, mappedScaleNodeNameKey, err)
+		}
+	}
+	return nodeMappingEntry{scaleNode: scaleNode, source: source, expiry: time.Now().Add(ttl)}
+}
+
+// recordScaleNodeName records the IBM Storage Scale node a Kubernetes node
+// is mapped to in the csi.ibm.com/mapped-scale-node-name annotation of the
+// node.
+func recordScaleNodeName(ctx context.Context, client kubernetes.Interface, k8sNode string, scaleNode string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node, err := client.CoreV1().Nodes().Get(ctx, k8sNode, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if node.Annotations == nil {
+			node.Annotations = make(map[string]string)
+		}
+		node.Annotations[mappedScaleNodeNameKey] = scaleNode
+		_, err = client.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// getCRDNodeMapping returns the IBM Storage Scale node of a Kubernetes node
+// from the ScaleNodeMappings.
+func getCRDNodeMapping(ctx context.Context, k8sNode string) (string, bool) {
+	loggerId := utils.GetLoggerId(ctx)
+	client, err := getKubeDynamicClient()
+	if err != nil {
+		return "", false
+	}
+	mappings, err := client.Resource(scaleNodeMappingResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.V(4).Infof("[%s] node mapping: unable to list ScaleNodeMappings: %v", loggerId, err)
+		return "", false
+	}
+	for _, mapping := range mappings.Items {
+		node, _, _ := unstructured.NestedString(mapping.Object, "spec", "k8sNode")
+		if node != k8sNode {
+			continue
+		}
+		scaleNode, _, _ := unstructured.NestedString(mapping.Object, "spec", "scaleNode")
+		if scaleNode != "" {
+			return scaleNode, true
+		}
+	}
+	return "", false
+}
+
+// matchNodeAddresses returns the admin node name of the IBM Storage Scale
+// node with an address or a name of a Kubernetes node.
+func matchNodeAddresses(node *corev1.Node, scaleNodes []connectors.Node_v2) string {
+	for _, address := range node.Status.Addresses {
+		for _, scaleNode := range scaleNodes {
+			switch address.Address {
+			case scaleNode.Network.AdminIPAddress, scaleNode.Network.DaemonIPAddress:
+				return scaleNode.AdminNodename
+			}
+			if address.Type == corev1.NodeHostName || address.Type == corev1.NodeInternalDNS {
+				if sameNodeName(address.Address, scaleNode.AdminNodename) || sameNodeName(address.Address, scaleNode.Network.DaemonNodeName) {
+					return scaleNode.AdminNodename
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func isScaleNode(name string, scaleNodes []connectors.Node_v2) bool {
+	for _, scaleNode := range scaleNodes {
+		if sameNodeName(name, scaleNode.AdminNodename) || sameNodeName(name, scaleNode.Network.DaemonNodeName) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameNodeName compares node names, also by their short names when
+// SHORTNAME_NODE_MAPPING is set.
+func sameNodeName(name1 string, name2 string) bool {
+	if name1 == "" || name2 == "" {
+		return false
+	}
+	if strings.EqualFold(name1, name2) {
+		return true
+	}
+	if utils.GetEnv(SHORTNAME_NODE_MAPPING, no) == yes {
+		return strings.EqualFold(strings.SplitN(name1, ".", 2)[0], strings.SplitN(name2, ".", 2)[0])
+	}
+	return false
+}
@@ -19,14 +19,18 @@ package scale
 import (
 	"fmt"
 	"os"
-	"os/exec"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 
 	"k8s.io/klog/v2"
 
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/connectors"
 	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/utils"
 	"golang.org/x/net/context"
+	"golang.org/x/sys/unix"
 	"k8s.io/mount-utils"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
@@ -47,7 +51,12 @@ const errStaleNFSFileHandle = "stale NFS file handle"
 const nodePublishMethod = "NODEPUBLISH_METHOD"
 const nodePublishMethodSymlink = "SYMLINK"
 
-const mountPathLength = 6
+const procMountInfoPath = "/proc/self/mountinfo"
+const seLinuxContextOption = "context"
+
+// seLinuxContextRegex matches the context mount option passed by kubelet,
+// e.g. context="system_u:object_r:container_file_t:s0:c1,c2"
+var seLinuxContextRegex = regexp.MustCompile(`^context="?[a-zA-Z0-9_.-]+:[a-zA-Z0-9_.-]+:[a-zA-Z0-9_.-]+(:[a-zA-Z0-9_.,:-]+)?"?$`)
 
 const ENVClusterCNSAPresenceCheck = "CNSADeployment"
 
@@ -88,13 +97,11 @@ func unlock(targetPath string, ctx context.Context) {
 // returns nil if it is a gpfs type, otherwise returns
 // corresponding error.
 func checkGpfsType(ctx context.Context, path string) error {
-	gpfsPaths := getGpfsPaths(ctx)
-	isGpfsPath := false
-	for _, gpfsPath := range gpfsPaths {
-		if strings.HasPrefix(path, gpfsPath) {
-			isGpfsPath = true
-			break
-		}
+	isGpfsPath := isUnderGpfsPath(path, getGpfsPaths(ctx))
+	if !isGpfsPath {
+		// The mount table may not be refreshed yet after a filesystem was
+		// just mounted
+		isGpfsPath = isUnderGpfsPath(path, readGpfsMountPoints(ctx))
 	}
 
 	if !isGpfsPath {
@@ -104,37 +111,19 @@ func checkGpfsType(ctx context.Context, path string) error {
 	return nil
 }
 
-func getGpfsPaths(ctx context.Context) []string {
-	var gpfsPaths []string
-	gpfsPathCmd := `cat /proc/mounts | grep "gpfs"`
-	cmd := exec.Command("bash", "-c", gpfsPathCmd)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		klog.Errorf("[%s] Error in executing command: [%s]", utils.GetLoggerId(ctx), err)
-	} else {
-		outputPaths := string(output)
-		strOutput := strings.Split(outputPaths, "\n")
-		for _, out := range strOutput {
-			finalOutput := strings.Split(out, " ")
-			if len(finalOutput) == mountPathLength {
-				if finalOutput[1] != "" && finalOutput[2] == "gpfs" {
-					cnsaPresence, ok := os.LookupEnv(ENVClusterCNSAPresenceCheck)
-					if ok && cnsaPresence == "True" {
-						before, after, found := strings.Cut(finalOutput[1], "/var")
-						if found && before == hostDir && strings.HasPrefix(after, mountPath) {
-							openShiftMountPath := before + after
-							gpfsPaths = append(gpfsPaths, openShiftMountPath)
-						} else {
-							gpfsPaths = append(gpfsPaths, finalOutput[1])
-						}
-					} else {
-						gpfsPaths = append(gpfsPaths, finalOutput[1])
-					}
-				}
-			}
+func isUnderGpfsPath(path string, gpfsPaths []string) bool {
+	for _, gpfsPath := range gpfsPaths {
+		if strings.HasPrefix(path, gpfsPath) {
+			return true
 		}
 	}
-	return gpfsPaths
+	return false
+}
+
+// getGpfsPaths returns the mount points of the GPFS filesystems of the node
+// from the GPFS mount table.
+func getGpfsPaths(ctx context.Context) []string {
+	return gpfsMounts.getPaths(ctx)
 }
 
 func (ns *ScaleNodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolumeRequest) (*csi.NodePublishVolumeResponse, error) {
@@ -193,9 +182,28 @@ func (ns *ScaleNodeServer) NodePublishVolume(ctx context.Context, req *csi.NodeP
 		return nil, err
 	}
 
+	// A readonly volume is not written by the pod, it needs no group
+	if mountGroup := volumeCapability.GetMount().GetVolumeMountGroup(); mountGroup != "" && !isReadonlyPublish(req) {
+		keepPermissions := req.GetVolumeContext()[connectors.UserSpecifiedPermissions] != ""
+		err = applyVolumeMountGroup(ctx, volScalePathInContainer, mountGroup, keepPermissions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	seLinuxContext, err := getSELinuxMountContext(volumeCapability.GetMount().GetMountFlags())
+	if err != nil {
+		return nil, err
+	}
+
 	method := strings.ToUpper(os.Getenv(nodePublishMethod))
 	klog.V(4).Infof("[%s] NodePublishVolume - NodePublishVolume method used: %s", loggerId, method)
 
+	if method == nodePublishMethodSymlink && seLinuxContext != "" {
+		klog.Errorf("[%s] NodePublishVolume - SELinux mount context [%s] is requested for targetPath [%s] with %s method", loggerId, seLinuxContext, targetPath, method)
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("SELinux mount context is not supported with %s=%s, the volume must be bind mounted", nodePublishMethod, nodePublishMethodSymlink))
+	}
+
 	if method == nodePublishMethodSymlink {
 		//There can be 2 symlinks here:
 		//1. symlink1 (volScalePath): User provides a symlink as path for volume
@@ -266,6 +274,12 @@ func (ns *ScaleNodeServer) NodePublishVolume(ctx context.Context, req *csi.NodeP
 
 		// create bind mount
 		options := []string{"bind"}
+		if seLinuxContext != "" {
+			err = checkSELinuxContext(ctx, volScalePathInContainer, seLinuxContext)
+			if err != nil {
+				return nil, err
+			}
+		}
 		klog.V(4).Infof("[%s] NodePublishVolume - creating bind mount [%v] -> [%v]", loggerId, targetPath, volScalePath)
 		if err := mounter.Mount(volScalePath, targetPath, "", options); err != nil {
 			klog.Errorf("[%s] NodePublishVolume - mounting [%s] at [%s] failed with error [%v]", loggerId, volScalePath, targetPath, err)
@@ -287,6 +301,128 @@ func (ns *ScaleNodeServer) NodePublishVolume(ctx context.Context, req *csi.NodeP
 	return &csi.NodePublishVolumeResponse{}, nil
 }
 
+// getSELinuxMountContext returns the context= mount option passed by
+// kubelet in the mount flags of a volume when the CSIDriver has seLinuxMount
+// set. The other SELinux mount options are refused. The context is not
+// passed to the bind mount of the volume, see checkSELinuxContext.
+func getSELinuxMountContext(mountFlags []string) (string, error) {
+	seLinuxContext := ""
+	// The level of a context has commas, e.g. s0:c1,c2, so kubelet passes it
+	// as a flag of its own
+	for _, option := range mountFlags {
+		switch {
+		case strings.HasPrefix(option, seLinuxContextOption+"="):
+			if !seLinuxContextRegex.MatchString(option) {
+				return "", status.Error(codes.InvalidArgument, fmt.Sprintf("invalid SELinux mount context [%s]", option))
+			}
+			if seLinuxContext != "" && seLinuxContext != option {
+				return "", status.Error(codes.InvalidArgument, fmt.Sprintf("more than one SELinux mount context is specified: [%s], [%s]", seLinuxContext, option))
+			}
+			seLinuxContext = option
+		case strings.Contains(option, "fscontext="), strings.Contains(option, "defcontext="), strings.Contains(option, "rootcontext="):
+			return "", status.Error(codes.InvalidArgument, fmt.Sprintf("mount option [%s] is not supported, only %s is supported", option, seLinuxContextOption))
+		}
+	}
+	return seLinuxContext, nil
+}
+
+// checkSELinuxContext checks that the filesystem of a volume is mounted on
+// the node with the SELinux context requested for the volume. The context is
+// an option of the superblock of the filesystem, it can not be set or changed
+// by the bind mount of a volume, so the volumes of a filesystem can only be
+// used without relabeling by pods running with the context of its mount.
+func checkSELinuxContext(ctx context.Context, volPath string, seLinuxContext string) error {
+	loggerId := utils.GetLoggerId(ctx)
+	var stat syscall.Stat_t
+	if err := syscall.Stat(volPath, &stat); err != nil {
+		klog.Errorf("[%s] NodePublishVolume - stat [%s] failed with error [%v]", loggerId, volPath, err)
+		return status.Error(codes.Internal, fmt.Sprintf("stat [%s] failed with error [%v]", volPath, err))
+	}
+	major, minor := int(unix.Major(uint64(stat.Dev))), int(unix.Minor(uint64(stat.Dev)))
+
+	mountInfos, err := mount.ParseMountInfo(procMountInfoPath)
+	if err != nil {
+		klog.Errorf("[%s] NodePublishVolume - parsing [%s] failed with error [%v]", loggerId, procMountInfoPath, err)
+		return status.Error(codes.Internal, fmt.Sprintf("parsing [%s] failed with error [%v]", procMountInfoPath, err))
+	}
+	fsContext := ""
+	for _, mountInfo := range mountInfos {
+		if mountInfo.Major != major || mountInfo.Minor != minor {
+			continue
+		}
+		for _, option := range append(mountInfo.SuperOptions, mountInfo.MountOptions...) {
+			if strings.HasPrefix(option, seLinuxContextOption+"=") {
+				fsContext = option
+				break
+			}
+		}
+		break
+	}
+	if strings.Trim(fsContext, "\"") != strings.Trim(seLinuxContext, "\"") {
+		klog.Errorf("[%s] NodePublishVolume - filesystem of [%s] is mounted with SELinux context [%s], [%s] is requested", loggerId, volPath, fsContext, seLinuxContext)
+		return status.Error(codes.FailedPrecondition, fmt.Sprintf("the volume requires the SELinux mount option [%s], but its filesystem is mounted with [%s] on this node. Mount the filesystem with this context or disable seLinuxMount in the CSIScaleOperator", seLinuxContext, fsContext))
+	}
+	return nil
+}
+
+// isReadonlyPublish returns true if a volume is published readonly or with
+// a reader only access mode.
+func isReadonlyPublish(req *csi.NodePublishVolumeRequest) bool {
+	switch req.GetVolumeCapability().GetAccessMode().GetMode() {
+	case csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY, csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY:
+		return true
+	}
+	return req.GetReadonly()
+}
+
+// applyVolumeMountGroup applies the fsGroup of a pod, passed as the volume
+// mount group, to the root directory of a volume: the group owns it and the
+// setgid bit makes new files and directories inherit the group. The group
+// is given write permission, unless the permissions of the volume are set
+// by the storageClass; bits are only added, never removed. Unlike kubelet,
+// the files of the volume are not changed, and nothing is changed when the
+// root directory has the group and the bits already.
+func applyVolumeMountGroup(ctx context.Context, path string, mountGroup string, keepPermissions bool) error {
+	loggerId := utils.GetLoggerId(ctx)
+	gid, err := strconv.Atoi(mountGroup)
+	if err != nil || gid < 0 {
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("volume mount group [%s] is not a valid group ID", mountGroup))
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		klog.Errorf("[%s] NodePublishVolume - stat [%s] failed with error [%v]", loggerId, path, err)
+		return status.Error(codes.Internal, fmt.Sprintf("stat [%s] failed with error [%v]", path, err))
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return status.Error(codes.Internal, fmt.Sprintf("unable to get the owner of [%s]", path))
+	}
+
+	if int(stat.Gid) != gid {
+		klog.Infof("[%s] NodePublishVolume - applying the volume mount group [%d] to [%s]", loggerId, gid, path)
+		if err := os.Chown(path, -1, gid); err != nil {
+			klog.Errorf("[%s] NodePublishVolume - chown [%s] failed with error [%v]", loggerId, path, err)
+			return status.Error(codes.Internal, fmt.Sprintf("chown [%s] to group [%d] failed with error [%v]", path, gid, err))
+		}
+	}
+
+	bits := os.ModeSetgid
+	if !keepPermissions {
+		bits |= 0070
+	}
+	mode := info.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+	if mode&bits == bits {
+		klog.V(4).Infof("[%s] NodePublishVolume - [%s] has the volume mount group [%d] already", loggerId, path, gid)
+		return nil
+	}
+	if err := os.Chmod(path, mode|bits); err != nil {
+		klog.Errorf("[%s] NodePublishVolume - chmod [%s] failed with error [%v]", loggerId, path, err)
+		return status.Error(codes.Internal, fmt.Sprintf("chmod [%s] to [%v] failed with error [%v]", path, mode|bits, err))
+	}
+	return nil
+}
+
 // unmountAndDelete unmounts and deletes a targetPath (forcefully if
 // foreceful=true is passed) and returns a bool which tells if a
 // calling function should return, along with the response and error
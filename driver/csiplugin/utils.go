@@ -21,14 +21,54 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/utils"
 	csi "github.com/container-storage-interface/spec/lib/go/csi"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
 )
 
+var (
+	kubeClient        kubernetes.Interface
+	kubeDynamicClient dynamic.Interface
+	kubeClientErr     error
+	kubeClientOnce    sync.Once
+)
+
+func initKubeClients() {
+	kubeClientOnce.Do(func() {
+		config, err := rest.InClusterConfig()
+		if err != nil {
+			kubeClientErr = err
+			return
+		}
+		kubeClient, kubeClientErr = kubernetes.NewForConfig(config)
+		if kubeClientErr != nil {
+			return
+		}
+		kubeDynamicClient, kubeClientErr = dynamic.NewForConfig(config)
+	})
+}
+
+// getKubeClient returns the in-cluster client of the driver, which is
+// created on first use.
+func getKubeClient() (kubernetes.Interface, error) {
+	initKubeClients()
+	return kubeClient, kubeClientErr
+}
+
+// getKubeDynamicClient returns the in-cluster dynamic client of the driver,
+// for the custom resources of the operator.
+func getKubeDynamicClient() (dynamic.Interface, error) {
+	initKubeClients()
+	return kubeDynamicClient, kubeClientErr
+}
+
 func NewVolumeCapabilityAccessMode(mode csi.VolumeCapability_AccessMode_Mode) *csi.VolumeCapability_AccessMode {
 	return &csi.VolumeCapability_AccessMode{Mode: mode}
 }
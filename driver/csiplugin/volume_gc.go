@@ -0,0 +1,540 @@
+/**
+ * Copyright 2024 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scale
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/connectors"
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/utils"
+)
+
+const (
+	// VOLUME_GC_MODE is the mode of the garbage collection of the orphaned
+	// volumes: DISABLED, REPORT to only report them (default) or DELETE to
+	// also delete them. DELETE must only be used when the filesystems are not
+	// shared with the volumes of another Kubernetes cluster.
+	VOLUME_GC_MODE = "VOLUME_GC_MODE"
+	// VOLUME_GC_INTERVAL is the interval of the garbage collection, e.g. 6h
+	VOLUME_GC_INTERVAL = "VOLUME_GC_INTERVAL"
+	// VOLUME_GC_MIN_AGE is the age from which an orphaned volume is reported
+	// and deleted, so that the volumes being created are left alone, e.g. 24h
+	VOLUME_GC_MIN_AGE = "VOLUME_GC_MIN_AGE"
+
+	volumeGCModeDisabled = "DISABLED"
+	volumeGCModeReport   = "REPORT"
+	volumeGCModeDelete   = "DELETE"
+
+	defaultVolumeGCInterval = 6 * time.Hour
+	defaultVolumeGCMinAge   = 24 * time.Hour
+
+	serviceAccountNamespacePath = "/var/run/secrets/kubernetes.io/serviceaccount/namespace" // #nosec G101 -- not a credential
+
+	// Types of the orphaned objects
+	orphanFileset        = "fileset"
+	orphanSymlink        = "symlink"
+	orphanShallowCopyRef = "shallow_copy_reference"
+
+	orphanDeleteSucceeded = "succeeded"
+	orphanDeleteFailed    = "failed"
+
+	// Reasons of the events of the node
+	orphanedVolumeReason   = "OrphanedVolumeFound"
+	orphanDeletedReason    = "OrphanedVolumeDeleted"
+	orphanDeleteFailReason = "OrphanedVolumeDeleteFailed"
+)
+
+var (
+	orphanedVolumeObjects = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ibm_spectrum_scale_csi_orphaned_volume_objects",
+		Help: "Number of filesets, symlinks and shallow copy reference directories created by the driver which no PersistentVolume uses, found by the last garbage collection.",
+	}, []string{"type"})
+	orphanedVolumeDeletions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ibm_spectrum_scale_csi_orphaned_volume_object_deletions_total",
+		Help: "Number of deletions of orphaned filesets, symlinks and shallow copy reference directories by type and result.",
+	}, []string{"type", "result"})
+)
+
+func init() {
+	prometheus.MustRegister(orphanedVolumeObjects, orphanedVolumeDeletions)
+}
+
+// volumeGC finds the objects of the volumes left behind by failed creates,
+// interrupted deletes and PersistentVolumes deleted manually: the filesets
+// tagged with the fileset comment of the driver, the symlinks under the
+// .volumes directory of the primary fileset and the reference directories of
+// the shallow copy volumes, which no PersistentVolume of the driver uses. The
+// age of a fileset is measured from the time the driver created or adopted it,
+// which is recorded in its comment. The filesets without the time are skipped,
+// e.g. the filesets created by earlier releases, and so are the AFM-DR
+// secondary filesets of the replicated volumes, which have no PersistentVolume
+// on this cluster. They are reported by metrics and events of the node running the collection, and
+// deleted in the DELETE mode. Only one node plugin runs the collection, which
+// is elected by a lease.
+type volumeGC struct {
+	cs       *ScaleControllerServer
+	mode     string
+	minAge   time.Duration
+	recorder record.EventRecorder
+}
+
+// volumeReferences are the objects used by the PersistentVolumes.
+type volumeReferences struct {
+	// filesystem UUID/fileset name or filesystem UUID/#fileset ID
+	filesets map[string]bool
+	// names of the symlinks under the .volumes directory
+	symlinks map[string]bool
+	// filesystem UUID/snapshot/volume
+	shallowCopyRefs map[string]bool
+}
+
+func getVolumeGCDuration(ctx context.Context, key string, defaultValue time.Duration) time.Duration {
+	value := utils.GetEnv(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		klog.Warningf("[%s] invalid %s [%s], using %v", utils.GetLoggerId(ctx), key, value, defaultValue)
+		return defaultValue
+	}
+	return duration
+}
+
+// startVolumeGC starts the garbage collection of the orphaned volumes unless
+// it is disabled. The node plugin which holds the lease of the collection
+// runs it.
+func (driver *ScaleDriver) startVolumeGC(ctx context.Context, recorder record.EventRecorder) {
+	loggerId := utils.GetLoggerId(ctx)
+	mode := strings.ToUpper(utils.GetEnv(VOLUME_GC_MODE, volumeGCModeReport))
+	if mode == volumeGCModeDisabled {
+		klog.Infof("[%s] garbage collection of orphaned volumes is disabled", loggerId)
+		return
+	}
+	if mode != volumeGCModeReport && mode != volumeGCModeDelete {
+		klog.Warningf("[%s] invalid %s [%s], using %s", loggerId, VOLUME_GC_MODE, mode, volumeGCModeReport)
+		mode = volumeGCModeReport
+	}
+	client, err := getKubeClient()
+	if err != nil {
+		klog.Errorf("[%s] garbage collection of orphaned volumes is disabled, unable to create the Kubernetes client: %v", loggerId, err)
+		return
+	}
+	namespace, err := os.ReadFile(serviceAccountNamespacePath)
+	if err != nil {
+		klog.Errorf("[%s] garbage collection of orphaned volumes is disabled, unable to get the namespace of the driver: %v", loggerId, err)
+		return
+	}
+
+	gc := &volumeGC{
+		cs:       driver.cs,
+		mode:     mode,
+		minAge:   getVolumeGCDuration(ctx, VOLUME_GC_MIN_AGE, defaultVolumeGCMinAge),
+		recorder: recorder,
+	}
+	interval := getVolumeGCDuration(ctx, VOLUME_GC_INTERVAL, defaultVolumeGCInterval)
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      strings.ReplaceAll(driver.name, ".", "-") + "-volume-gc",
+			Namespace: strings.TrimSpace(string(namespace)),
+		},
+		Client:     client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: driver.nodeID},
+	}
+	config := leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		ReleaseOnCancel: true,
+		LeaseDuration:   60 * time.Second,
+		RenewDeadline:   30 * time.Second,
+		RetryPeriod:     10 * time.Second,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				gc.run(ctx, interval)
+			},
+			OnStoppedLeading: func() {
+				klog.Infof("[%s] stopped the garbage collection of orphaned volumes", loggerId)
+			},
+		},
+	}
+	go func() {
+		for {
+			leaderelection.RunOrDie(context.Background(), config)
+		}
+	}()
+	klog.Infof("[%s] garbage collection of orphaned volumes is enabled in mode [%s], interval [%v], minimum age [%v]", loggerId, mode, interval, gc.minAge)
+}
+
+// run collects the orphaned volumes at each interval while the lease is held.
+func (gc *volumeGC) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		gc.collect(utils.SetLoggerId(ctx))
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// collect reports and deletes the orphaned filesets, symlinks and shallow
+// copy reference directories of all clusters.
+func (gc *volumeGC) collect(ctx context.Context) {
+	loggerId := utils.GetLoggerId(ctx)
+	klog.Infof("[%s] garbage collection of orphaned volumes started", loggerId)
+	refs, err := gc.listVolumeReferences(ctx)
+	if err != nil {
+		klog.Errorf("[%s] garbage collection of orphaned volumes skipped, unable to get the volumes of the PersistentVolumes: %v", loggerId, err)
+		return
+	}
+
+	counts := map[string]int{orphanFileset: 0, orphanSymlink: 0, orphanShallowCopyRef: 0}
+	for _, cluster := range gc.cs.Driver.cmap.Clusters {
+		conn, ok := gc.cs.Driver.connmap[cluster.ID]
+		if !ok {
+			continue
+		}
+		gc.collectFilesets(ctx, cluster.ID, conn, refs, counts)
+	}
+	gc.collectSymlinks(ctx, refs, counts)
+
+	for orphanType, count := range counts {
+		orphanedVolumeObjects.WithLabelValues(orphanType).Set(float64(count))
+	}
+	klog.Infof("[%s] garbage collection of orphaned volumes done, found %d filesets, %d symlinks and %d shallow copy reference directories",
+		loggerId, counts[orphanFileset], counts[orphanSymlink], counts[orphanShallowCopyRef])
+}
+
+// listVolumeReferences returns the filesets, symlinks and shallow copy
+// reference directories used by the PersistentVolumes of the driver. An error
+// is returned if the volume handle of a PersistentVolume can not be parsed, so
+// that the collection is skipped rather than deleting the objects it uses.
+func (gc *volumeGC) listVolumeReferences(ctx context.Context) (volumeReferences, error) {
+	refs := volumeReferences{
+		filesets:        make(map[string]bool),
+		symlinks:        make(map[string]bool),
+		shallowCopyRefs: make(map[string]bool),
+	}
+	client, err := getKubeClient()
+	if err != nil {
+		return refs, err
+	}
+	pvList, err := client.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return refs, err
+	}
+	for _, pv := range pvList.Items {
+		if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != gc.cs.Driver.name {
+			continue
+		}
+		volumeIDMembers, err := getVolIDMembers(pv.Spec.CSI.VolumeHandle)
+		if err != nil {
+			return refs, fmt.Errorf("unable to parse the volume handle of PersistentVolume [%s]: %v", pv.Name, err)
+		}
+		fsUUID := volumeIDMembers.FsUUID
+		if volumeIDMembers.FsetName != "" {
+			refs.filesets[fsUUID+"/"+volumeIDMembers.FsetName] = true
+		}
+		if volumeIDMembers.FsetId != "" {
+			refs.filesets[fsUUID+"/#"+volumeIDMembers.FsetId] = true
+		}
+		if volumeIDMembers.ConsistencyGroup != "" {
+			refs.filesets[fsUUID+"/"+volumeIDMembers.ConsistencyGroup] = true
+		}
+		if filepath.Base(filepath.Dir(volumeIDMembers.Path)) == symlinkDir {
+			refs.symlinks[filepath.Base(volumeIDMembers.Path)] = true
+		}
+		if volumeIDMembers.VolType == FILE_SHALLOWCOPY_VOLUME {
+			// The path is <fileset junction>/.snapshots/<snapshot>/..., the
+			// junction does not always end with the fileset name, e.g. for
+			// the root fileset
+			_, after, found := strings.Cut(volumeIDMembers.Path, "/.snapshots/")
+			if found {
+				snapName, _, _ := strings.Cut(after, "/")
+				refs.shallowCopyRefs[fmt.Sprintf("%s/%s/%s", fsUUID, snapName, volumeIDMembers.FsetName)] = true
+			}
+		}
+	}
+	return refs, nil
+}
+
+// collectFilesets reports and deletes the orphaned filesets and shallow copy
+// reference directories of the filesystems owned by a cluster.
+func (gc *volumeGC) collectFilesets(ctx context.Context, clusterID string, conn connectors.SpectrumScaleConnector, refs volumeReferences, counts map[string]int) {
+	loggerId := utils.GetLoggerId(ctx)
+	filesystems, err := conn.ListFilesystems(ctx)
+	if err != nil {
+		klog.Errorf("[%s] unable to list the filesystems of cluster [%s]: %v", loggerId, clusterID, err)
+		return
+	}
+	primary := gc.cs.Driver.primary
+	primaryFsUUID, err := gc.cs.Driver.connmap["primary"].GetFsUid(ctx, primary.GetPrimaryFs())
+	if err != nil {
+		klog.Errorf("[%s] unable to get the UUID of primary filesystem [%s]: %v", loggerId, primary.GetPrimaryFs(), err)
+		return
+	}
+	for _, fsName := range filesystems {
+		fsDetails, err := conn.GetFilesystemDetails(ctx, fsName)
+		if err != nil {
+			klog.Errorf("[%s] unable to get the details of filesystem [%s] of cluster [%s]: %v", loggerId, fsName, clusterID, err)
+			continue
+		}
+		// The filesets of a remote filesystem are collected with the owning cluster
+		if fsDetails.Type == filesystemTypeRemote {
+			continue
+		}
+		filesets, err := conn.ListFilesets(ctx, fsName, connectors.FilesetFilter{CSIOnly: true})
+		if err != nil {
+			klog.Errorf("[%s] unable to list the filesets of filesystem [%s] of cluster [%s]: %v", loggerId, fsName, clusterID, err)
+			continue
+		}
+		for _, fileset := range filesets {
+			name := fileset.FilesetName
+			if name == primary.PrimaryFset && fsDetails.UUID == primaryFsUUID {
+				continue
+			}
+			if fileset.Config.IsInodeSpaceOwner {
+				gc.collectShallowCopyRefs(ctx, conn, fsName, fsDetails.UUID, name, refs, counts)
+			}
+			if refs.filesets[fsDetails.UUID+"/"+name] || refs.filesets[fsDetails.UUID+"/#"+strconv.Itoa(fileset.Config.Id)] {
+				continue
+			}
+			gc.collectFileset(ctx, clusterID, conn, fsName, name, counts)
+		}
+	}
+}
+
+// collectFileset reports and deletes a fileset which no PersistentVolume
+// uses, once it is older than the minimum age. The AFM filesets, the
+// independent filesets with dependent filesets or snapshots and the filesets
+// of immutable volumes which are still retained are only reported.
+func (gc *volumeGC) collectFileset(ctx context.Context, clusterID string, conn connectors.SpectrumScaleConnector, fsName string, filesetName string, counts map[string]int) {
+	loggerId := utils.GetLoggerId(ctx)
+	fileset, err := conn.ListFileset(ctx, fsName, filesetName)
+	if err != nil {
+		if !strings.Contains(err.Error(), fsetNotFoundErrCode) && !strings.Contains(err.Error(), fsetNotFoundErrMsg) {
+			klog.Errorf("[%s] unable to get fileset [%s] of filesystem [%s]: %v", loggerId, filesetName, fsName, err)
+		}
+		return
+	}
+	// The AFM-DR secondary of a replicated volume is used by the PersistentVolume
+	// of the primary fileset, which is on another cluster
+	if fileset.AFM.AFMMode == connectors.AFMModeSecondary {
+		klog.V(4).Infof("[%s] fileset [%s] of filesystem [%s] is an AFM-DR secondary, skipped", loggerId, filesetName, fsName)
+		return
+	}
+	since, found := connectors.GetCSIFilesetCommentTime(fileset.Config.Comment)
+	if !found {
+		klog.V(4).Infof("[%s] fileset [%s] of filesystem [%s] has no creation time of the driver in its comment, skipped", loggerId, filesetName, fsName)
+		return
+	}
+	if time.Since(since) < gc.minAge {
+		return
+	}
+
+	counts[orphanFileset]++
+	object := fmt.Sprintf("fileset %s of filesystem %s of cluster %s", filesetName, fsName, clusterID)
+	gc.reportOrphan(ctx, object)
+	if gc.mode != volumeGCModeDelete {
+		return
+	}
+
+	if reason := gc.filesetDeleteBlocker(ctx, conn, fsName, fileset); reason != "" {
+		klog.Infof("[%s] orphaned %s is not deleted, %s", loggerId, object, reason)
+		return
+	}
+	_, err = gc.cs.DeleteFilesetVol(ctx, fsName, filesetName, scaleVolId{ClusterId: clusterID}, conn, false)
+	if err == nil {
+		err = deleteVolumePolicies(ctx, conn, fsName, filesetName)
+	}
+	gc.reportDelete(ctx, orphanFileset, object, err)
+}
+
+// filesetDeleteBlocker returns why an orphaned fileset is not deleted, or an
+// empty string.
+func (gc *volumeGC) filesetDeleteBlocker(ctx context.Context, conn connectors.SpectrumScaleConnector, fsName string, fileset connectors.Fileset_v2) string {
+	filesetName := fileset.FilesetName
+	if fileset.AFM.AFMMode != "" {
+		return fmt.Sprintf("it is an AFM fileset in mode %s", fileset.AFM.AFMMode)
+	}
+	if fileset.Config.IsInodeSpaceOwner {
+		filesets, err := conn.GetFilesetsInodeSpace(ctx, fsName, fileset.Config.InodeSpace)
+		if err != nil {
+			return fmt.Sprintf("unable to list the filesets of its inode space: %v", err)
+		}
+		if len(filesets) > 1 {
+			return "it has dependent filesets"
+		}
+		snapshots, err := conn.ListFilesetSnapshots(ctx, fsName, filesetName)
+		if err != nil {
+			return fmt.Sprintf("unable to list its snapshots: %v", err)
+		}
+		if len(snapshots) > 0 {
+			return "it has snapshots"
+		}
+	}
+	if err := checkVolumeRetention(ctx, conn, fsName, filesetName); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// collectShallowCopyRefs reports and deletes the reference directories of
+// the shallow copy volumes of the snapshots of an independent fileset, which
+// no PersistentVolume uses. They are listed on the node, so the filesystem
+// must be mounted on the node running the collection.
+func (gc *volumeGC) collectShallowCopyRefs(ctx context.Context, conn connectors.SpectrumScaleConnector, fsName string, fsUUID string, filesetName string, refs volumeReferences, counts map[string]int) {
+	loggerId := utils.GetLoggerId(ctx)
+	mountPoint, err := gc.getLocalMountPoint(ctx, fsUUID)
+	if err != nil {
+		klog.V(4).Infof("[%s] shallow copy reference directories of fileset [%s] of filesystem [%s] are not collected: %v", loggerId, filesetName, fsName, err)
+		return
+	}
+	snapshots, err := conn.ListFilesetSnapshots(ctx, fsName, filesetName)
+	if err != nil {
+		klog.Errorf("[%s] unable to list the snapshots of fileset [%s] of filesystem [%s]: %v", loggerId, filesetName, fsName, err)
+		return
+	}
+	for _, snapshot := range snapshots {
+		snapName := snapshot.SnapshotName
+		entries, err := os.ReadDir(filepath.Join(mountPoint, filesetName, snapName))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			volName := entry.Name()
+			if !entry.IsDir() || refs.shallowCopyRefs[fmt.Sprintf("%s/%s/%s", fsUUID, snapName, volName)] {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil || time.Since(info.ModTime()) < gc.minAge {
+				continue
+			}
+
+			counts[orphanShallowCopyRef]++
+			object := fmt.Sprintf("shallow copy reference directory %s/%s/%s of filesystem %s", filesetName, snapName, volName, fsName)
+			gc.reportOrphan(ctx, object)
+			if gc.mode != volumeGCModeDelete {
+				continue
+			}
+			// The snapshot is left as it is, it may be used by a VolumeSnapshot
+			err = gc.cs.DeleteShallowCopyRefPath(ctx, fsName, volName, filesetName+"/"+snapName, "", filesetName, snapName, conn)
+			gc.reportDelete(ctx, orphanShallowCopyRef, object, err)
+		}
+	}
+}
+
+// getLocalMountPoint returns the path in the container of the mount point
+// of a filesystem on the node.
+func (gc *volumeGC) getLocalMountPoint(ctx context.Context, fsUUID string) (string, error) {
+	primaryConn := gc.cs.Driver.connmap["primary"]
+	fsName, err := primaryConn.GetFilesystemName(ctx, fsUUID)
+	if err != nil {
+		return "", err
+	}
+	mountDetails, err := primaryConn.GetFilesystemMountDetails(ctx, fsName)
+	if err != nil {
+		return "", err
+	}
+	mountPoint := hostDir + mountDetails.MountPoint
+	if !isUnderGpfsPath(mountPoint, getGpfsPaths(ctx)) {
+		return "", fmt.Errorf("filesystem [%s] is not mounted on this node", fsName)
+	}
+	return mountPoint, nil
+}
+
+// collectSymlinks reports and deletes the symlinks under the .volumes
+// directory of the primary fileset which no PersistentVolume uses.
+func (gc *volumeGC) collectSymlinks(ctx context.Context, refs volumeReferences, counts map[string]int) {
+	loggerId := utils.GetLoggerId(ctx)
+	primaryFs := gc.cs.Driver.primary.GetPrimaryFs()
+	primaryFset := gc.cs.Driver.primary.PrimaryFset
+	if primaryFset == "" {
+		primaryFset = defaultPrimaryFileset
+	}
+	primaryFsUUID, err := gc.cs.Driver.connmap["primary"].GetFsUid(ctx, primaryFs)
+	if err != nil {
+		klog.Errorf("[%s] unable to get the UUID of primary filesystem [%s]: %v", loggerId, primaryFs, err)
+		return
+	}
+	mountPoint, err := gc.getLocalMountPoint(ctx, primaryFsUUID)
+	if err != nil {
+		klog.Warningf("[%s] symlinks of the volumes are not collected: %v", loggerId, err)
+		return
+	}
+	symlinkDirRelPath := primaryFset + "/" + symlinkDir
+	entries, err := os.ReadDir(filepath.Join(mountPoint, symlinkDirRelPath))
+	if err != nil {
+		klog.Errorf("[%s] unable to list the symlinks of the volumes: %v", loggerId, err)
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type()&os.ModeSymlink == 0 || refs.symlinks[name] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < gc.minAge {
+			continue
+		}
+
+		counts[orphanSymlink]++
+		object := fmt.Sprintf("symlink %s/%s of filesystem %s", symlinkDirRelPath, name, primaryFs)
+		gc.reportOrphan(ctx, object)
+		if gc.mode != volumeGCModeDelete {
+			continue
+		}
+		err = gc.cs.Driver.connmap["primary"].DeleteSymLnk(ctx, primaryFs, symlinkDirRelPath+"/"+name)
+		gc.reportDelete(ctx, orphanSymlink, object, err)
+	}
+}
+
+func (gc *volumeGC) reportOrphan(ctx context.Context, object string) {
+	klog.Warningf("[%s] found orphaned %s, no PersistentVolume uses it", utils.GetLoggerId(ctx), object)
+	recordNodeEvent(ctx, gc.recorder, gc.cs.Driver.nodeID, v1.EventTypeWarning, orphanedVolumeReason,
+		fmt.Sprintf("Found orphaned %s, no PersistentVolume uses it", object))
+}
+
+func (gc *volumeGC) reportDelete(ctx context.Context, orphanType string, object string, err error) {
+	loggerId := utils.GetLoggerId(ctx)
+	if err != nil {
+		klog.Errorf("[%s] unable to delete orphaned %s: %v", loggerId, object, err)
+		orphanedVolumeDeletions.WithLabelValues(orphanType, orphanDeleteFailed).Inc()
+		recordNodeEvent(ctx, gc.recorder, gc.cs.Driver.nodeID, v1.EventTypeWarning, orphanDeleteFailReason,
+			fmt.Sprintf("Unable to delete orphaned %s: %v", object, err))
+		return
+	}
+	klog.Infof("[%s] deleted orphaned %s", loggerId, object)
+	orphanedVolumeDeletions.WithLabelValues(orphanType, orphanDeleteSucceeded).Inc()
+	recordNodeEvent(ctx, gc.recorder, gc.cs.Driver.nodeID, v1.EventTypeNormal, orphanDeletedReason,
+		fmt.Sprintf("Deleted orphaned %s", object))
+}
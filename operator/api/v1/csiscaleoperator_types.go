@@ -127,6 +127,39 @@ type CSIScaleOperatorSpec struct {
 	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="CSI Pod Security Policy Name",xDescriptors="urn:alm:descriptor:com.tectonic.ui:hidden"
 	CSIpspname string `json:"csipspname,omitempty"`
 
+	// seLinuxMount makes kubelet pass the SELinux context of the pods to the
+	// driver as the context mount option of the volumes, instead of relabeling
+	// the files of the volumes. The context is an option of the mount of a whole
+	// filesystem and can not be set by the bind mount of a volume, so a volume
+	// is published only when its filesystem is mounted on the node with the
+	// context of the pod, and all the pods using the filesystem on a node must
+	// run with that context. Disabled by default.
+	// +kubebuilder:default:=false
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="SELinux Mount",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch","urn:alm:descriptor:com.tectonic.ui:advanced"}
+	SELinuxMount bool `json:"seLinuxMount,omitempty"`
+
+	// recreateCSIDriver allows the operator to delete and recreate the
+	// CSIDriver object when its immutable fields, like fsGroupPolicy, differ
+	// from the ones of the release, e.g. on an upgrade. The volumes stay
+	// published but no volume can be attached or mounted until it is
+	// recreated. When disabled, the CSIDriver object must be deleted by the
+	// administrator on such an upgrade. Disabled by default.
+	// +kubebuilder:default:=false
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Recreate CSIDriver",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch","urn:alm:descriptor:com.tectonic.ui:advanced"}
+	RecreateCSIDriver bool `json:"recreateCSIDriver,omitempty"`
+
+	// metricsPort is the port of the Prometheus metrics endpoint of the node
+	// plugin pods. The endpoint is not authenticated and the pods use the
+	// network of the hosts, so it is served only when the port is set.
+	// Disabled by default.
+	// +kubebuilder:validation:Minimum:=1024
+	// +kubebuilder:validation:Maximum:=65535
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Metrics Port",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:number","urn:alm:descriptor:com.tectonic.ui:advanced"}
+	MetricsPort int32 `json:"metricsPort,omitempty"`
+
 	// consistencyGroupPrefix is a prefix of consistency group of an application.
 	// This is expected to be an RFC4122 UUID value (xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx in hexadecimal values)
 	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Consistency Group Prefix",xDescriptors="urn:alm:descriptor:com.tectonic.ui:hidden"
@@ -421,3 +454,19 @@ const (
 	UpdateFailed CSIReason = "UpdateFailed"
 	DeleteFailed CSIReason = "DeleteFailed"
 )
+
+const (
+	CachePrefetchCompleted CSIReason = "CachePrefetchCompleted"
+	CachePrefetchFailed    CSIReason = "CachePrefetchFailed"
+	CacheEvictCompleted    CSIReason = "CacheEvictCompleted"
+	CacheEvictFailed       CSIReason = "CacheEvictFailed"
+	CacheRequestInvalid    CSIReason = "CacheRequestInvalid"
+	CacheStateHealthy      CSIReason = "CacheStateHealthy"
+	CacheStateDirty        CSIReason = "CacheStateDirty"
+	CacheStateUnhealthy    CSIReason = "CacheStateUnhealthy"
+)
+
+const (
+	NodeUnhealthy CSIReason = "NodeUnhealthy"
+	NodeHealthy   CSIReason = "NodeHealthy"
+)
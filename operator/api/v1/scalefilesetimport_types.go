@@ -0,0 +1,155 @@
+/*
+Copyright 2024 IBM Corp.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ScaleFilesetImportSpec defines the desired state of ScaleFilesetImport
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+type ScaleFilesetImportSpec struct {
+
+	// clusterId is the ID of the IBM Storage Scale cluster owning the filesystem.
+	// Defaults to the primary cluster.
+	// +optional
+	ClusterID string `json:"clusterId,omitempty"`
+
+	// filesystem is the name of the filesystem of the fileset on the owning cluster.
+	// +kubebuilder:validation:MinLength:=1
+	Filesystem string `json:"filesystem"`
+
+	// fileset is the name of the fileset to be imported. The fileset must be
+	// linked and must not be created or imported by the CSI driver already.
+	// +kubebuilder:validation:MinLength:=1
+	Fileset string `json:"fileset"`
+
+	// namespace of the PVC to be created.
+	// Defaults to the namespace of the ScaleFilesetImport.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// persistentVolumeClaimName is the name of the PVC to be created.
+	// +kubebuilder:validation:MinLength:=1
+	PersistentVolumeClaimName string `json:"persistentVolumeClaimName"`
+
+	// persistentVolumeName is the name of the PV to be created.
+	// Defaults to <namespace>-<persistentVolumeClaimName>.
+	// +optional
+	PersistentVolumeName string `json:"persistentVolumeName,omitempty"`
+
+	// storageClassName of the PV and the PVC. Defaults to no storageClass.
+	// +optional
+	StorageClassName string `json:"storageClassName,omitempty"`
+
+	// accessMode of the PV and the PVC.
+	// +kubebuilder:validation:Enum=ReadWriteMany;ReadWriteOnce;ReadOnlyMany;ReadWriteOncePod
+	// +kubebuilder:default:=ReadWriteMany
+	// +optional
+	AccessMode corev1.PersistentVolumeAccessMode `json:"accessMode,omitempty"`
+
+	// size is the capacity of the PV. Defaults to the block quota of the fileset,
+	// it is required if the fileset has no block quota.
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+}
+
+// FilesetImportPhase is the phase of a ScaleFilesetImport
+type FilesetImportPhase string
+
+const (
+	// FilesetImportPending means the import has not started yet.
+	FilesetImportPending FilesetImportPhase = "Pending"
+	// FilesetImportRunning means the fileset is validated, its volume handle is
+	// recorded, and it is being marked as owned by the CSI driver before the PV
+	// and the PVC are created.
+	FilesetImportRunning FilesetImportPhase = "Running"
+	// FilesetImportSucceeded means the PV and the PVC of the fileset are created.
+	FilesetImportSucceeded FilesetImportPhase = "Succeeded"
+	// FilesetImportFailed means the import is refused or failed.
+	FilesetImportFailed FilesetImportPhase = "Failed"
+)
+
+// ScaleFilesetImportStatus defines the observed state of ScaleFilesetImport
+type ScaleFilesetImportStatus struct {
+
+	// phase of the import.
+	// +optional
+	Phase FilesetImportPhase `json:"phase,omitempty"`
+
+	// message is a human readable description of the phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// volumeHandle is the volume handle of the PV of the fileset.
+	// +optional
+	VolumeHandle string `json:"volumeHandle,omitempty"`
+
+	// capacity of the PV of the fileset.
+	// +optional
+	Capacity *resource.Quantity `json:"capacity,omitempty"`
+
+	// persistentVolumeName is the name of the created PV.
+	// +optional
+	PersistentVolumeName string `json:"persistentVolumeName,omitempty"`
+
+	// completionTime is the time at which the import succeeded or failed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=sfi, categories=scale, scope=Namespaced
+// +kubebuilder:printcolumn:name="Filesystem",type=string,JSONPath=`.spec.filesystem`,description="Filesystem of the fileset."
+// +kubebuilder:printcolumn:name="Fileset",type=string,JSONPath=`.spec.fileset`,description="Fileset to be imported."
+// +kubebuilder:printcolumn:name="PVC",type=string,JSONPath=`.spec.persistentVolumeClaimName`,description="PVC to be created."
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`,description="Phase of the import."
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ScaleFilesetImport is the Schema for the scalefilesetimports API
+// +operator-sdk:csv:customresourcedefinitions:displayName="IBM Storage Scale Fileset Import"
+type ScaleFilesetImport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ScaleFilesetImportSpec   `json:"spec,omitempty"`
+	Status ScaleFilesetImportStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ScaleFilesetImportList contains a list of ScaleFilesetImport
+type ScaleFilesetImportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScaleFilesetImport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ScaleFilesetImport{}, &ScaleFilesetImportList{})
+}
+
+const (
+	FilesetImportStarted       CSIReason = "FilesetImportStarted"
+	FilesetImportCompleted     CSIReason = "FilesetImportCompleted"
+	FilesetImportInvalid       CSIReason = "FilesetImportInvalid"
+	FilesetImportWaitingForGUI CSIReason = "FilesetImportWaitingForGUI"
+	FilesetImportStepFailed    CSIReason = "FilesetImportStepFailed"
+)
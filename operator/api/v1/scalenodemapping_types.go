@@ -0,0 +1,64 @@
+/*
+Copyright 2024 IBM Corp.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ScaleNodeMappingSpec defines the IBM Storage Scale node of a Kubernetes node
+type ScaleNodeMappingSpec struct {
+
+	// k8sNode is the name of the Kubernetes node.
+	// +kubebuilder:validation:MinLength:=1
+	K8sNode string `json:"k8sNode"`
+
+	// scaleNode is the admin node name of the IBM Storage Scale node of the
+	// Kubernetes node.
+	// +kubebuilder:validation:MinLength:=1
+	ScaleNode string `json:"scaleNode"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=snm, categories=scale, scope=Cluster
+// +kubebuilder:printcolumn:name="Kubernetes Node",type=string,JSONPath=`.spec.k8sNode`,description="Name of the Kubernetes node."
+// +kubebuilder:printcolumn:name="Scale Node",type=string,JSONPath=`.spec.scaleNode`,description="Admin node name of the IBM Storage Scale node."
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ScaleNodeMapping is the Schema for the scalenodemappings API. It maps a
+// Kubernetes node to its IBM Storage Scale node for the CSI driver, when
+// the nodeMapping of the CSIScaleOperator does not map the node.
+// +operator-sdk:csv:customresourcedefinitions:displayName="IBM Storage Scale Node Mapping"
+type ScaleNodeMapping struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ScaleNodeMappingSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ScaleNodeMappingList contains a list of ScaleNodeMapping
+type ScaleNodeMappingList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScaleNodeMapping `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ScaleNodeMapping{}, &ScaleNodeMappingList{})
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2024 IBM Corp.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ScaleSnapshotScheduleSpec defines the desired state of ScaleSnapshotSchedule
+type ScaleSnapshotScheduleSpec struct {
+
+	// schedule is a cron expression (minute hour day-of-month month day-of-week)
+	// or one of @hourly, @daily, @weekly, @monthly and @yearly.
+	// +kubebuilder:validation:MinLength:=1
+	Schedule string `json:"schedule"`
+
+	// volumeSnapshotClassName is the name of the VolumeSnapshotClass used to take the snapshots.
+	// The PVCs of a consistency group share one snapshot of the consistency group fileset,
+	// which the driver takes for the first PVC and reuses for the others within the snapWindow
+	// parameter of the class, 30 minutes by default. The snapWindow must be shorter than the
+	// interval between the runs of the schedule, or the snapshots of the consistency groups fail,
+	// and it is validated when the schedule selects PVCs of a consistency group.
+	// +kubebuilder:validation:MinLength:=1
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName"`
+
+	// namespaces is the list of namespaces in which PVCs are selected.
+	// Defaults to the namespace of the ScaleSnapshotSchedule.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// pvcSelector is a label selector for the PVCs to be snapshotted.
+	// All the PVCs of the driver in the selected namespaces are snapshotted if it is not set.
+	// +optional
+	PVCSelector *metav1.LabelSelector `json:"pvcSelector,omitempty"`
+
+	// retention defines how many of the snapshots taken by this schedule are kept.
+	// +optional
+	Retention SnapshotRetention `json:"retention,omitempty"`
+
+	// suspend stops taking new snapshots, retention is still enforced.
+	// +kubebuilder:default:=false
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// SnapshotRetention defines the retention policy of scheduled snapshots.
+// A snapshot is kept if any of the rules keeps it. All the snapshots are
+// kept if no rule is set.
+type SnapshotRetention struct {
+
+	// keepLast is the number of most recent snapshots kept for every PVC.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	KeepLast int32 `json:"keepLast,omitempty"`
+
+	// keepDaily is the number of days for which the most recent snapshot of the day is kept for every PVC.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	KeepDaily int32 `json:"keepDaily,omitempty"`
+}
+
+// ScaleSnapshotScheduleStatus defines the observed state of ScaleSnapshotSchedule
+type ScaleSnapshotScheduleStatus struct {
+
+	// lastScheduleTime is the last time snapshots were triggered by this schedule.
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// nextScheduleTime is the next time snapshots will be triggered by this schedule.
+	// +optional
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+
+	// volumes contains the snapshot state of every selected PVC.
+	// +optional
+	Volumes []SnapshotVolumeStatus `json:"volumes,omitempty"`
+
+	// conditions contains the details for one aspect of the current state of this custom resource.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// SnapshotVolumeStatus defines the snapshot state of a single PVC
+type SnapshotVolumeStatus struct {
+
+	// namespace of the PVC.
+	Namespace string `json:"namespace"`
+
+	// name of the PVC.
+	Name string `json:"name"`
+
+	// consistencyGroup is the consistency group of the PVC, if any.
+	// Snapshots of all PVCs of a consistency group are taken together.
+	// +optional
+	ConsistencyGroup string `json:"consistencyGroup,omitempty"`
+
+	// lastSnapshotName is the name of the most recent VolumeSnapshot which is ready to use.
+	// +optional
+	LastSnapshotName string `json:"lastSnapshotName,omitempty"`
+
+	// lastSuccessTime is the creation time of the most recent VolumeSnapshot which is ready to use.
+	// +optional
+	LastSuccessTime *metav1.Time `json:"lastSuccessTime,omitempty"`
+
+	// lastFailureTime is the time of the most recent failure to take a snapshot.
+	// +optional
+	LastFailureTime *metav1.Time `json:"lastFailureTime,omitempty"`
+
+	// lastFailureMessage is the error of the most recent failure to take a snapshot.
+	// +optional
+	LastFailureMessage string `json:"lastFailureMessage,omitempty"`
+
+	// snapshotCount is the number of VolumeSnapshots of the PVC retained by this schedule.
+	// +optional
+	SnapshotCount int32 `json:"snapshotCount,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=sss, categories=scale, scope=Namespaced
+// +kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.spec.schedule`,description="Cron schedule."
+// +kubebuilder:printcolumn:name="Suspend",type=boolean,JSONPath=`.spec.suspend`,description="Schedule is suspended."
+// +kubebuilder:printcolumn:name="Last Schedule",type=date,JSONPath=`.status.lastScheduleTime`,description="Last time snapshots were triggered."
+
+// ScaleSnapshotSchedule is the Schema for the scalesnapshotschedules API
+// +operator-sdk:csv:customresourcedefinitions:displayName="IBM Storage Scale Snapshot Schedule"
+type ScaleSnapshotSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ScaleSnapshotScheduleSpec   `json:"spec,omitempty"`
+	Status ScaleSnapshotScheduleStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ScaleSnapshotScheduleList contains a list of ScaleSnapshotSchedule
+type ScaleSnapshotScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScaleSnapshotSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ScaleSnapshotSchedule{}, &ScaleSnapshotScheduleList{})
+}
+
+const (
+	SnapshotScheduleReady           CSIReason = "SnapshotScheduleReady"
+	InvalidSchedule                 CSIReason = "InvalidSchedule"
+	SnapshotCreateFailed            CSIReason = "SnapshotCreateFailed"
+	SnapshotDeleteFailed            CSIReason = "SnapshotDeleteFailed"
+	SnapshotRetentionEnforced       CSIReason = "SnapshotRetentionEnforced"
+	SnapshotScheduleVolumesNotFound CSIReason = "SnapshotScheduleVolumesNotFound"
+	InvalidSnapWindow               CSIReason = "InvalidSnapWindow"
+)
@@ -0,0 +1,154 @@
+/*
+Copyright 2024 IBM Corp.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReplicationState is the requested replication state of a volume
+// +kubebuilder:validation:Enum=primary;secondary;resync
+type ReplicationState string
+
+const (
+	// ReplicationStatePrimary promotes the volume: its fileset is the AFM-DR
+	// primary and is replicated to the peer cluster. A volume failed over to
+	// the peer cluster is failed back, with the changes made on the peer.
+	ReplicationStatePrimary ReplicationState = "primary"
+	// ReplicationStateSecondary demotes the volume: the fileset of the peer
+	// cluster is failed over to and becomes the acting primary.
+	ReplicationStateSecondary ReplicationState = "secondary"
+	// ReplicationStateResync copies the changes made on the peer cluster back
+	// to the fileset of the volume while the peer is still the acting primary.
+	ReplicationStateResync ReplicationState = "resync"
+)
+
+// ScaleVolumeReplicationSpec defines the desired state of ScaleVolumeReplication
+type ScaleVolumeReplicationSpec struct {
+
+	// namespace of the PVC.
+	// Defaults to the namespace of the ScaleVolumeReplication.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="namespace is immutable"
+	Namespace string `json:"namespace,omitempty"`
+
+	// persistentVolumeClaimName is the name of the replicated PVC. The PVC
+	// must be a volume of a storageClass with replicationClusterId.
+	// +kubebuilder:validation:MinLength:=1
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="persistentVolumeClaimName is immutable"
+	PersistentVolumeClaimName string `json:"persistentVolumeClaimName"`
+
+	// replicationState is the requested state of the volume, primary,
+	// secondary or resync.
+	ReplicationState ReplicationState `json:"replicationState"`
+}
+
+// ReplicationStatus is the observed replication state of a volume
+type ReplicationStatus string
+
+const (
+	// ReplicationPrimary means the fileset of the volume is the AFM-DR primary.
+	ReplicationPrimary ReplicationStatus = "Primary"
+	// ReplicationSecondary means the fileset of the peer cluster is the acting primary.
+	ReplicationSecondary ReplicationStatus = "Secondary"
+	// ReplicationResyncing means the changes made on the peer cluster are
+	// being copied back to the fileset of the volume.
+	ReplicationResyncing ReplicationStatus = "Resyncing"
+	// ReplicationUnknown means the replication state is not known yet.
+	ReplicationUnknown ReplicationStatus = "Unknown"
+)
+
+// Condition types of a ScaleVolumeReplication
+const (
+	// ReplicationConditionCompleted is true once the requested state is reached.
+	ReplicationConditionCompleted = "Completed"
+	// ReplicationConditionDegraded is true while the replication lag of a
+	// primary volume exceeds twice its RPO.
+	ReplicationConditionDegraded = "Degraded"
+)
+
+// ScaleVolumeReplicationStatus defines the observed state of ScaleVolumeReplication
+type ScaleVolumeReplicationStatus struct {
+
+	// state is the replication state of the volume.
+	// +optional
+	State ReplicationStatus `json:"state,omitempty"`
+
+	// message is a human readable description of the state.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// observedGeneration is the generation of the spec whose replicationState
+	// is reached.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// lastSyncTime is the time of the last RPO snapshot of the primary volume,
+	// up to which the data is replicated to the peer cluster.
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// replicationLag is the age of the last RPO snapshot of the primary
+	// volume when it was last polled.
+	// +optional
+	ReplicationLag *metav1.Duration `json:"replicationLag,omitempty"`
+
+	// conditions of the replication.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=svrep, categories=scale, scope=Namespaced
+// +kubebuilder:printcolumn:name="PVC",type=string,JSONPath=`.spec.persistentVolumeClaimName`,description="Replicated PVC."
+// +kubebuilder:printcolumn:name="Desired",type=string,JSONPath=`.spec.replicationState`,description="Requested replication state."
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`,description="Replication state of the volume."
+// +kubebuilder:printcolumn:name="Lag",type=string,JSONPath=`.status.replicationLag`,description="Replication lag of the primary volume."
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ScaleVolumeReplication is the Schema for the scalevolumereplications API
+// +operator-sdk:csv:customresourcedefinitions:displayName="IBM Storage Scale Volume Replication"
+type ScaleVolumeReplication struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ScaleVolumeReplicationSpec   `json:"spec,omitempty"`
+	Status ScaleVolumeReplicationStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ScaleVolumeReplicationList contains a list of ScaleVolumeReplication
+type ScaleVolumeReplicationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScaleVolumeReplication `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ScaleVolumeReplication{}, &ScaleVolumeReplicationList{})
+}
+
+const (
+	VolumeReplicationInvalid       CSIReason = "VolumeReplicationInvalid"
+	VolumeReplicationWaitingForGUI CSIReason = "VolumeReplicationWaitingForGUI"
+	VolumeReplicationStarted       CSIReason = "VolumeReplicationStarted"
+	VolumeReplicationCompleted     CSIReason = "VolumeReplicationCompleted"
+	VolumeReplicationFailed        CSIReason = "VolumeReplicationFailed"
+	VolumeReplicationLagging       CSIReason = "VolumeReplicationLagging"
+)
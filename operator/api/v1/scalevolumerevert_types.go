@@ -0,0 +1,176 @@
+/*
+Copyright 2024 IBM Corp.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ScaleVolumeRevertSpec defines the desired state of ScaleVolumeRevert
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+type ScaleVolumeRevertSpec struct {
+
+	// namespace of the PVC and the VolumeSnapshot.
+	// Defaults to the namespace of the ScaleVolumeRevert.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// persistentVolumeClaimName is the name of the PVC to be reverted.
+	// The PVC must be a fileset based volume of classic storageClass,
+	// and it must not be in use by any pod when the revert starts.
+	// The PVC can not be published to a node until the revert completes.
+	// The volume data is not reverted in place, it is replaced by a copy of
+	// the snapshot data which is staged in the root of the filesystem first.
+	// The filesystem needs free space for the snapshot data outside the
+	// quota of the fileset of the volume until the revert completes.
+	// +kubebuilder:validation:MinLength:=1
+	PersistentVolumeClaimName string `json:"persistentVolumeClaimName"`
+
+	// volumeSnapshotName is the name of the VolumeSnapshot of the PVC to revert to.
+	// +kubebuilder:validation:MinLength:=1
+	VolumeSnapshotName string `json:"volumeSnapshotName"`
+}
+
+// VolumeRevertPhase is the phase of a ScaleVolumeRevert
+type VolumeRevertPhase string
+
+const (
+	// VolumeRevertPending means the revert has not started yet, e.g. while
+	// the volume is in use.
+	VolumeRevertPending VolumeRevertPhase = "Pending"
+	// VolumeRevertRunning means the volume data is being reverted.
+	VolumeRevertRunning VolumeRevertPhase = "Running"
+	// VolumeRevertSucceeded means the volume data is reverted to the snapshot.
+	VolumeRevertSucceeded VolumeRevertPhase = "Succeeded"
+	// VolumeRevertFailed means the revert is refused as the PVC or the
+	// VolumeSnapshot can not be reverted to.
+	VolumeRevertFailed VolumeRevertPhase = "Failed"
+)
+
+// VolumeRevertStep is the step of a running ScaleVolumeRevert
+type VolumeRevertStep string
+
+const (
+	// VolumeRevertStepStaging means the snapshot data is being copied to the
+	// staging directory, the volume data is not changed yet.
+	VolumeRevertStepStaging VolumeRevertStep = "Staging"
+	// VolumeRevertStepReplacing means the volume data is being replaced by the
+	// data of the staging directory.
+	VolumeRevertStepReplacing VolumeRevertStep = "Replacing"
+	// VolumeRevertStepCleaningUp means the staging directory is being deleted.
+	VolumeRevertStepCleaningUp VolumeRevertStep = "CleaningUp"
+)
+
+// ScaleVolumeRevertStatus defines the observed state of ScaleVolumeRevert
+type ScaleVolumeRevertStatus struct {
+
+	// phase of the revert.
+	// +optional
+	Phase VolumeRevertPhase `json:"phase,omitempty"`
+
+	// message is a human readable description of the phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// startTime is the time at which the revert started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// completionTime is the time at which the revert succeeded or failed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// step of a running revert.
+	// +optional
+	Step VolumeRevertStep `json:"step,omitempty"`
+
+	// dataDirectory is the volume data directory which is reverted.
+	// +optional
+	DataDirectory *RevertDataDirectory `json:"dataDirectory,omitempty"`
+
+	// copyJobId is the ID of the IBM Storage Scale job copying the data
+	// in the current step.
+	// +optional
+	CopyJobID int64 `json:"copyJobId,omitempty"`
+}
+
+// RevertDataDirectory defines the volume data directory and its attributes,
+// which are used to recreate the directory before copying the staged data.
+type RevertDataDirectory struct {
+
+	// filesystem is the name of the filesystem on the owning cluster.
+	Filesystem string `json:"filesystem"`
+
+	// path of the directory relative to the filesystem mount point.
+	Path string `json:"path"`
+
+	// uid of the directory owner.
+	UID string `json:"uid"`
+
+	// gid of the directory owner.
+	GID string `json:"gid"`
+
+	// permissions of the directory in octal.
+	Permissions string `json:"permissions"`
+
+	// stagingPath is the directory the snapshot data is copied to before
+	// replacing the volume data, relative to the filesystem mount point.
+	StagingPath string `json:"stagingPath"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=svr, categories=scale, scope=Namespaced
+// +kubebuilder:printcolumn:name="PVC",type=string,JSONPath=`.spec.persistentVolumeClaimName`,description="PVC to be reverted."
+// +kubebuilder:printcolumn:name="Snapshot",type=string,JSONPath=`.spec.volumeSnapshotName`,description="VolumeSnapshot to revert to."
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`,description="Phase of the revert."
+// +kubebuilder:printcolumn:name="Step",type=string,JSONPath=`.status.step`,description="Step of a running revert.",priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ScaleVolumeRevert is the Schema for the scalevolumereverts API
+// +operator-sdk:csv:customresourcedefinitions:displayName="IBM Storage Scale Volume Revert"
+type ScaleVolumeRevert struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ScaleVolumeRevertSpec   `json:"spec,omitempty"`
+	Status ScaleVolumeRevertStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ScaleVolumeRevertList contains a list of ScaleVolumeRevert
+type ScaleVolumeRevertList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScaleVolumeRevert `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ScaleVolumeRevert{}, &ScaleVolumeRevertList{})
+}
+
+const (
+	VolumeRevertStarted       CSIReason = "VolumeRevertStarted"
+	VolumeRevertCopyStarted   CSIReason = "VolumeRevertCopyStarted"
+	VolumeRevertReplacing     CSIReason = "VolumeRevertReplacing"
+	VolumeRevertCompleted     CSIReason = "VolumeRevertCompleted"
+	VolumeRevertInvalid       CSIReason = "VolumeRevertInvalid"
+	VolumeRevertVolumeInUse   CSIReason = "VolumeRevertVolumeInUse"
+	VolumeRevertWaitingForGUI CSIReason = "VolumeRevertWaitingForGUI"
+	VolumeRevertStepFailed    CSIReason = "VolumeRevertStepFailed"
+)
@@ -0,0 +1,198 @@
+/*
+Copyright 2024 IBM Corp.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/connectors"
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/utils"
+)
+
+// afmConnector runs the AFM requests of cache and replicated volumes with the
+// GUI endpoints and the credentials of the connector of a cluster, as the
+// driver connector the operator is built with has no AFM methods.
+type afmConnector struct {
+	*connectors.SpectrumRestV2
+}
+
+// afmControlRequest is the request body of the afmctl GUI API
+type afmControlRequest struct {
+	Action string   `json:"action"`
+	Paths  []string `json:"paths,omitempty"`
+	Start  bool     `json:"start,omitempty"`
+	Stop   bool     `json:"stop,omitempty"`
+}
+
+// AFM modes of the filesets of replicated volumes
+const (
+	afmModePrimary   = "primary"
+	afmModeSecondary = "secondary"
+)
+
+type afmCacheState struct {
+	FilesetName string `json:"filesetName,omitempty"`
+	CacheState  string `json:"cacheState,omitempty"`
+	QueueLength int    `json:"queueLength,omitempty"`
+}
+
+type getAFMStateResponse struct {
+	AFMStates []afmCacheState   `json:"afmStates,omitempty"`
+	Status    connectors.Status `json:"status,omitempty"`
+}
+
+// newAFMConnector returns the cache connector of the connector of a cluster
+func newAFMConnector(conn connectors.SpectrumScaleConnector) (*afmConnector, error) {
+	rest, ok := conn.(*connectors.SpectrumRestV2)
+	if !ok || len(rest.Endpoint) == 0 {
+		return nil, fmt.Errorf("the IBM Storage Scale connector has no GUI endpoint")
+	}
+	return &afmConnector{SpectrumRestV2: rest}, nil
+}
+
+// PrefetchCacheFileset fetches the passed paths of a cache fileset from its home.
+// The paths are relative to the fileset junction, the whole fileset is fetched
+// if no path is passed.
+func (c *afmConnector) PrefetchCacheFileset(ctx context.Context, filesystemName string, filesetName string, paths []string) error {
+	absPaths, err := c.getFilesetPaths(ctx, filesystemName, filesetName, paths)
+	if err != nil {
+		return err
+	}
+	return c.runAFMControl(ctx, filesystemName, filesetName, afmControlRequest{Action: "prefetch", Paths: absPaths})
+}
+
+// EvictCacheFileset evicts the data of the passed paths of a cache fileset to
+// free up its quota. The paths are relative to the fileset junction, the whole
+// fileset is evicted if no path is passed.
+func (c *afmConnector) EvictCacheFileset(ctx context.Context, filesystemName string, filesetName string, paths []string) error {
+	absPaths, err := c.getFilesetPaths(ctx, filesystemName, filesetName, paths)
+	if err != nil {
+		return err
+	}
+	return c.runAFMControl(ctx, filesystemName, filesetName, afmControlRequest{Action: "evict", Paths: absPaths})
+}
+
+// GetAFMCacheState returns the AFM cache state and the queue length of a cache fileset
+func (c *afmConnector) GetAFMCacheState(ctx context.Context, filesystemName string, filesetName string) (string, int, error) {
+	getAFMStateURL := fmt.Sprintf("scalemgmt/v2/filesystems/%s/afm/state?filter=filesetName=%s", filesystemName, filesetName)
+	getAFMStateResponse := getAFMStateResponse{}
+	if err := c.doHTTP(ctx, getAFMStateURL, http.MethodGet, &getAFMStateResponse, nil); err != nil {
+		return "", 0, err
+	}
+	for _, afmState := range getAFMStateResponse.AFMStates {
+		if afmState.FilesetName == filesetName {
+			return afmState.CacheState, afmState.QueueLength, nil
+		}
+	}
+	return "", 0, fmt.Errorf("no AFM state returned for fileset %s", filesetName)
+}
+
+// FailoverToSecondary makes the AFM-DR secondary fileset the acting primary
+func (c *afmConnector) FailoverToSecondary(ctx context.Context, filesystemName string, filesetName string) error {
+	return c.runAFMControl(ctx, filesystemName, filesetName, afmControlRequest{Action: "failoverToSecondary"})
+}
+
+// FailbackToPrimary starts the failback of an AFM-DR primary fileset from the
+// acting primary, or completes it if stop is true.
+func (c *afmConnector) FailbackToPrimary(ctx context.Context, filesystemName string, filesetName string, stop bool) error {
+	return c.runAFMControl(ctx, filesystemName, filesetName, afmControlRequest{Action: "failbackToPrimary", Start: !stop, Stop: stop})
+}
+
+// ApplyUpdates copies the changes made on the acting primary to an AFM-DR
+// primary fileset being failed back.
+func (c *afmConnector) ApplyUpdates(ctx context.Context, filesystemName string, filesetName string) error {
+	return c.runAFMControl(ctx, filesystemName, filesetName, afmControlRequest{Action: "applyUpdates"})
+}
+
+// ConvertToSecondary converts an acting primary fileset back to the AFM-DR
+// secondary of the primary with the passed primary ID.
+func (c *afmConnector) ConvertToSecondary(ctx context.Context, filesystemName string, filesetName string, primaryID string) error {
+	updateFilesetURL := fmt.Sprintf("scalemgmt/v2/filesystems/%s/filesets/%s", filesystemName, filesetName)
+	filesetreq := connectors.CreateFilesetRequest{AfmMode: afmModeSecondary, AfmPrimaryID: primaryID}
+	updateFilesetResponse := connectors.GenericResponse{}
+	if err := c.doHTTP(ctx, updateFilesetURL, http.MethodPut, &updateFilesetResponse, filesetreq); err != nil {
+		return fmt.Errorf("failed to convert fileset %s to AFM-DR secondary: %w", filesetName, err)
+	}
+	if len(updateFilesetResponse.Jobs) == 0 {
+		return fmt.Errorf("unable to get the job converting fileset %s to AFM-DR secondary: %v", filesetName, updateFilesetResponse)
+	}
+	return c.WaitForJobCompletion(ctx, updateFilesetResponse.Status.Code, updateFilesetResponse.Jobs[0].JobID)
+}
+
+// getFilesetPaths returns the absolute paths of the passed paths relative to the fileset junction
+func (c *afmConnector) getFilesetPaths(ctx context.Context, filesystemName string, filesetName string, paths []string) ([]string, error) {
+	fileset, err := c.ListFileset(ctx, filesystemName, filesetName)
+	if err != nil {
+		return nil, err
+	}
+	junctionPath := fileset.Config.Path
+	if junctionPath == "" || junctionPath == "--" {
+		return nil, fmt.Errorf("fileset %s is not linked", filesetName)
+	}
+	if len(paths) == 0 {
+		return []string{junctionPath}, nil
+	}
+	absPaths := make([]string, 0, len(paths))
+	for _, p := range paths {
+		absPaths = append(absPaths, junctionPath+"/"+p)
+	}
+	return absPaths, nil
+}
+
+// runAFMControl runs an AFM action on a fileset and waits for its job to complete
+func (c *afmConnector) runAFMControl(ctx context.Context, filesystemName string, filesetName string, afmctlreq afmControlRequest) error {
+	afmctlURL := fmt.Sprintf("scalemgmt/v2/filesystems/%s/filesets/%s/afmctl", filesystemName, filesetName)
+	afmctlResponse := connectors.GenericResponse{}
+	if err := c.doHTTP(ctx, afmctlURL, http.MethodPut, &afmctlResponse, afmctlreq); err != nil {
+		return fmt.Errorf("failed to run AFM action %s on fileset %s: %w", afmctlreq.Action, filesetName, err)
+	}
+	if len(afmctlResponse.Jobs) == 0 {
+		return fmt.Errorf("unable to get the job of AFM action %s on fileset %s: %v", afmctlreq.Action, filesetName, afmctlResponse)
+	}
+	return c.WaitForJobCompletion(ctx, afmctlResponse.Status.Code, afmctlResponse.Jobs[0].JobID)
+}
+
+// doHTTP sends a request to the GUI endpoints of the connector in turn until
+// one of them can be connected to.
+func (c *afmConnector) doHTTP(ctx context.Context, urlSuffix string, method string, responseObject interface{}, param interface{}) error {
+	var response *http.Response
+	var err error
+	for i := range c.Endpoint {
+		endpoint := c.Endpoint[(c.EndPointIndex+i)%len(c.Endpoint)]
+		response, err = utils.HttpExecuteUserAuth(ctx, c.HTTPclient, method, endpoint+urlSuffix, c.ClusterConfig.MgmtUsername, c.ClusterConfig.MgmtPassword, param)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to the GUI: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("%s request %s is not authorized: %s", method, urlSuffix, response.Status)
+	}
+	if err := utils.UnmarshalResponse(ctx, response, responseObject); err != nil {
+		return fmt.Errorf("failed to read the response of %s request %s: %w", method, urlSuffix, err)
+	}
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusCreated && response.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("%s request %s failed with response %v", method, urlSuffix, responseObject)
+	}
+	return nil
+}
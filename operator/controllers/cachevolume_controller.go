@@ -0,0 +1,351 @@
+/*
+Copyright 2024 IBM Corp.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	csiv1 "github.com/IBM/ibm-spectrum-scale-csi/operator/api/v1"
+	config "github.com/IBM/ibm-spectrum-scale-csi/operator/controllers/config"
+)
+
+// CacheVolumeReconciler handles the prefetch and the eviction requests of
+// cache volumes, which are made by annotating the PVC, and polls the AFM state
+// of the cache volumes.
+type CacheVolumeReconciler struct {
+	Client client.Client
+	// APIReader reads PVs directly from the API server.
+	APIReader client.Reader
+	Scheme    *runtime.Scheme
+	Recorder  record.EventRecorder
+}
+
+var cacheVolumeLog = log.Log.WithName("cachevolume_controller")
+
+const storageClassCache = "2"
+
+// Annotations set on the PVCs by the provisioner which provisions them
+const (
+	storageProvisionerAnnotation     = "volume.kubernetes.io/storage-provisioner"
+	betaStorageProvisionerAnnotation = "volume.beta.kubernetes.io/storage-provisioner"
+)
+
+// AFM cache states, a cache volume is unhealthy in any other state
+const (
+	cacheStateActive   = "Active"
+	cacheStateInactive = "Inactive"
+	cacheStateClean    = "Clean"
+	cacheStateDirty    = "Dirty"
+)
+
+// Metrics of the AFM state of the cache volumes
+var (
+	cacheVolumeState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ibm_spectrum_scale_csi_cache_volume_state",
+		Help: "AFM state of the cache volume, the value is 1 for the current state.",
+	}, []string{"namespace", "persistentvolumeclaim", "state"})
+	cacheVolumeQueueLength = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ibm_spectrum_scale_csi_cache_volume_queue_length",
+		Help: "Number of operations queued for the home of the cache volume.",
+	}, []string{"namespace", "persistentvolumeclaim"})
+)
+
+// cacheStates holds the last polled AFM state of the cache volumes by PVC
+var cacheStates sync.Map
+
+// cacheRequestsRunning holds the PVCs whose prefetch or eviction requests are running
+var cacheRequestsRunning sync.Map
+
+func init() {
+	metrics.Registry.MustRegister(cacheVolumeState, cacheVolumeQueueLength)
+}
+
+// cacheRequest is a prefetch or an eviction request of a cache volume
+type cacheRequest struct {
+	annotation string
+	paths      string
+	completed  csiv1.CSIReason
+	failed     csiv1.CSIReason
+	run        func(ctx context.Context, conn *afmConnector, filesystemName string, filesetName string, paths []string) error
+}
+
+// Reconcile starts the prefetch or the eviction requested by the annotations
+// of a cache volume PVC and removes the annotations. The requests run in the
+// background and their outcome is recorded as an event on the PVC. The AFM
+// state of the cache volume is then polled every CacheVolumePollSeconds.
+func (r *CacheVolumeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := cacheVolumeLog.WithName("Reconcile").WithValues("PVC", req.NamespacedName)
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := r.Client.Get(ctx, req.NamespacedName, pvc); err != nil {
+		if errors.IsNotFound(err) {
+			forgetCacheState(req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to get PVC")
+		return ctrl.Result{}, err
+	}
+	if !pvc.DeletionTimestamp.IsZero() {
+		forgetCacheState(req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+
+	requests := getCacheRequests(pvc)
+	if pvc.Status.Phase == corev1.ClaimPending {
+		// The prefetch requested by the driver when the volume is created is
+		// started once the PVC is bound
+		return ctrl.Result{}, nil
+	}
+	message, volumeHandle, err := r.getCacheVolumeHandle(ctx, pvc)
+	if err != nil {
+		logger.Error(err, "Failed to get the PV of the PVC")
+		return ctrl.Result{}, err
+	}
+	if message != "" {
+		if len(requests) == 0 {
+			return ctrl.Result{}, nil
+		}
+		r.Recorder.Event(pvc, corev1.EventTypeWarning, string(csiv1.CacheRequestInvalid), message)
+		return ctrl.Result{}, r.removeCacheAnnotations(ctx, pvc, requests)
+	}
+
+	volMembers := strings.Split(volumeHandle, ";")
+	clusterID := volMembers[volIDClusterIndex]
+	conn, connectorExists := getScaleConnector(clusterID)
+	if !connectorExists {
+		logger.Info("Waiting for the GUI connection of the cluster to be initialized", "clusterID", clusterID)
+		return ctrl.Result{RequeueAfter: config.CacheVolumeRetrySeconds * time.Second}, nil
+	}
+	cacheConn, err := newAFMConnector(conn)
+	if err != nil {
+		logger.Error(err, "Failed to get the connector of the cluster", "clusterID", clusterID)
+		return ctrl.Result{}, err
+	}
+
+	filesystemName, err := conn.GetFilesystemName(ctx, volMembers[volIDFsUUIDIndex])
+	if err != nil {
+		logger.Error(err, "Failed to get the filesystem name", "filesystemUUID", volMembers[volIDFsUUIDIndex])
+		return ctrl.Result{}, err
+	}
+	filesetName := volMembers[volIDFilesetIndex]
+
+	if len(requests) != 0 {
+		started, err := r.startCacheRequests(ctx, pvc, cacheConn, filesystemName, filesetName, requests)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !started {
+			logger.Info("Waiting for the running requests of the cache volume to complete")
+			return ctrl.Result{RequeueAfter: config.CacheVolumeRetrySeconds * time.Second}, nil
+		}
+	}
+
+	r.pollCacheState(ctx, pvc, cacheConn, filesystemName, filesetName)
+	return ctrl.Result{RequeueAfter: config.CacheVolumePollSeconds * time.Second}, nil
+}
+
+// startCacheRequests removes the annotations of the prefetch and the eviction
+// requests of a cache volume and runs the requests in the background, so that
+// a long prefetch does not hold up the reconcile. False is returned if the
+// earlier requests of the PVC are still running.
+func (r *CacheVolumeReconciler) startCacheRequests(ctx context.Context, pvc *corev1.PersistentVolumeClaim, conn *afmConnector, filesystemName string, filesetName string, requests []cacheRequest) (bool, error) {
+	key := client.ObjectKeyFromObject(pvc)
+	if _, running := cacheRequestsRunning.LoadOrStore(key, struct{}{}); running {
+		return false, nil
+	}
+	if err := r.removeCacheAnnotations(ctx, pvc, requests); err != nil {
+		cacheRequestsRunning.Delete(key)
+		return false, err
+	}
+	go func(pvc *corev1.PersistentVolumeClaim) {
+		defer cacheRequestsRunning.Delete(key)
+		r.runCacheRequests(context.Background(), pvc, conn, filesystemName, filesetName, requests)
+	}(pvc.DeepCopy())
+	return true, nil
+}
+
+// runCacheRequests runs the prefetch and the eviction requests of a cache
+// volume and records their outcome as events on the PVC.
+func (r *CacheVolumeReconciler) runCacheRequests(ctx context.Context, pvc *corev1.PersistentVolumeClaim, conn *afmConnector, filesystemName string, filesetName string, requests []cacheRequest) {
+	logger := cacheVolumeLog.WithName("runCacheRequests").WithValues("PVC", client.ObjectKeyFromObject(pvc))
+
+	for _, request := range requests {
+		if err := request.run(ctx, conn, filesystemName, filesetName, parseCachePaths(request.paths)); err != nil {
+			logger.Error(err, "Failed to process the cache volume request", "annotation", request.annotation)
+			r.Recorder.Event(pvc, corev1.EventTypeWarning, string(request.failed),
+				fmt.Sprintf("Failed to process %s=%s: %v", request.annotation, request.paths, err))
+		} else {
+			r.Recorder.Event(pvc, corev1.EventTypeNormal, string(request.completed),
+				fmt.Sprintf("Processed %s=%s", request.annotation, request.paths))
+		}
+	}
+}
+
+// pollCacheState updates the metrics of a cache volume from the AFM state of
+// its fileset, and records an event on the PVC when the state changes.
+func (r *CacheVolumeReconciler) pollCacheState(ctx context.Context, pvc *corev1.PersistentVolumeClaim, conn *afmConnector, filesystemName string, filesetName string) {
+	key := client.ObjectKeyFromObject(pvc)
+	logger := cacheVolumeLog.WithName("pollCacheState").WithValues("PVC", key)
+
+	state, queueLength, err := conn.GetAFMCacheState(ctx, filesystemName, filesetName)
+	if err != nil {
+		logger.Error(err, "Failed to get the AFM state of the cache volume", "fileset", filesetName)
+		return
+	}
+	logger.V(1).Info("Polled the AFM state of the cache volume", "state", state, "queueLength", queueLength)
+
+	previous, found := cacheStates.Swap(key, state)
+	if found && previous.(string) != state {
+		cacheVolumeState.DeleteLabelValues(key.Namespace, key.Name, previous.(string))
+	}
+	cacheVolumeState.WithLabelValues(key.Namespace, key.Name, state).Set(1)
+	cacheVolumeQueueLength.WithLabelValues(key.Namespace, key.Name).Set(float64(queueLength))
+
+	if found && previous.(string) == state {
+		return
+	}
+	switch state {
+	case cacheStateActive, cacheStateInactive, cacheStateClean:
+		if found {
+			r.Recorder.Event(pvc, corev1.EventTypeNormal, string(csiv1.CacheStateHealthy), fmt.Sprintf("Cache state changed to %s", state))
+		}
+	case cacheStateDirty:
+		r.Recorder.Event(pvc, corev1.EventTypeNormal, string(csiv1.CacheStateDirty),
+			fmt.Sprintf("Cache state changed to %s, %d operations are queued for the home", state, queueLength))
+	default:
+		r.Recorder.Event(pvc, corev1.EventTypeWarning, string(csiv1.CacheStateUnhealthy),
+			fmt.Sprintf("Cache state changed to %s, writes are not reaching the home, %d operations are queued", state, queueLength))
+	}
+}
+
+// forgetCacheState removes the metrics and the last polled state of a deleted PVC
+func forgetCacheState(key types.NamespacedName) {
+	if previous, found := cacheStates.LoadAndDelete(key); found {
+		cacheVolumeState.DeleteLabelValues(key.Namespace, key.Name, previous.(string))
+		cacheVolumeQueueLength.DeleteLabelValues(key.Namespace, key.Name)
+	}
+}
+
+// getCacheRequests returns the prefetch and the eviction requests of a PVC,
+// the prefetch is run first.
+func getCacheRequests(pvc *corev1.PersistentVolumeClaim) []cacheRequest {
+	requests := []cacheRequest{}
+	if paths, ok := pvc.Annotations[config.CachePrefetchAnnotation]; ok {
+		requests = append(requests, cacheRequest{
+			annotation: config.CachePrefetchAnnotation,
+			paths:      paths,
+			completed:  csiv1.CachePrefetchCompleted,
+			failed:     csiv1.CachePrefetchFailed,
+			run: func(ctx context.Context, conn *afmConnector, filesystemName string, filesetName string, paths []string) error {
+				return conn.PrefetchCacheFileset(ctx, filesystemName, filesetName, paths)
+			},
+		})
+	}
+	if paths, ok := pvc.Annotations[config.CacheEvictAnnotation]; ok {
+		requests = append(requests, cacheRequest{
+			annotation: config.CacheEvictAnnotation,
+			paths:      paths,
+			completed:  csiv1.CacheEvictCompleted,
+			failed:     csiv1.CacheEvictFailed,
+			run: func(ctx context.Context, conn *afmConnector, filesystemName string, filesetName string, paths []string) error {
+				return conn.EvictCacheFileset(ctx, filesystemName, filesetName, paths)
+			},
+		})
+	}
+	return requests
+}
+
+// parseCachePaths returns the comma separated paths of a cache volume relative
+// to the volume root. An empty list is returned if any of the paths is the
+// volume root, which means the whole volume.
+func parseCachePaths(paths string) []string {
+	cachePaths := []string{}
+	for _, p := range strings.Split(paths, ",") {
+		p = strings.Trim(strings.TrimSpace(p), "/")
+		if p == "" {
+			return []string{}
+		}
+		cachePaths = append(cachePaths, p)
+	}
+	return cachePaths
+}
+
+// getCacheVolumeHandle returns the volume handle of a cache volume PVC. A non
+// empty message is returned if the PVC is not a cache volume.
+func (r *CacheVolumeReconciler) getCacheVolumeHandle(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (string, string, error) {
+	if pvc.Status.Phase != corev1.ClaimBound || pvc.Spec.VolumeName == "" {
+		return fmt.Sprintf("PVC %s/%s is not bound", pvc.Namespace, pvc.Name), "", nil
+	}
+	pv := &corev1.PersistentVolume{}
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Name: pvc.Spec.VolumeName}, pv); err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Sprintf("PV %s of PVC %s/%s is not found", pvc.Spec.VolumeName, pvc.Namespace, pvc.Name), "", nil
+		}
+		return "", "", err
+	}
+	if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != config.DriverName {
+		return fmt.Sprintf("PVC %s/%s is not a volume of the IBM Storage Scale CSI driver", pvc.Namespace, pvc.Name), "", nil
+	}
+	volMembers := strings.Split(pv.Spec.CSI.VolumeHandle, ";")
+	if len(volMembers) != volIDMembersCount || volMembers[volIDSCTypeIndex] != storageClassCache || volMembers[volIDFilesetIndex] == "" {
+		return fmt.Sprintf("PVC %s/%s is not a cache volume", pvc.Namespace, pvc.Name), "", nil
+	}
+	return "", pv.Spec.CSI.VolumeHandle, nil
+}
+
+// removeCacheAnnotations removes the annotations of the processed requests from the PVC.
+func (r *CacheVolumeReconciler) removeCacheAnnotations(ctx context.Context, pvc *corev1.PersistentVolumeClaim, requests []cacheRequest) error {
+	patch := client.MergeFrom(pvc.DeepCopy())
+	for _, request := range requests {
+		delete(pvc.Annotations, request.annotation)
+	}
+	return r.Client.Patch(ctx, pvc, patch)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CacheVolumeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	isScaleVolume := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		annotations := obj.GetAnnotations()
+		_, prefetch := annotations[config.CachePrefetchAnnotation]
+		_, evict := annotations[config.CacheEvictAnnotation]
+		return prefetch || evict ||
+			annotations[storageProvisionerAnnotation] == config.DriverName ||
+			annotations[betaStorageProvisionerAnnotation] == config.DriverName
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("cachevolume").
+		For(&corev1.PersistentVolumeClaim{}, builder.WithPredicates(isScaleVolume)).
+		Complete(r)
+}
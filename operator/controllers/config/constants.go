@@ -155,30 +155,151 @@ const (
 	EnvVolumeStatsCapabilityKey       = "VOLUME_STATS_CAPABILITY"
 	EnvDiscoverCGFilesetKey           = "DISCOVER_CG_FILESET"
 	HostNetworkKey                    = "HOST_NETWORK"
+	EnvPublishNodeHealthCheckKey      = "PUBLISH_NODE_HEALTH_CHECK"
+	TaintUnhealthyNodesKey            = "TAINT_UNHEALTHY_NODES"
+	EnvBindMountRepairKey             = "BIND_MOUNT_REPAIR"
+	EnvVolumeGCModeKey                = "VOLUME_GC_MODE"
+	EnvVolumeGCIntervalKey            = "VOLUME_GC_INTERVAL"
+	EnvVolumeGCMinAgeKey              = "VOLUME_GC_MIN_AGE"
+	EnvUnmountLingerPeriodKey         = "UNMOUNT_LINGER_PERIOD"
+	EnvMigrationPolicyKey             = "MIGRATION_POLICY"
 
 	// Optional ConfigMap keys with prefix
-	EnvLogLevelKeyPrefixed              = EnvVarPrefix + EnvLogLevelKey
-	EnvPersistentLogKeyPrefixed         = EnvVarPrefix + EnvPersistentLogKey
-	EnvNodePublishMethodKeyPrefixed     = EnvVarPrefix + EnvNodePublishMethodKey
-	EnvVolumeStatsCapabilityKeyPrefixed = EnvVarPrefix + EnvVolumeStatsCapabilityKey
-	EnvDiscoverCGFilesetKeyPrefixed     = EnvVarPrefix + EnvDiscoverCGFilesetKey
+	EnvLogLevelKeyPrefixed               = EnvVarPrefix + EnvLogLevelKey
+	EnvPersistentLogKeyPrefixed          = EnvVarPrefix + EnvPersistentLogKey
+	EnvNodePublishMethodKeyPrefixed      = EnvVarPrefix + EnvNodePublishMethodKey
+	EnvVolumeStatsCapabilityKeyPrefixed  = EnvVarPrefix + EnvVolumeStatsCapabilityKey
+	EnvDiscoverCGFilesetKeyPrefixed      = EnvVarPrefix + EnvDiscoverCGFilesetKey
+	EnvPublishNodeHealthCheckKeyPrefixed = EnvVarPrefix + EnvPublishNodeHealthCheckKey
+	EnvBindMountRepairKeyPrefixed        = EnvVarPrefix + EnvBindMountRepairKey
+	EnvVolumeGCModeKeyPrefixed           = EnvVarPrefix + EnvVolumeGCModeKey
+	EnvVolumeGCIntervalKeyPrefixed       = EnvVarPrefix + EnvVolumeGCIntervalKey
+	EnvVolumeGCMinAgeKeyPrefixed         = EnvVarPrefix + EnvVolumeGCMinAgeKey
+	EnvUnmountLingerPeriodKeyPrefixed    = EnvVarPrefix + EnvUnmountLingerPeriodKey
+	EnvMigrationPolicyKeyPrefixed        = EnvVarPrefix + EnvMigrationPolicyKey
 
 	// Optional ConfigMap default values
-	DriverCPULimitsDefaultValue          = "600m"
-	DriverMemoryLimitsDefaultValue       = "600Mi"
-	SidecarCPULimitsDefaultValue         = "300m"
-	SidecarMemoryLimitsDefaultValue      = "800Mi"
-	EnvLogLevelDefaultValue              = "INFO"
-	EnvPersistentLogDefaultValue         = "DISABLED"
-	EnvNodePublishMethodDefaultValue     = "BINDMOUNT"
-	EnvVolumeStatsCapabilityDefaultValue = "ENABLED"
-	EnvHostNetworkDefaultValue           = "ENABLED"
+	DriverCPULimitsDefaultValue           = "600m"
+	DriverMemoryLimitsDefaultValue        = "600Mi"
+	SidecarCPULimitsDefaultValue          = "300m"
+	SidecarMemoryLimitsDefaultValue       = "800Mi"
+	EnvLogLevelDefaultValue               = "INFO"
+	EnvPersistentLogDefaultValue          = "DISABLED"
+	EnvNodePublishMethodDefaultValue      = "BINDMOUNT"
+	EnvVolumeStatsCapabilityDefaultValue  = "ENABLED"
+	EnvHostNetworkDefaultValue            = "ENABLED"
+	EnvPublishNodeHealthCheckDefaultValue = "ENABLED"
+	TaintUnhealthyNodesDefaultValue       = "DISABLED"
+	EnvBindMountRepairDefaultValue        = "ENABLED"
+	EnvVolumeGCModeDefaultValue           = "REPORT"
+	EnvMigrationPolicyDefaultValue        = "DISABLED"
 
 	// Driver and Sidecar Containers Resources limits
 	PodsCPULimitsLowerValue    = "20m"
 	PodsMemoryLimitsLowerValue = "20Mi"
 )
 
+// Values of the boolean keys of the optional ConfigMap
+const (
+	BooleanEnabledValue  = "ENABLED"
+	BooleanDisabledValue = "DISABLED"
+)
+
+// ScaleSnapshotSchedule constants
+const (
+	// LabelSnapshotSchedule is the name of the ScaleSnapshotSchedule which took a VolumeSnapshot.
+	LabelSnapshotSchedule = "csi.ibm.com/snapshot-schedule"
+	// LabelSnapshotScheduleNamespace is the namespace of the ScaleSnapshotSchedule which took a VolumeSnapshot.
+	LabelSnapshotScheduleNamespace = "csi.ibm.com/snapshot-schedule-namespace"
+
+	SnapshotAPIGroup   = "snapshot.storage.k8s.io"
+	SnapshotAPIVersion = "v1"
+	VolumeSnapshotKind = "VolumeSnapshot"
+	// VolumeSnapshotClassKind is a cluster scoped kind of the snapshot API group
+	VolumeSnapshotClassKind = "VolumeSnapshotClass"
+
+	// Requeue interval while scheduled snapshots are not ready to use
+	SnapshotScheduleRetrySeconds = 30
+
+	// Parameter of the VolumeSnapshotClass with the minutes in which the driver
+	// reuses the snapshot of a consistency group, and its default value in the
+	// driver. It must match the driver.
+	SnapWindowParameter      = "snapWindow"
+	DefaultSnapWindowMinutes = 30
+)
+
+// ScaleVolumeRevert constants
+const (
+	VolumeSnapshotContentKind = "VolumeSnapshotContent"
+
+	// Requeue interval while the GUI connection of the cluster owning the volume is not initialized
+	// or the volume is in use
+	VolumeRevertRetrySeconds = 30
+	// Requeue interval while a copy job of a revert is running
+	VolumeRevertPollSeconds = 30
+
+	// Label of the PV being reverted, set to the UID of the ScaleVolumeRevert.
+	// The driver refuses to publish a volume while its PV has the label.
+	LabelVolumeRevert = "csi.ibm.com/volume-revert"
+	// Finalizer of a ScaleVolumeRevert removing the label of the PV
+	VolumeRevertFinalizer = "csi.ibm.com/volume-revert"
+)
+
+// ScaleVolumeReplication constants
+const (
+	// Requeue interval while the GUI connections of the clusters of the volume
+	// are not initialized or the replication state is being changed
+	VolumeReplicationRetrySeconds = 30
+
+	// Interval at which the replication lag of the primary volumes is polled
+	VolumeReplicationPollSeconds = 60
+)
+
+// Cache volume constants
+const (
+	// PVC annotations requesting the prefetch or the eviction of comma separated
+	// paths of a cache volume, relative to the volume root. The annotation is
+	// removed once the request is processed.
+	CachePrefetchAnnotation = "csi.ibm.com/cache-prefetch"
+	CacheEvictAnnotation    = "csi.ibm.com/cache-evict"
+
+	// Requeue interval while the GUI connection of the cluster owning the volume is not initialized
+	CacheVolumeRetrySeconds = 30
+
+	// Interval at which the AFM state of the cache volumes is polled
+	CacheVolumePollSeconds = 120
+)
+
+// Node health constants
+const (
+	// UnhealthyNodeTaintKey is the key of the NoSchedule taint of the nodes on
+	// which IBM Storage Scale is not healthy, when TAINT_UNHEALTHY_NODES is
+	// enabled in the optional ConfigMap.
+	UnhealthyNodeTaintKey = "csi.ibm.com/scale-unhealthy"
+
+	// MappedScaleNodeNameKey is the annotation of a node with the admin node
+	// name of the IBM Storage Scale node it is mapped to by the driver. It
+	// must match the annotation of the driver.
+	MappedScaleNodeNameKey = "csi.ibm.com/mapped-scale-node-name"
+
+	// Requeue interval while the GUI connection of the primary cluster is not initialized
+	NodeHealthRetrySeconds = 30
+
+	// Interval at which the health of the nodes is polled
+	NodeHealthPollSeconds = 60
+)
+
+// ScaleFilesetImport constants
+const (
+	// Requeue interval while the GUI connection of the cluster owning the fileset is not initialized
+	FilesetImportRetrySeconds = 30
+
+	// Separator of the fileset comment of the driver and the time the driver
+	// created or adopted the fileset, the garbage collection of the driver
+	// skips the filesets without the time. It must match the driver.
+	FilesetCommentTimeSep = ", since "
+)
+
 var CSIOptionalConfigMapKeys = []string{
 	EnvLogLevelKeyPrefixed,
 	EnvPersistentLogKeyPrefixed,
@@ -187,6 +308,14 @@ var CSIOptionalConfigMapKeys = []string{
 	DaemonSetUpgradeMaxUnavailableKey,
 	EnvDiscoverCGFilesetKeyPrefixed,
 	HostNetworkKey,
+	EnvPublishNodeHealthCheckKeyPrefixed,
+	TaintUnhealthyNodesKey,
+	EnvBindMountRepairKeyPrefixed,
+	EnvVolumeGCModeKeyPrefixed,
+	EnvVolumeGCIntervalKeyPrefixed,
+	EnvVolumeGCMinAgeKeyPrefixed,
+	EnvUnmountLingerPeriodKeyPrefixed,
+	EnvMigrationPolicyKeyPrefixed,
 	DriverCPULimits,
 	DriverMemoryLimits,
 	SidecarCPULimits,
@@ -197,6 +326,10 @@ var EnvPersistentLogValues = []string{"ENABLED", "DISABLED"}
 var EnvVolumeStatsCapabilityValues = []string{"ENABLED", "DISABLED"}
 var EnvDiscoverCGFilesetValues = []string{"ENABLED", "DISABLED"}
 var EnvHostNetworkValues = []string{"ENABLED", "DISABLED"}
+var EnvPublishNodeHealthCheckValues = []string{"ENABLED", "DISABLED"}
+var EnvBindMountRepairValues = []string{"ENABLED", "DISABLED"}
+var EnvVolumeGCModeValues = []string{"DISABLED", "REPORT", "DELETE"}
+var EnvMigrationPolicyValues = []string{"ENABLED", "DISABLED"}
 
 const (
 	StatusConditionReady   = "Ready"
@@ -27,6 +27,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	uuid "github.com/google/uuid"
@@ -93,6 +94,10 @@ var changedClusters = make(map[string]bool)
 // a map of connectors to make REST calls to GUI
 var scaleConnMap = make(map[string]connectors.SpectrumScaleConnector)
 
+// scaleConnMapLock guards the updates of scaleConnMap against
+// the reads from the controllers other than CSIScaleOperator.
+var scaleConnMapLock sync.RWMutex
+
 var cmData = make(map[string]string)
 var cmDataCopy = make(map[string]string)
 
@@ -113,7 +118,7 @@ var watchResources = map[string]map[string]bool{corev1.ResourceConfigMaps.String
 // TODO: Does the operator need to access to all the resources mentioned above?
 // TODO: Does all resources mentioned above required delete/patch/update permissions?
 
-// +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources={clusterroles,clusterrolebindings},verbs=*
+// +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources={clusterroles,clusterrolebindings,roles,rolebindings},verbs=*
 // +kubebuilder:rbac:groups="apps",resources={deployments,daemonsets,replicasets,statefulsets},verbs=create;delete;get;list;update;watch
 // +kubebuilder:rbac:groups="apps",resourceNames=ibm-spectrum-scale-csi-operator,resources=deployments/finalizers,verbs=get;update
 // +kubebuilder:rbac:groups="storage.k8s.io",resources={volumeattachments,storageclasses,csidrivers},verbs=create;delete;get;list;patch;update;watch
@@ -323,6 +328,7 @@ func (r *CSIScaleOperatorReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		r.reconcileServiceAccount,
 		r.reconcileClusterRole,
 		r.reconcileClusterRoleBinding,
+		r.reconcileRole,
 	} {
 		if err = rec(instance); err != nil {
 			return ctrl.Result{}, err
@@ -719,7 +725,9 @@ func (r *CSIScaleOperatorReconciler) updateChangedClusters(instance *csiscaleope
 	//the connector for that cluster as we no longer need it.
 	for _, cluster := range currentCMclusters {
 		if _, processed := currentCMProcessedClusters[cluster.Id]; !processed {
+			scaleConnMapLock.Lock()
 			delete(scaleConnMap, cluster.Id)
+			scaleConnMapLock.Unlock()
 		}
 	}
 	logger.Info("The changed clusters to process", "changedClusters", changedClusters)
@@ -1052,9 +1060,41 @@ func (r *CSIScaleOperatorReconciler) reconcileCSIDriver(instance *csiscaleoperat
 			metav1.ConditionFalse, string(csiv1.GetFailed), message,
 		)
 		return err
+	} else if !reflect.DeepEqual(found.Spec.FSGroupPolicy, cd.Spec.FSGroupPolicy) && instance.Spec.RecreateCSIDriver {
+		// fsGroupPolicy is immutable, the CSIDriver of an older release
+		// is recreated when the CSIScaleOperator allows it
+		logger.Info("Recreating CSIDriver to update its fsGroupPolicy.")
+		if err := r.Client.Delete(context.TODO(), found); err != nil {
+			logger.Error(err, "Failed to delete CSIDriver", "Name", found.GetName())
+			return err
+		}
+		if err := r.Client.Create(context.TODO(), cd); err != nil {
+			message := fmt.Sprintf("Failed to create the CSIDriver %s for the CSISCaleOperator instance %s", config.DriverName, instance.Name)
+			logger.Error(err, message)
+			SetStatusAndRaiseEvent(instance, r.Recorder, corev1.EventTypeWarning, string(config.StatusConditionSuccess),
+				metav1.ConditionFalse, string(csiv1.CreateFailed), message,
+			)
+			return err
+		}
 	} else {
-		// Resource already exists - don't requeue
-		logger.Info("Resource CSIDriver already exists.")
+		if !reflect.DeepEqual(found.Spec.FSGroupPolicy, cd.Spec.FSGroupPolicy) {
+			// The CSIDriver of an older release must be deleted by the
+			// administrator, the operator recreates it
+			message := fmt.Sprintf("The CSIDriver %s has an outdated fsGroupPolicy, which can not be updated. Delete the CSIDriver to let the operator recreate it, or set recreateCSIDriver in the CSISCaleOperator instance %s", config.DriverName, instance.Name)
+			logger.Info(message)
+			RaiseCSOEvent(instance, r.Recorder, corev1.EventTypeWarning, string(csiv1.ValidationWarning), message)
+		}
+		if !reflect.DeepEqual(found.Spec.SELinuxMount, cd.Spec.SELinuxMount) {
+			logger.Info("Updating seLinuxMount of CSIDriver.")
+			found.Spec.SELinuxMount = cd.Spec.SELinuxMount
+			if err := r.Client.Update(context.TODO(), found); err != nil {
+				logger.Error(err, "Failed to update CSIDriver", "Name", found.GetName())
+				return err
+			}
+		} else {
+			// Resource already exists - don't requeue
+			logger.Info("Resource CSIDriver already exists.")
+		}
 	}
 	logger.V(1).Info("Exiting reconcileCSIDriver method.")
 	return nil
@@ -1314,6 +1354,68 @@ func (r *CSIScaleOperatorReconciler) reconcileClusterRole(instance *csiscaleoper
 	return nil
 }
 
+// reconcileRole creates or updates the Role and the RoleBinding of the node
+// plugin in the namespace of the driver. They are owned by the CSIScaleOperator
+// instance, so they are deleted along with it.
+func (r *CSIScaleOperatorReconciler) reconcileRole(instance *csiscaleoperator.CSIScaleOperator) error {
+	logger := csiLog.WithName("reconcileRole")
+	logger.Info("Creating the required Role and RoleBinding resources.")
+
+	for _, obj := range []client.Object{
+		instance.GenerateNodePluginRole(),
+		instance.GenerateNodePluginRoleBinding(),
+	} {
+		kind := "Role"
+		found := client.Object(&rbacv1.Role{})
+		if _, ok := obj.(*rbacv1.RoleBinding); ok {
+			kind = "RoleBinding"
+			found = &rbacv1.RoleBinding{}
+		}
+		if err := controllerutil.SetControllerReference(instance.Unwrap(), obj, r.Scheme); err != nil {
+			message := fmt.Sprintf("Failed to set the controller reference for %s: %s", kind, obj.GetName())
+			logger.Error(err, message)
+			SetStatusAndRaiseEvent(instance, r.Recorder, corev1.EventTypeWarning, string(config.StatusConditionSuccess),
+				metav1.ConditionFalse, string(csiv1.UpdateFailed), message,
+			)
+			return err
+		}
+		err := r.Client.Get(context.TODO(), types.NamespacedName{
+			Name:      obj.GetName(),
+			Namespace: obj.GetNamespace(),
+		}, found)
+		if err != nil && errors.IsNotFound(err) {
+			logger.Info("Creating a new "+kind, "Namespace", obj.GetNamespace(), "Name", obj.GetName())
+			if err := r.Client.Create(context.TODO(), obj); err != nil {
+				message := fmt.Sprintf("Failed to create the %s: %s", kind, obj.GetName())
+				logger.Error(err, message)
+				SetStatusAndRaiseEvent(instance, r.Recorder, corev1.EventTypeWarning, string(config.StatusConditionSuccess),
+					metav1.ConditionFalse, string(csiv1.CreateFailed), message,
+				)
+				return err
+			}
+		} else if err != nil {
+			message := fmt.Sprintf("Failed to get the %s: %s", kind, obj.GetName())
+			logger.Error(err, message)
+			SetStatusAndRaiseEvent(instance, r.Recorder, corev1.EventTypeWarning, string(config.StatusConditionSuccess),
+				metav1.ConditionFalse, string(csiv1.GetFailed), message,
+			)
+			return err
+		} else {
+			logger.Info(kind + " " + obj.GetName() + " already exists. Updating " + kind + ".")
+			if err := r.Client.Update(context.TODO(), obj); err != nil {
+				message := fmt.Sprintf("Failed to update the %s: %s", kind, obj.GetName())
+				logger.Error(err, message)
+				SetStatusAndRaiseEvent(instance, r.Recorder, corev1.EventTypeWarning, string(config.StatusConditionSuccess),
+					metav1.ConditionFalse, string(csiv1.UpdateFailed), message,
+				)
+				return err
+			}
+		}
+	}
+	logger.V(1).Info("Reconciliation of Roles and RoleBindings is successful")
+	return nil
+}
+
 func (r *CSIScaleOperatorReconciler) getClusterRoles(instance *csiscaleoperator.CSIScaleOperator) []*rbacv1.ClusterRole {
 	externalProvisioner := instance.GenerateProvisionerClusterRole()
 	externalAttacher := instance.GenerateAttacherClusterRole()
@@ -1890,10 +1992,12 @@ func (r *CSIScaleOperatorReconciler) handleSpectrumScaleConnectors(instance *csi
 				if err != nil {
 					return requeAfterDelay, err
 				}
+				scaleConnMapLock.Lock()
 				scaleConnMap[cluster.Id] = connector
 				if isPrimaryCluster {
 					scaleConnMap[config.Primary] = connector
 				}
+				scaleConnMapLock.Unlock()
 			}
 
 			//Validate GUI connection and cluster ID in CR.
@@ -1925,7 +2029,9 @@ func (r *CSIScaleOperatorReconciler) handleSpectrumScaleConnectors(instance *csi
 						metav1.ConditionFalse, string(csiv1.GUIConnFailed), message,
 					)
 					//remove the connector if GUI connection fails
+					scaleConnMapLock.Lock()
 					delete(scaleConnMap, cluster.Id)
+					scaleConnMapLock.Unlock()
 					return requeAfterDelay, err
 				} else {
 					logger.Info("The GUI connection for the cluster is successful", "Cluster ID", cluster.Id)
@@ -2316,6 +2422,18 @@ func (r *CSIScaleOperatorReconciler) parseConfigMap(instance *csiscaleoperator.C
 				validateEnvVarValue(config.EnvDiscoverCGFilesetValues[:], keyUpper, value, validEnvMap, invalidEnvValueMap)
 			case config.DaemonSetUpgradeMaxUnavailableKey:
 				validateMaxUnavailableValue(keyUpper, value, validEnvMap, invalidEnvValueMap)
+			case config.EnvPublishNodeHealthCheckKeyPrefixed:
+				validateEnvVarValue(config.EnvPublishNodeHealthCheckValues[:], keyUpper, value, validEnvMap, invalidEnvValueMap)
+			case config.EnvBindMountRepairKeyPrefixed:
+				validateEnvVarValue(config.EnvBindMountRepairValues[:], keyUpper, value, validEnvMap, invalidEnvValueMap)
+			case config.EnvVolumeGCModeKeyPrefixed:
+				validateEnvVarValue(config.EnvVolumeGCModeValues[:], keyUpper, value, validEnvMap, invalidEnvValueMap)
+			case config.EnvMigrationPolicyKeyPrefixed:
+				validateEnvVarValue(config.EnvMigrationPolicyValues[:], keyUpper, value, validEnvMap, invalidEnvValueMap)
+			case config.EnvVolumeGCIntervalKeyPrefixed, config.EnvVolumeGCMinAgeKeyPrefixed, config.EnvUnmountLingerPeriodKeyPrefixed:
+				validateEnvVarDuration(keyUpper, value, validEnvMap, invalidEnvValueMap)
+			case config.TaintUnhealthyNodesKey:
+				validateBooleanValue(keyUpper, value, validEnvMap, invalidEnvValueMap)
 			case config.HostNetworkKey:
 				validateHostNetworkValue(config.EnvHostNetworkValues[:], keyUpper, value, validEnvMap, invalidEnvValueMap)
 			case config.DriverCPULimits:
@@ -2396,6 +2514,17 @@ func validateHostNetworkValue(inputSlice []string, key, value string, envMap, in
 	}
 }
 
+// validateBooleanValue accepts ENABLED or DISABLED in any case and keeps the
+// value in upper case.
+func validateBooleanValue(key string, value string, envMap map[string]string, invalidEnvValue map[string]string) {
+	switch normalized := strings.ToUpper(strings.TrimSpace(value)); normalized {
+	case config.BooleanEnabledValue, config.BooleanDisabledValue:
+		envMap[key] = normalized
+	default:
+		invalidEnvValue[key] = value
+	}
+}
+
 // validateCPULimitsValue: To set only accepted CPU limit from configMap
 func validateCPULimitsValue(key string, value string, data map[string]string, invalidEnvValue map[string]string) {
 	logger := csiLog.WithName("validateCPULimitsValue")
@@ -2472,6 +2601,15 @@ func validateEnvVarValue(inputSlice []string, key string, value string, validEnv
 	}
 }
 
+// validateEnvVarDuration accepts a positive duration, e.g. 24h.
+func validateEnvVarDuration(key string, value string, validEnvMap map[string]string, invalidEnvMap map[string]string) {
+	if duration, err := time.ParseDuration(value); err == nil && duration > 0 {
+		validEnvMap[key[11:]] = value
+	} else {
+		invalidEnvMap[key] = value
+	}
+}
+
 func setDefaultDriverEnvValues(envMap map[string]string) {
 	logger := csiLog.WithName("setDefaultDriverEnvValues")
 	// Set default LogLevel when it is not present in envMap
@@ -2505,6 +2643,26 @@ func setDefaultDriverEnvValues(envMap map[string]string) {
 		logger.Info("DiscoverCGFileset is empty or incorrect.", "Defaulting DiscoverCGFileset to", envDiscoverCGFilesetDefaultValue)
 		envMap[config.EnvDiscoverCGFilesetKey] = envDiscoverCGFilesetDefaultValue
 	}
+	// Set default PublishNodeHealthCheck when it is not present in envMap
+	if _, ok := envMap[config.EnvPublishNodeHealthCheckKey]; !ok {
+		logger.Info("PublishNodeHealthCheck is empty or incorrect.", "Defaulting PublishNodeHealthCheck to", config.EnvPublishNodeHealthCheckDefaultValue)
+		envMap[config.EnvPublishNodeHealthCheckKey] = config.EnvPublishNodeHealthCheckDefaultValue
+	}
+	// Set default VolumeGCMode when it is not present in envMap
+	if _, ok := envMap[config.EnvVolumeGCModeKey]; !ok {
+		logger.Info("VolumeGCMode is empty or incorrect.", "Defaulting VolumeGCMode to", config.EnvVolumeGCModeDefaultValue)
+		envMap[config.EnvVolumeGCModeKey] = config.EnvVolumeGCModeDefaultValue
+	}
+	// Set default MigrationPolicy when it is not present in envMap
+	if _, ok := envMap[config.EnvMigrationPolicyKey]; !ok {
+		logger.Info("MigrationPolicy is empty or incorrect.", "Defaulting MigrationPolicy to", config.EnvMigrationPolicyDefaultValue)
+		envMap[config.EnvMigrationPolicyKey] = config.EnvMigrationPolicyDefaultValue
+	}
+	// Set default BindMountRepair when it is not present in envMap
+	if _, ok := envMap[config.EnvBindMountRepairKey]; !ok {
+		logger.Info("BindMountRepair is empty or incorrect.", "Defaulting BindMountRepair to", config.EnvBindMountRepairDefaultValue)
+		envMap[config.EnvBindMountRepairKey] = config.EnvBindMountRepairDefaultValue
+	}
 
 	// set default HostNetwork env when it is not present in envMap
 	if _, ok := envMap[config.HostNetworkKey]; !ok {
@@ -55,6 +55,9 @@ const (
 	podSecurityPolicyResource            string = "podsecuritypolicies"
 	leaseResource                        string = "leases"
 	secretResource                       string = "secrets"
+	configMapsResource                   string = "configmaps"
+	scaleNodeMappingsResource            string = "scalenodemappings"
+	scaleFilesetImportsResource          string = "scalefilesetimports"
 	verbGet                              string = "get"
 	verbList                             string = "list"
 	verbWatch                            string = "watch"
@@ -66,8 +69,13 @@ const (
 )
 
 // GenerateCSIDriver returns a non-namespaced CSIDriver object.
+// The fsGroup of the pods is always passed to the driver, which applies it
+// to the root of the volume instead of the recursive change by kubelet.
+// seLinuxMount is set only if it is enabled in the CSIScaleOperator spec, as
+// the context of the pods must then match the context of the filesystem mounts.
 func (c *CSIScaleOperator) GenerateCSIDriver() *storagev1.CSIDriver {
-	// fileFSGroupPolicy := storagev1.FileFSGroupPolicy
+	fileFSGroupPolicy := storagev1.FileFSGroupPolicy
+	seLinuxMount := c.Spec.SELinuxMount
 	return &storagev1.CSIDriver{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:   config.DriverName,
@@ -76,7 +84,8 @@ func (c *CSIScaleOperator) GenerateCSIDriver() *storagev1.CSIDriver {
 		Spec: storagev1.CSIDriverSpec{
 			AttachRequired: boolptr.True(),
 			PodInfoOnMount: boolptr.True(),
-			// FSGroupPolicy:  &fileFSGroupPolicy,
+			FSGroupPolicy:  &fileFSGroupPolicy,
+			SELinuxMount:   &seLinuxMount,
 		},
 	}
 }
@@ -515,6 +524,12 @@ func (c *CSIScaleOperator) GenerateNodePluginClusterRole() *rbacv1.ClusterRole {
 				Verbs:     []string{verbGet, verbList, verbWatch, verbUpdate},
 			},
 
+			{
+				APIGroups: []string{""},
+				Resources: []string{persistentVolumeClaimsResource},
+				Verbs:     []string{verbGet, verbUpdate},
+			},
+
 			{
 				APIGroups: []string{storageApiGroup},
 				Resources: []string{volumeAttachmentsResource},
@@ -526,6 +541,18 @@ func (c *CSIScaleOperator) GenerateNodePluginClusterRole() *rbacv1.ClusterRole {
 				Resources: []string{namespacesResource},
 				Verbs:     []string{verbGet, verbList},
 			},
+
+			{
+				APIGroups: []string{config.APIGroup},
+				Resources: []string{scaleNodeMappingsResource},
+				Verbs:     []string{verbGet, verbList},
+			},
+
+			{
+				APIGroups: []string{""},
+				Resources: []string{eventsResource},
+				Verbs:     []string{verbCreate, verbPatch},
+			},
 		},
 	}
 	if len(c.Spec.CSIpspname) != 0 {
@@ -562,6 +589,63 @@ func (c *CSIScaleOperator) GenerateNodePluginClusterRoleBinding() *rbacv1.Cluste
 	}
 }
 
+// GenerateNodePluginRole returns a kubernetes role object for the CSI driver
+// node plugin, for the resources of the namespace of the driver.
+func (c *CSIScaleOperator) GenerateNodePluginRole() *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      config.GetNameForResource(config.NodePlugin, c.Name),
+			Namespace: c.Namespace,
+			Labels:    c.GetLabels(),
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{coordinationApiGroup},
+				Resources: []string{leaseResource},
+				Verbs:     []string{verbGet, verbCreate, verbUpdate},
+			},
+			// The import-fileset command of the driver imports through
+			// a ScaleFilesetImport in the namespace of the driver
+			{
+				APIGroups: []string{config.APIGroup},
+				Resources: []string{scaleFilesetImportsResource},
+				Verbs:     []string{verbGet, verbCreate},
+			},
+			// The ACL templates of the storageClasses are read from the
+			// ConfigMaps of the namespace of the driver
+			{
+				APIGroups: []string{""},
+				Resources: []string{configMapsResource},
+				Verbs:     []string{verbGet},
+			},
+		},
+	}
+}
+
+// GenerateNodePluginRoleBinding returns a kubernetes rolebinding object for the
+// CSI driver node plugin.
+func (c *CSIScaleOperator) GenerateNodePluginRoleBinding() *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      config.GetNameForResource(config.NodePlugin, c.Name),
+			Namespace: c.Namespace,
+			Labels:    c.GetLabels(),
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      "ServiceAccount",
+				Name:      config.GetNameForResource(config.CSINodeServiceAccount, c.Name),
+				Namespace: c.Namespace,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			Kind:     "Role",
+			Name:     config.GetNameForResource(config.NodePlugin, c.Name),
+			APIGroup: rbacAuthorizationApiGroup,
+		},
+	}
+}
+
 /*
 func (c *CSIScaleOperator) GenerateSCCForControllerClusterRole() *rbacv1.ClusterRole {
 	return &rbacv1.ClusterRole{
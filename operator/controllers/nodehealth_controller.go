@@ -0,0 +1,234 @@
+/*
+Copyright 2024 IBM Corp.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	csiv1 "github.com/IBM/ibm-spectrum-scale-csi/operator/api/v1"
+	config "github.com/IBM/ibm-spectrum-scale-csi/operator/controllers/config"
+	csiscaleoperator "github.com/IBM/ibm-spectrum-scale-csi/operator/controllers/internal/csiscaleoperator"
+)
+
+// NodeHealthReconciler polls the health of the GPFS daemon of the nodes of
+// the node plugin and taints the unhealthy nodes with NoSchedule when
+// TAINT_UNHEALTHY_NODES is enabled in the optional ConfigMap, so that no new
+// pods are scheduled to them. The taint is removed once the node is healthy
+// again or the option is disabled. The health of the filesystems is checked
+// by the driver when a volume is published to a node.
+type NodeHealthReconciler struct {
+	Client   client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+var nodeHealthLog = log.Log.WithName("nodehealth_controller")
+
+const healthComponentGPFS = "GPFS"
+
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch;update;patch
+
+// Reconcile taints or untaints a node of the node plugin by the health of its
+// GPFS daemon, and requeues the node for the next poll.
+func (r *NodeHealthReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := nodeHealthLog.WithName("Reconcile").WithValues("Node", req.Name)
+
+	node := &corev1.Node{}
+	if err := r.Client.Get(ctx, req.NamespacedName, node); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to get node")
+		return ctrl.Result{}, err
+	}
+
+	instance, err := r.getCSIScaleOperator(ctx)
+	if err != nil {
+		logger.Error(err, "Failed to get CSIScaleOperator")
+		return ctrl.Result{}, err
+	}
+	if instance == nil {
+		return ctrl.Result{}, r.untaintNode(ctx, node, "")
+	}
+	nodeSelector := labels.SelectorFromSet(instance.GetNodeSelectors(instance.Spec.PluginNodeSelector))
+	if !nodeSelector.Matches(labels.Set(node.Labels)) {
+		return ctrl.Result{}, r.untaintNode(ctx, node, "")
+	}
+
+	enabled, err := r.isTaintUnhealthyNodesEnabled(ctx, instance.Namespace)
+	if err != nil {
+		logger.Error(err, "Failed to get the optional ConfigMap", "ConfigMap", config.EnvVarConfigMap)
+		return ctrl.Result{}, err
+	}
+	if !enabled {
+		return ctrl.Result{RequeueAfter: config.NodeHealthPollSeconds * time.Second}, r.untaintNode(ctx, node, "")
+	}
+
+	conn, connectorExists := getScaleConnector(config.Primary)
+	if !connectorExists {
+		logger.Info("Waiting for the GUI connection of the primary cluster to be initialized")
+		return ctrl.Result{RequeueAfter: config.NodeHealthRetrySeconds * time.Second}, nil
+	}
+
+	scaleNode := getScaleNodeName(instance, node)
+	healthy, err := conn.IsNodeComponentHealthy(ctx, scaleNode, healthComponentGPFS)
+	if err != nil {
+		// The health of a node which is not known to the GUI is not changed
+		logger.Info("Unable to get the health of the IBM Storage Scale node", "scaleNode", scaleNode, "error", err.Error())
+		return ctrl.Result{RequeueAfter: config.NodeHealthPollSeconds * time.Second}, nil
+	}
+
+	if healthy {
+		message := fmt.Sprintf("IBM Storage Scale on node %s is healthy, removed taint %s", scaleNode, config.UnhealthyNodeTaintKey)
+		err = r.untaintNode(ctx, node, message)
+	} else {
+		message := fmt.Sprintf("IBM Storage Scale on node %s is not healthy, added taint %s:%s, check mmhealth node show %s -N %s",
+			scaleNode, config.UnhealthyNodeTaintKey, corev1.TaintEffectNoSchedule, healthComponentGPFS, scaleNode)
+		err = r.taintNode(ctx, node, message)
+	}
+	if err != nil {
+		logger.Error(err, "Failed to update the taints of node")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: config.NodeHealthPollSeconds * time.Second}, nil
+}
+
+// getCSIScaleOperator returns the CSIScaleOperator of the driver, or nil if
+// there is none.
+func (r *NodeHealthReconciler) getCSIScaleOperator(ctx context.Context) (*csiscaleoperator.CSIScaleOperator, error) {
+	instances := &csiv1.CSIScaleOperatorList{}
+	if err := r.Client.List(ctx, instances); err != nil {
+		return nil, err
+	}
+	for i := range instances.Items {
+		if instances.Items[i].DeletionTimestamp.IsZero() {
+			return csiscaleoperator.New(&instances.Items[i]), nil
+		}
+	}
+	return nil, nil
+}
+
+// isTaintUnhealthyNodesEnabled returns true if TAINT_UNHEALTHY_NODES is
+// enabled in the optional ConfigMap.
+func (r *NodeHealthReconciler) isTaintUnhealthyNodesEnabled(ctx context.Context, namespace string) (bool, error) {
+	cm := &corev1.ConfigMap{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: config.EnvVarConfigMap, Namespace: namespace}, cm)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	value := config.TaintUnhealthyNodesDefaultValue
+	for key, val := range cm.Data {
+		if strings.ToUpper(key) == config.TaintUnhealthyNodesKey {
+			value = val
+		}
+	}
+	return strings.ToUpper(strings.TrimSpace(value)) == config.BooleanEnabledValue, nil
+}
+
+// getScaleNodeName returns the admin node name of the IBM Storage Scale node
+// of a node, as mapped by the driver and recorded in the
+// csi.ibm.com/mapped-scale-node-name annotation of the node. The nodeMapping
+// of the CSIScaleOperator, or else the node name, is used until the driver
+// has mapped the node.
+func getScaleNodeName(instance *csiscaleoperator.CSIScaleOperator, node *corev1.Node) string {
+	if scaleNode := node.Annotations[config.MappedScaleNodeNameKey]; scaleNode != "" {
+		return scaleNode
+	}
+	for _, mapping := range instance.Spec.NodeMapping {
+		if mapping.K8sNode == node.Name && mapping.SpectrumscaleNode != "" {
+			return mapping.SpectrumscaleNode
+		}
+	}
+	return node.Name
+}
+
+func hasUnhealthyTaint(node *corev1.Node) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == config.UnhealthyNodeTaintKey {
+			return true
+		}
+	}
+	return false
+}
+
+// taintNode adds the unhealthy taint to a node and records an event.
+func (r *NodeHealthReconciler) taintNode(ctx context.Context, node *corev1.Node, message string) error {
+	if hasUnhealthyTaint(node) {
+		return nil
+	}
+	patch := client.MergeFrom(node.DeepCopy())
+	node.Spec.Taints = append(node.Spec.Taints, corev1.Taint{
+		Key:    config.UnhealthyNodeTaintKey,
+		Effect: corev1.TaintEffectNoSchedule,
+	})
+	if err := r.Client.Patch(ctx, node, patch); err != nil {
+		return err
+	}
+	r.Recorder.Event(node, corev1.EventTypeWarning, string(csiv1.NodeUnhealthy), message)
+	return nil
+}
+
+// untaintNode removes the unhealthy taint of a node and records an event if
+// a message is given.
+func (r *NodeHealthReconciler) untaintNode(ctx context.Context, node *corev1.Node, message string) error {
+	if !hasUnhealthyTaint(node) {
+		return nil
+	}
+	patch := client.MergeFrom(node.DeepCopy())
+	taints := []corev1.Taint{}
+	for _, taint := range node.Spec.Taints {
+		if taint.Key != config.UnhealthyNodeTaintKey {
+			taints = append(taints, taint)
+		}
+	}
+	node.Spec.Taints = taints
+	if err := r.Client.Patch(ctx, node, patch); err != nil {
+		return err
+	}
+	if message != "" {
+		r.Recorder.Event(node, corev1.EventTypeNormal, string(csiv1.NodeHealthy), message)
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager. The nodes are
+// reconciled when they are created or their labels or annotations change,
+// and polled after.
+func (r *NodeHealthReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("nodehealth").
+		For(&corev1.Node{}, builder.WithPredicates(predicate.Or(predicate.LabelChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
+		Complete(r)
+}
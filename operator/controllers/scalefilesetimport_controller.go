@@ -0,0 +1,428 @@
+/*
+Copyright 2024 IBM Corp.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/connectors"
+	csiv1 "github.com/IBM/ibm-spectrum-scale-csi/operator/api/v1"
+	config "github.com/IBM/ibm-spectrum-scale-csi/operator/controllers/config"
+)
+
+// ScaleFilesetImportReconciler reconciles a ScaleFilesetImport object
+type ScaleFilesetImportReconciler struct {
+	Client client.Client
+	// APIReader reads PVCs and PVs directly from the API server as
+	// the manager cache does not hold PVs.
+	APIReader client.Reader
+	Scheme    *runtime.Scheme
+	Recorder  record.EventRecorder
+}
+
+var filesetImportLog = log.Log.WithName("scalefilesetimport_controller")
+
+const (
+	volumeTypeDependentFileset   = "1"
+	volumeTypeIndependentFileset = "2"
+	rootFilesetID                = 0
+	filesetUnlinkedPath          = "--"
+)
+
+// importTarget is the PV and the PVC to be created for an imported fileset
+type importTarget struct {
+	clusterID  string
+	namespace  string
+	pvcName    string
+	pvName     string
+	accessMode corev1.PersistentVolumeAccessMode
+}
+
+// +kubebuilder:rbac:groups="",resources=persistentvolumes,verbs=get;list;watch;create
+
+// Reconcile adopts an existing fileset as a statically provisioned volume.
+// The fileset is validated and its volume handle is recorded in the status,
+// then it is marked as owned by the CSI driver by setting its comment and a
+// PV with the volume handle of the fileset is created together with a PVC
+// bound to it.
+func (r *ScaleFilesetImportReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := filesetImportLog.WithName("Reconcile").WithValues("ScaleFilesetImport", req.NamespacedName)
+
+	instance := &csiv1.ScaleFilesetImport{}
+	if err := r.Client.Get(ctx, req.NamespacedName, instance); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("ScaleFilesetImport resource not found. Ignoring since object must be deleted.")
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to get ScaleFilesetImport")
+		return ctrl.Result{}, err
+	}
+
+	if instance.Status.Phase == csiv1.FilesetImportSucceeded || instance.Status.Phase == csiv1.FilesetImportFailed {
+		return ctrl.Result{}, nil
+	}
+
+	target := getImportTarget(instance)
+	if instance.Status.VolumeHandle == "" {
+		message, err := r.checkImportTarget(ctx, target)
+		if err != nil {
+			logger.Error(err, "Failed to check the PV and the PVC to be created")
+			return ctrl.Result{}, err
+		}
+		if message != "" {
+			return r.fail(ctx, instance, csiv1.FilesetImportInvalid, message)
+		}
+	}
+
+	connClusterID := target.clusterID
+	if connClusterID == "" {
+		connClusterID = config.Primary
+	}
+	conn, connectorExists := getScaleConnector(connClusterID)
+	if !connectorExists {
+		message := fmt.Sprintf("Waiting for the GUI connection of the cluster with ID %s to be initialized", connClusterID)
+		logger.Info(message)
+		if instance.Status.Phase != csiv1.FilesetImportPending {
+			instance.Status.Phase = csiv1.FilesetImportPending
+			instance.Status.Message = message
+			r.Recorder.Event(instance, corev1.EventTypeNormal, string(csiv1.FilesetImportWaitingForGUI), message)
+			if err := r.Client.Status().Update(ctx, instance); err != nil {
+				logger.Error(err, "Failed to update ScaleFilesetImport status")
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{RequeueAfter: config.FilesetImportRetrySeconds * time.Second}, nil
+	}
+
+	// The volume handle is recorded before the fileset is marked as owned by
+	// the CSI driver, so that a fileset marked by this import is not refused
+	// as owned already when the reconcile is retried
+	if instance.Status.VolumeHandle == "" {
+		volumeHandle, capacity, message, err := checkFileset(ctx, conn, instance, target)
+		if err != nil {
+			logger.Error(err, "Failed to check the fileset")
+			return r.fail(ctx, instance, csiv1.FilesetImportStepFailed,
+				fmt.Sprintf("Failed to import fileset %s of filesystem %s: %v", instance.Spec.Fileset, instance.Spec.Filesystem, err))
+		}
+		if message != "" {
+			return r.fail(ctx, instance, csiv1.FilesetImportInvalid, message)
+		}
+
+		message = fmt.Sprintf("Importing fileset %s of filesystem %s as PV %s and PVC %s/%s",
+			instance.Spec.Fileset, instance.Spec.Filesystem, target.pvName, target.namespace, target.pvcName)
+		instance.Status.Phase = csiv1.FilesetImportRunning
+		instance.Status.Message = message
+		instance.Status.VolumeHandle = volumeHandle
+		instance.Status.Capacity = &capacity
+		r.Recorder.Event(instance, corev1.EventTypeNormal, string(csiv1.FilesetImportStarted), message)
+		if err := r.Client.Status().Update(ctx, instance); err != nil {
+			logger.Error(err, "Failed to update ScaleFilesetImport status")
+			return ctrl.Result{}, err
+		}
+	}
+
+	// The errors are retried, the fileset is marked again until it succeeds
+	message, err := adoptFileset(ctx, conn, instance)
+	if err != nil {
+		logger.Error(err, "Failed to mark the fileset as owned by the CSI driver")
+		return ctrl.Result{}, err
+	}
+	if message != "" {
+		return r.fail(ctx, instance, csiv1.FilesetImportStepFailed, message)
+	}
+
+	message, err = r.createVolume(ctx, instance, target)
+	if err != nil {
+		logger.Error(err, "Failed to create the PV and the PVC")
+		return ctrl.Result{}, err
+	}
+	if message != "" {
+		return r.fail(ctx, instance, csiv1.FilesetImportStepFailed, message)
+	}
+
+	message = fmt.Sprintf("Imported fileset %s of filesystem %s as PVC %s/%s",
+		instance.Spec.Fileset, instance.Spec.Filesystem, target.namespace, target.pvcName)
+	instance.Status.Phase = csiv1.FilesetImportSucceeded
+	instance.Status.Message = message
+	instance.Status.PersistentVolumeName = target.pvName
+	instance.Status.CompletionTime = &metav1.Time{Time: time.Now()}
+	r.Recorder.Event(instance, corev1.EventTypeNormal, string(csiv1.FilesetImportCompleted), message)
+	if err := r.Client.Status().Update(ctx, instance); err != nil {
+		logger.Error(err, "Failed to update ScaleFilesetImport status")
+		return ctrl.Result{}, err
+	}
+
+	logger.Info(message)
+	return ctrl.Result{}, nil
+}
+
+// getImportTarget returns the names of the PV and the PVC of the import
+// with the defaults applied.
+func getImportTarget(instance *csiv1.ScaleFilesetImport) *importTarget {
+	target := &importTarget{
+		clusterID:  instance.Spec.ClusterID,
+		namespace:  instance.Spec.Namespace,
+		pvcName:    instance.Spec.PersistentVolumeClaimName,
+		pvName:     instance.Spec.PersistentVolumeName,
+		accessMode: instance.Spec.AccessMode,
+	}
+	if target.namespace == "" {
+		target.namespace = instance.Namespace
+	}
+	if target.pvName == "" {
+		target.pvName = fmt.Sprintf("%s-%s", target.namespace, target.pvcName)
+	}
+	if target.accessMode == "" {
+		target.accessMode = corev1.ReadWriteMany
+	}
+	return target
+}
+
+// checkImportTarget returns a non empty message if the PV or the PVC of the
+// import can not be created.
+func (r *ScaleFilesetImportReconciler) checkImportTarget(ctx context.Context, target *importTarget) (string, error) {
+	for kind, name := range map[string]string{"PV": target.pvName, "PVC": target.pvcName} {
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fmt.Sprintf("Invalid %s name %s: %s", kind, name, strings.Join(errs, ", ")), nil
+		}
+	}
+	if errs := validation.IsDNS1123Label(target.namespace); len(errs) > 0 {
+		return fmt.Sprintf("Invalid namespace name %s: %s", target.namespace, strings.Join(errs, ", ")), nil
+	}
+
+	pv := &corev1.PersistentVolume{}
+	err := r.APIReader.Get(ctx, types.NamespacedName{Name: target.pvName}, pv)
+	if err == nil {
+		return fmt.Sprintf("PV %s already exists", target.pvName), nil
+	} else if !errors.IsNotFound(err) {
+		return "", err
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	err = r.APIReader.Get(ctx, types.NamespacedName{Namespace: target.namespace, Name: target.pvcName}, pvc)
+	if err == nil {
+		return fmt.Sprintf("PVC %s/%s already exists", target.namespace, target.pvcName), nil
+	} else if !errors.IsNotFound(err) {
+		return "", err
+	}
+	return "", nil
+}
+
+// checkFileset validates the fileset of the import. The volume handle and the
+// capacity of the fileset are returned, or a non empty message if the fileset
+// can not be imported.
+func checkFileset(ctx context.Context, conn connectors.SpectrumScaleConnector, instance *csiv1.ScaleFilesetImport,
+	target *importTarget) (string, resource.Quantity, string, error) {
+	filesystem := instance.Spec.Filesystem
+	fileset := instance.Spec.Fileset
+
+	filesetInfo, err := conn.ListFileset(ctx, filesystem, fileset)
+	if err != nil {
+		return "", resource.Quantity{}, "", fmt.Errorf("failed to get the fileset: %w", err)
+	}
+	if filesetInfo.Config.Id == rootFilesetID {
+		return "", resource.Quantity{}, fmt.Sprintf("The root fileset of filesystem %s can not be imported", filesystem), nil
+	}
+	if filesetInfo.Config.Path == "" || filesetInfo.Config.Path == filesetUnlinkedPath {
+		return "", resource.Quantity{}, fmt.Sprintf("Fileset %s of filesystem %s is not linked", fileset, filesystem), nil
+	}
+	if isCSIFilesetComment(filesetInfo.Config.Comment) {
+		return "", resource.Quantity{}, fmt.Sprintf("Fileset %s of filesystem %s is already owned by the CSI driver", fileset, filesystem), nil
+	}
+
+	quota, err := conn.GetFilesetQuotaDetails(ctx, filesystem, fileset)
+	if err != nil {
+		return "", resource.Quantity{}, "", fmt.Errorf("failed to get the quota of the fileset: %w", err)
+	}
+	// blockLimit is reported in KiB
+	quotaBytes := int64(quota.BlockLimit) * 1024
+	var capacity resource.Quantity
+	switch {
+	case instance.Spec.Size == nil && quotaBytes == 0:
+		return "", resource.Quantity{}, fmt.Sprintf("Fileset %s of filesystem %s has no block quota, size is required", fileset, filesystem), nil
+	case instance.Spec.Size == nil:
+		capacity = *resource.NewQuantity(quotaBytes, resource.BinarySI)
+	case quotaBytes > 0 && instance.Spec.Size.Value() > quotaBytes:
+		return "", resource.Quantity{}, fmt.Sprintf("Size %s is larger than the block quota %s of fileset %s of filesystem %s",
+			instance.Spec.Size.String(), resource.NewQuantity(quotaBytes, resource.BinarySI).String(), fileset, filesystem), nil
+	default:
+		capacity = *instance.Spec.Size
+	}
+
+	clusterID := target.clusterID
+	if clusterID == "" {
+		clusterID, err = conn.GetClusterId(ctx)
+		if err != nil {
+			return "", resource.Quantity{}, "", fmt.Errorf("failed to get the cluster ID: %w", err)
+		}
+	}
+	fsUUID, err := conn.GetFsUid(ctx, filesystem)
+	if err != nil {
+		return "", resource.Quantity{}, "", fmt.Errorf("failed to get the UID of the filesystem: %w", err)
+	}
+
+	volumeType := volumeTypeDependentFileset
+	if filesetInfo.Config.IsInodeSpaceOwner {
+		volumeType = volumeTypeIndependentFileset
+	}
+
+	volumeHandle := fmt.Sprintf("%s;%s;%s;%s;;%s;%s", storageClassClassic, volumeType, clusterID, fsUUID, fileset, filesetInfo.Config.Path)
+	return volumeHandle, capacity, "", nil
+}
+
+// adoptFileset marks the fileset of the import as owned by the CSI driver by
+// setting its comment, along with the time of the import from which the
+// garbage collection of the driver measures the age of the fileset.
+// It is called again until the import completes, a fileset with the comment
+// set already was marked by this import, as its volume handle is recorded.
+// A non empty message is returned if the comment can not be set.
+func adoptFileset(ctx context.Context, conn connectors.SpectrumScaleConnector, instance *csiv1.ScaleFilesetImport) (string, error) {
+	filesystem := instance.Spec.Filesystem
+	fileset := instance.Spec.Fileset
+
+	filesetInfo, err := conn.ListFileset(ctx, filesystem, fileset)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the fileset: %w", err)
+	}
+	if isCSIFilesetComment(filesetInfo.Config.Comment) {
+		return "", nil
+	}
+	comment := connectors.FilesetComment + config.FilesetCommentTimeSep + time.Now().UTC().Format(time.RFC3339)
+	updateOpts := map[string]interface{}{connectors.FilesetComment: comment}
+	if err := conn.UpdateFileset(ctx, filesystem, fileset, updateOpts); err != nil {
+		return "", fmt.Errorf("failed to update the comment of the fileset: %w", err)
+	}
+	// The comment is verified as GUI connectors without fileset comment
+	// support accept the update without setting it.
+	filesetInfo, err = conn.ListFileset(ctx, filesystem, fileset)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the fileset: %w", err)
+	}
+	if !isCSIFilesetComment(filesetInfo.Config.Comment) {
+		return fmt.Sprintf("The comment of fileset %s of filesystem %s is not updated, set the comment to \"%s\" with mmchfileset and recreate the ScaleFilesetImport", fileset, filesystem, comment), nil
+	}
+	return "", nil
+}
+
+// isCSIFilesetComment returns true if a fileset comment is the comment of a
+// fileset created or adopted by the driver, with or without the time.
+func isCSIFilesetComment(comment string) bool {
+	comment = strings.TrimSpace(comment)
+	return comment == connectors.FilesetComment || strings.HasPrefix(comment, connectors.FilesetComment+config.FilesetCommentTimeSep)
+}
+
+// createVolume creates the PV of the imported fileset and the PVC bound to it.
+// The PV is retained on release as the deletion of the volume would delete
+// the fileset. A non empty message is returned if a PV or a PVC of the same
+// name is created by someone else meanwhile.
+func (r *ScaleFilesetImportReconciler) createVolume(ctx context.Context, instance *csiv1.ScaleFilesetImport, target *importTarget) (string, error) {
+	capacity := corev1.ResourceList{corev1.ResourceStorage: *instance.Status.Capacity}
+	storageClassName := instance.Spec.StorageClassName
+
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: target.pvName},
+		Spec: corev1.PersistentVolumeSpec{
+			Capacity:                      capacity,
+			AccessModes:                   []corev1.PersistentVolumeAccessMode{target.accessMode},
+			PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain,
+			StorageClassName:              storageClassName,
+			ClaimRef:                      &corev1.ObjectReference{Namespace: target.namespace, Name: target.pvcName},
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{
+					Driver:       config.DriverName,
+					VolumeHandle: instance.Status.VolumeHandle,
+				},
+			},
+		},
+	}
+	if err := r.Client.Create(ctx, pv); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return "", err
+		}
+		existing := &corev1.PersistentVolume{}
+		if err := r.APIReader.Get(ctx, types.NamespacedName{Name: target.pvName}, existing); err != nil {
+			return "", err
+		}
+		if existing.Spec.CSI == nil || existing.Spec.CSI.VolumeHandle != instance.Status.VolumeHandle {
+			return fmt.Sprintf("PV %s already exists with another volume", target.pvName), nil
+		}
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: target.namespace, Name: target.pvcName},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{target.accessMode},
+			Resources:        corev1.VolumeResourceRequirements{Requests: capacity},
+			StorageClassName: &storageClassName,
+			VolumeName:       target.pvName,
+		},
+	}
+	if err := r.Client.Create(ctx, pvc); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return "", err
+		}
+		existing := &corev1.PersistentVolumeClaim{}
+		if err := r.APIReader.Get(ctx, types.NamespacedName{Namespace: target.namespace, Name: target.pvcName}, existing); err != nil {
+			return "", err
+		}
+		if existing.Spec.VolumeName != target.pvName {
+			return fmt.Sprintf("PVC %s/%s already exists with another volume", target.namespace, target.pvcName), nil
+		}
+	}
+	return "", nil
+}
+
+// fail marks the import failed with the given reason and message.
+func (r *ScaleFilesetImportReconciler) fail(ctx context.Context, instance *csiv1.ScaleFilesetImport,
+	reason csiv1.CSIReason, message string) (ctrl.Result, error) {
+	logger := filesetImportLog.WithName("fail").WithValues("ScaleFilesetImport", instance.Namespace+"/"+instance.Name)
+	logger.Info("ScaleFilesetImport failed", "reason", reason, "message", message)
+
+	instance.Status.Phase = csiv1.FilesetImportFailed
+	instance.Status.Message = message
+	instance.Status.CompletionTime = &metav1.Time{Time: time.Now()}
+	r.Recorder.Event(instance, corev1.EventTypeWarning, string(reason), message)
+	if err := r.Client.Status().Update(ctx, instance); err != nil {
+		logger.Error(err, "Failed to update ScaleFilesetImport status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ScaleFilesetImportReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&csiv1.ScaleFilesetImport{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -0,0 +1,562 @@
+/*
+Copyright 2024 IBM Corp.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	csiv1 "github.com/IBM/ibm-spectrum-scale-csi/operator/api/v1"
+	config "github.com/IBM/ibm-spectrum-scale-csi/operator/controllers/config"
+	"github.com/IBM/ibm-spectrum-scale-csi/operator/controllers/util/cron"
+)
+
+// ScaleSnapshotScheduleReconciler reconciles a ScaleSnapshotSchedule object
+type ScaleSnapshotScheduleReconciler struct {
+	Client client.Client
+	// APIReader reads PVCs, PVs and VolumeSnapshots directly from the API server
+	// as the manager cache is restricted to the operator namespace.
+	APIReader client.Reader
+	Scheme    *runtime.Scheme
+	Recorder  record.EventRecorder
+}
+
+var snapScheduleLog = log.Log.WithName("scalesnapshotschedule_controller")
+
+const snapshotScheduleConditionType = "Ready"
+
+// Index of consistency group name in a volume ID of advanced storageClass
+// <storageclass_type>;<type_of_volume>;<cluster_id>;<filesystem_uuid>;<consistency_group>;<fileset_name>;<path>
+const (
+	volIDMembersCount     = 7
+	volIDSCTypeIndex      = 0
+	volIDCGIndex          = 4
+	storageClassAdvanced  = "1"
+	snapshotNameTimestamp = "20060102150405"
+	// Number of next runs of a schedule in which the shortest interval is searched
+	scheduleIntervalRuns = 10
+)
+
+var volumeSnapshotGVK = schema.GroupVersionKind{
+	Group:   config.SnapshotAPIGroup,
+	Version: config.SnapshotAPIVersion,
+	Kind:    config.VolumeSnapshotKind,
+}
+
+// scheduledVolume is a PVC selected by a ScaleSnapshotSchedule
+type scheduledVolume struct {
+	pvc              corev1.PersistentVolumeClaim
+	consistencyGroup string
+}
+
+// scheduledSnapshot is a VolumeSnapshot taken by a ScaleSnapshotSchedule
+type scheduledSnapshot struct {
+	obj          *unstructured.Unstructured
+	pvcName      string
+	created      time.Time
+	readyToUse   bool
+	errorMessage string
+}
+
+// +kubebuilder:rbac:groups="",resources=persistentvolumes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=create;delete;get;list;watch
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshotclasses,verbs=get
+
+// Reconcile takes snapshots of the PVCs selected by a ScaleSnapshotSchedule
+// when the schedule is due, enforces the retention policy on the snapshots
+// taken by the schedule and reports the per PVC state in the status.
+func (r *ScaleSnapshotScheduleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := snapScheduleLog.WithName("Reconcile").WithValues("ScaleSnapshotSchedule", req.NamespacedName)
+
+	instance := &csiv1.ScaleSnapshotSchedule{}
+	if err := r.Client.Get(ctx, req.NamespacedName, instance); err != nil {
+		if errors.IsNotFound(err) {
+			// Snapshots taken by a deleted schedule are retained.
+			logger.Info("ScaleSnapshotSchedule resource not found. Ignoring since object must be deleted.")
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to get ScaleSnapshotSchedule")
+		return ctrl.Result{}, err
+	}
+
+	schedule, err := cron.Parse(instance.Spec.Schedule)
+	if err == nil {
+		err = validateSnapshotScheduleName(instance.Name)
+	}
+	if err != nil {
+		message := fmt.Sprintf("Invalid ScaleSnapshotSchedule %s: %v", instance.Name, err)
+		logger.Error(err, "Invalid ScaleSnapshotSchedule, not taking snapshots")
+		r.setCondition(instance, metav1.ConditionFalse, csiv1.InvalidSchedule, message)
+		r.Recorder.Event(instance, corev1.EventTypeWarning, string(csiv1.InvalidSchedule), message)
+		return ctrl.Result{}, r.Client.Status().Update(ctx, instance)
+	}
+
+	volumes, err := r.getScheduledVolumes(ctx, instance)
+	if err != nil {
+		logger.Error(err, "Failed to get the PVCs selected by the schedule")
+		return ctrl.Result{}, err
+	}
+
+	now := time.Now()
+	if err := r.validateSnapWindow(ctx, instance, schedule, volumes, now); err != nil {
+		message := fmt.Sprintf("Invalid VolumeSnapshotClass %s for ScaleSnapshotSchedule %s: %v", instance.Spec.VolumeSnapshotClassName, instance.Name, err)
+		logger.Error(err, "Invalid VolumeSnapshotClass, not taking snapshots")
+		r.setCondition(instance, metav1.ConditionFalse, csiv1.InvalidSnapWindow, message)
+		r.Recorder.Event(instance, corev1.EventTypeWarning, string(csiv1.InvalidSnapWindow), message)
+		// The VolumeSnapshotClass is not watched, it is checked again at the next run
+		var requeueAfter time.Duration
+		if next := schedule.Next(now); !next.IsZero() {
+			requeueAfter = time.Until(next)
+		}
+		return ctrl.Result{RequeueAfter: requeueAfter}, r.Client.Status().Update(ctx, instance)
+	}
+
+	createErrors := map[string]error{}
+	lastScheduleTime := instance.CreationTimestamp.Time
+	if instance.Status.LastScheduleTime != nil {
+		lastScheduleTime = instance.Status.LastScheduleTime.Time
+	}
+	if !instance.Spec.Suspend && !schedule.Next(lastScheduleTime).After(now) {
+		// Only one round of snapshots is taken for any number of missed schedules.
+		createErrors = r.takeSnapshots(ctx, instance, volumes, now)
+		instance.Status.LastScheduleTime = &metav1.Time{Time: now}
+	}
+
+	snapshots, err := r.listScheduledSnapshots(ctx, instance)
+	if err != nil {
+		logger.Error(err, "Failed to list the VolumeSnapshots taken by the schedule")
+		return ctrl.Result{}, err
+	}
+	snapshots = r.enforceRetention(ctx, instance, snapshots)
+	pending := r.updateVolumeStatus(instance, volumes, snapshots, createErrors, now)
+
+	if len(volumes) == 0 {
+		r.setCondition(instance, metav1.ConditionFalse, csiv1.SnapshotScheduleVolumesNotFound,
+			"No bound PVCs of the IBM Storage Scale CSI driver are selected by the schedule")
+	} else if len(createErrors) > 0 {
+		r.setCondition(instance, metav1.ConditionFalse, csiv1.SnapshotCreateFailed,
+			fmt.Sprintf("Failed to create VolumeSnapshots for %d of %d PVCs", len(createErrors), len(volumes)))
+	} else {
+		r.setCondition(instance, metav1.ConditionTrue, csiv1.SnapshotScheduleReady,
+			fmt.Sprintf("Snapshots are scheduled for %d PVCs", len(volumes)))
+	}
+
+	var requeueAfter time.Duration
+	next := schedule.Next(now)
+	if next.IsZero() {
+		instance.Status.NextScheduleTime = nil
+	} else {
+		instance.Status.NextScheduleTime = &metav1.Time{Time: next}
+		requeueAfter = time.Until(next)
+	}
+	if pending && (requeueAfter == 0 || requeueAfter > config.SnapshotScheduleRetrySeconds*time.Second) {
+		requeueAfter = config.SnapshotScheduleRetrySeconds * time.Second
+	}
+
+	if err := r.Client.Status().Update(ctx, instance); err != nil {
+		logger.Error(err, "Failed to update ScaleSnapshotSchedule status")
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("Reconciled ScaleSnapshotSchedule", "volumes", len(volumes), "requeueAfter", requeueAfter)
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// validateSnapshotScheduleName checks that the schedule name can be
+// used as the label value on the VolumeSnapshots taken by it.
+func validateSnapshotScheduleName(name string) error {
+	if errs := validation.IsValidLabelValue(name); len(errs) > 0 {
+		return fmt.Errorf("name must be a valid label value: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// getScheduledVolumes returns the bound PVCs of the driver selected by the schedule,
+// sorted so that the PVCs of a consistency group are next to each other.
+func (r *ScaleSnapshotScheduleReconciler) getScheduledVolumes(ctx context.Context, instance *csiv1.ScaleSnapshotSchedule) ([]scheduledVolume, error) {
+	logger := snapScheduleLog.WithName("getScheduledVolumes")
+
+	namespaces := instance.Spec.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{instance.Namespace}
+	}
+
+	selector := labels.Everything()
+	if instance.Spec.PVCSelector != nil {
+		var err error
+		selector, err = metav1.LabelSelectorAsSelector(instance.Spec.PVCSelector)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	volumes := []scheduledVolume{}
+	for _, namespace := range namespaces {
+		pvcList := &corev1.PersistentVolumeClaimList{}
+		if err := r.APIReader.List(ctx, pvcList, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return nil, err
+		}
+
+		for _, pvc := range pvcList.Items {
+			if pvc.DeletionTimestamp != nil || pvc.Status.Phase != corev1.ClaimBound || pvc.Spec.VolumeName == "" {
+				continue
+			}
+
+			pv := &corev1.PersistentVolume{}
+			if err := r.APIReader.Get(ctx, types.NamespacedName{Name: pvc.Spec.VolumeName}, pv); err != nil {
+				if errors.IsNotFound(err) {
+					logger.Info("PV of the PVC is not found, skipping", "pvc", pvc.Namespace+"/"+pvc.Name, "pv", pvc.Spec.VolumeName)
+					continue
+				}
+				return nil, err
+			}
+			if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != config.DriverName {
+				continue
+			}
+
+			volumes = append(volumes, scheduledVolume{
+				pvc:              pvc,
+				consistencyGroup: getConsistencyGroup(pv.Spec.CSI.VolumeHandle),
+			})
+		}
+	}
+
+	sort.SliceStable(volumes, func(i, j int) bool {
+		if volumes[i].consistencyGroup != volumes[j].consistencyGroup {
+			return volumes[i].consistencyGroup < volumes[j].consistencyGroup
+		}
+		if volumes[i].pvc.Namespace != volumes[j].pvc.Namespace {
+			return volumes[i].pvc.Namespace < volumes[j].pvc.Namespace
+		}
+		return volumes[i].pvc.Name < volumes[j].pvc.Name
+	})
+	return volumes, nil
+}
+
+// getConsistencyGroup returns the consistency group from the volume ID
+// of a volume of advanced storageClass, empty string otherwise.
+func getConsistencyGroup(volumeHandle string) string {
+	members := strings.Split(volumeHandle, ";")
+	if len(members) != volIDMembersCount || members[volIDSCTypeIndex] != storageClassAdvanced {
+		return ""
+	}
+	return members[volIDCGIndex]
+}
+
+// validateSnapWindow checks that the snapWindow of the VolumeSnapshotClass
+// lets the PVCs of a consistency group share one snapshot and is shorter than
+// the interval between the runs of the schedule, if the schedule selects PVCs
+// of a consistency group. A snapshot of a consistency group fileset taken
+// within the snapWindow of the previous one fails.
+func (r *ScaleSnapshotScheduleReconciler) validateSnapWindow(ctx context.Context, instance *csiv1.ScaleSnapshotSchedule,
+	schedule *cron.Schedule, volumes []scheduledVolume, now time.Time) error {
+	hasConsistencyGroup := false
+	for _, volume := range volumes {
+		if volume.consistencyGroup != "" {
+			hasConsistencyGroup = true
+			break
+		}
+	}
+	if !hasConsistencyGroup {
+		return nil
+	}
+
+	snapshotClass := &unstructured.Unstructured{}
+	snapshotClass.SetGroupVersionKind(volumeSnapshotGVK.GroupVersion().WithKind(config.VolumeSnapshotClassKind))
+	if err := r.APIReader.Get(ctx, client.ObjectKey{Name: instance.Spec.VolumeSnapshotClassName}, snapshotClass); err != nil {
+		return err
+	}
+	snapWindowMinutes := config.DefaultSnapWindowMinutes
+	if snapWindow, found, _ := unstructured.NestedString(snapshotClass.Object, "parameters", config.SnapWindowParameter); found {
+		minutes, err := strconv.Atoi(snapWindow)
+		if err != nil || minutes <= 0 {
+			return fmt.Errorf("%s must be a positive number of minutes for the PVCs of a consistency group to share one snapshot, got %q",
+				config.SnapWindowParameter, snapWindow)
+		}
+		snapWindowMinutes = minutes
+	}
+	snapWindow := time.Duration(snapWindowMinutes) * time.Minute
+	if interval := getMinScheduleInterval(schedule, now); interval > 0 && snapWindow >= interval {
+		return fmt.Errorf("%s of %v must be shorter than the interval of %v between the runs of the schedule",
+			config.SnapWindowParameter, snapWindow, interval)
+	}
+	return nil
+}
+
+// getMinScheduleInterval returns the shortest interval between the next runs
+// of a schedule, 0 if it does not run twice.
+func getMinScheduleInterval(schedule *cron.Schedule, now time.Time) time.Duration {
+	var interval time.Duration
+	prev := schedule.Next(now)
+	for i := 0; i < scheduleIntervalRuns && !prev.IsZero(); i++ {
+		next := schedule.Next(prev)
+		if next.IsZero() {
+			break
+		}
+		if d := next.Sub(prev); interval == 0 || d < interval {
+			interval = d
+		}
+		prev = next
+	}
+	return interval
+}
+
+// takeSnapshots creates a VolumeSnapshot for every scheduled volume. The
+// snapshots of all volumes of a consistency group are created back to back,
+// so that the driver takes a single snapshot of the consistency group fileset
+// within the snapWindow and uses it for all of them.
+// It returns the creation errors keyed by namespace/name of the PVC.
+func (r *ScaleSnapshotScheduleReconciler) takeSnapshots(ctx context.Context, instance *csiv1.ScaleSnapshotSchedule,
+	volumes []scheduledVolume, now time.Time) map[string]error {
+	logger := snapScheduleLog.WithName("takeSnapshots")
+
+	createErrors := map[string]error{}
+	for i, volume := range volumes {
+		if volume.consistencyGroup != "" && (i == 0 || volumes[i-1].consistencyGroup != volume.consistencyGroup) {
+			logger.Info("Taking snapshots of consistency group", "consistencyGroup", volume.consistencyGroup)
+		}
+
+		snapshot := &unstructured.Unstructured{}
+		snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+		snapshot.SetName(getScheduledSnapshotName(instance.Name, volume.pvc.Name, now))
+		snapshot.SetNamespace(volume.pvc.Namespace)
+		snapshot.SetLabels(map[string]string{
+			config.LabelSnapshotSchedule:          instance.Name,
+			config.LabelSnapshotScheduleNamespace: instance.Namespace,
+		})
+		_ = unstructured.SetNestedField(snapshot.Object, instance.Spec.VolumeSnapshotClassName, "spec", "volumeSnapshotClassName")
+		_ = unstructured.SetNestedField(snapshot.Object, volume.pvc.Name, "spec", "source", "persistentVolumeClaimName")
+
+		if err := r.Client.Create(ctx, snapshot); err != nil && !errors.IsAlreadyExists(err) {
+			logger.Error(err, "Failed to create VolumeSnapshot", "pvc", volume.pvc.Namespace+"/"+volume.pvc.Name)
+			createErrors[volume.pvc.Namespace+"/"+volume.pvc.Name] = err
+			r.Recorder.Event(instance, corev1.EventTypeWarning, string(csiv1.SnapshotCreateFailed),
+				fmt.Sprintf("Failed to create VolumeSnapshot %s/%s: %v", volume.pvc.Namespace, snapshot.GetName(), err))
+			continue
+		}
+		logger.Info("Created VolumeSnapshot", "namespace", volume.pvc.Namespace, "name", snapshot.GetName())
+	}
+	return createErrors
+}
+
+// getScheduledSnapshotName returns the VolumeSnapshot name for a PVC,
+// <pvc name>-<schedule name>-<UTC timestamp>.
+func getScheduledSnapshotName(scheduleName string, pvcName string, t time.Time) string {
+	suffix := "-" + t.UTC().Format(snapshotNameTimestamp)
+	name := pvcName + "-" + scheduleName
+	if maxLen := validation.DNS1123SubdomainMaxLength - len(suffix); len(name) > maxLen {
+		name = strings.TrimRight(name[:maxLen], "-.")
+	}
+	return name + suffix
+}
+
+// listScheduledSnapshots returns the VolumeSnapshots taken by the schedule in all namespaces.
+func (r *ScaleSnapshotScheduleReconciler) listScheduledSnapshots(ctx context.Context, instance *csiv1.ScaleSnapshotSchedule) ([]scheduledSnapshot, error) {
+	snapshotList := &unstructured.UnstructuredList{}
+	snapshotList.SetGroupVersionKind(volumeSnapshotGVK.GroupVersion().WithKind(config.VolumeSnapshotKind + "List"))
+	if err := r.APIReader.List(ctx, snapshotList, client.MatchingLabels{
+		config.LabelSnapshotSchedule:          instance.Name,
+		config.LabelSnapshotScheduleNamespace: instance.Namespace,
+	}); err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]scheduledSnapshot, 0, len(snapshotList.Items))
+	for i := range snapshotList.Items {
+		obj := &snapshotList.Items[i]
+		if obj.GetDeletionTimestamp() != nil {
+			continue
+		}
+		pvcName, _, _ := unstructured.NestedString(obj.Object, "spec", "source", "persistentVolumeClaimName")
+		readyToUse, _, _ := unstructured.NestedBool(obj.Object, "status", "readyToUse")
+		errorMessage, _, _ := unstructured.NestedString(obj.Object, "status", "error", "message")
+		snapshots = append(snapshots, scheduledSnapshot{
+			obj:          obj,
+			pvcName:      pvcName,
+			created:      obj.GetCreationTimestamp().Time,
+			readyToUse:   readyToUse,
+			errorMessage: errorMessage,
+		})
+	}
+
+	// newest first
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].created.After(snapshots[j].created)
+	})
+	return snapshots, nil
+}
+
+// enforceRetention deletes the VolumeSnapshots which are not retained by the
+// retention policy and returns the remaining ones. Only snapshots which are
+// ready to use count for the policy, failed snapshots are deleted once a newer
+// snapshot of the PVC is ready and snapshots in progress are never deleted.
+func (r *ScaleSnapshotScheduleReconciler) enforceRetention(ctx context.Context, instance *csiv1.ScaleSnapshotSchedule,
+	snapshots []scheduledSnapshot) []scheduledSnapshot {
+	logger := snapScheduleLog.WithName("enforceRetention")
+
+	retention := instance.Spec.Retention
+	if retention.KeepLast == 0 && retention.KeepDaily == 0 {
+		return snapshots
+	}
+
+	keepLast := map[string]int32{}
+	keptDays := map[string]map[string]bool{}
+	newestReady := map[string]time.Time{}
+	retained := []scheduledSnapshot{}
+	for _, snapshot := range snapshots {
+		pvc := snapshot.obj.GetNamespace() + "/" + snapshot.pvcName
+		keep := false
+		switch {
+		case snapshot.readyToUse:
+			if _, ok := newestReady[pvc]; !ok {
+				newestReady[pvc] = snapshot.created
+			}
+			if keepLast[pvc] < retention.KeepLast {
+				keepLast[pvc]++
+				keep = true
+			}
+			day := snapshot.created.Local().Format("2006-01-02")
+			if keptDays[pvc] == nil {
+				keptDays[pvc] = map[string]bool{}
+			}
+			if !keptDays[pvc][day] && int32(len(keptDays[pvc])) < retention.KeepDaily {
+				keptDays[pvc][day] = true
+				keep = true
+			}
+		case snapshot.errorMessage != "":
+			newest, ok := newestReady[pvc]
+			keep = !ok || snapshot.created.After(newest)
+		default:
+			keep = true
+		}
+
+		if keep {
+			retained = append(retained, snapshot)
+			continue
+		}
+
+		if err := r.Client.Delete(ctx, snapshot.obj); err != nil && !errors.IsNotFound(err) {
+			logger.Error(err, "Failed to delete VolumeSnapshot", "namespace", snapshot.obj.GetNamespace(), "name", snapshot.obj.GetName())
+			r.Recorder.Event(instance, corev1.EventTypeWarning, string(csiv1.SnapshotDeleteFailed),
+				fmt.Sprintf("Failed to delete VolumeSnapshot %s/%s: %v", snapshot.obj.GetNamespace(), snapshot.obj.GetName(), err))
+			retained = append(retained, snapshot)
+			continue
+		}
+		logger.Info("Deleted VolumeSnapshot as per retention policy", "namespace", snapshot.obj.GetNamespace(), "name", snapshot.obj.GetName())
+		r.Recorder.Event(instance, corev1.EventTypeNormal, string(csiv1.SnapshotRetentionEnforced),
+			fmt.Sprintf("Deleted VolumeSnapshot %s/%s as per retention policy", snapshot.obj.GetNamespace(), snapshot.obj.GetName()))
+	}
+	return retained
+}
+
+// updateVolumeStatus sets the status of every scheduled volume from its
+// VolumeSnapshots and the creation errors of this reconcile. It returns
+// true if any VolumeSnapshot is neither ready to use nor failed.
+func (r *ScaleSnapshotScheduleReconciler) updateVolumeStatus(instance *csiv1.ScaleSnapshotSchedule, volumes []scheduledVolume,
+	snapshots []scheduledSnapshot, createErrors map[string]error, now time.Time) bool {
+
+	previous := map[string]csiv1.SnapshotVolumeStatus{}
+	for _, volumeStatus := range instance.Status.Volumes {
+		previous[volumeStatus.Namespace+"/"+volumeStatus.Name] = volumeStatus
+	}
+
+	snapshotsByPVC := map[string][]scheduledSnapshot{}
+	for _, snapshot := range snapshots {
+		pvc := snapshot.obj.GetNamespace() + "/" + snapshot.pvcName
+		snapshotsByPVC[pvc] = append(snapshotsByPVC[pvc], snapshot)
+	}
+
+	pending := false
+	volumeStatuses := make([]csiv1.SnapshotVolumeStatus, 0, len(volumes))
+	for _, volume := range volumes {
+		key := volume.pvc.Namespace + "/" + volume.pvc.Name
+		volumeStatus := csiv1.SnapshotVolumeStatus{
+			Namespace:          volume.pvc.Namespace,
+			Name:               volume.pvc.Name,
+			ConsistencyGroup:   volume.consistencyGroup,
+			LastFailureTime:    previous[key].LastFailureTime,
+			LastFailureMessage: previous[key].LastFailureMessage,
+			SnapshotCount:      int32(len(snapshotsByPVC[key])),
+		}
+
+		// snapshots are sorted newest first
+		for _, snapshot := range snapshotsByPVC[key] {
+			switch {
+			case snapshot.readyToUse:
+				if volumeStatus.LastSuccessTime == nil {
+					volumeStatus.LastSnapshotName = snapshot.obj.GetName()
+					volumeStatus.LastSuccessTime = &metav1.Time{Time: snapshot.created}
+				}
+			case snapshot.errorMessage != "":
+				if volumeStatus.LastFailureTime == nil || snapshot.created.After(volumeStatus.LastFailureTime.Time) {
+					volumeStatus.LastFailureTime = &metav1.Time{Time: snapshot.created}
+					volumeStatus.LastFailureMessage = fmt.Sprintf("VolumeSnapshot %s failed: %s", snapshot.obj.GetName(), snapshot.errorMessage)
+				}
+			default:
+				pending = true
+			}
+		}
+
+		if err, ok := createErrors[key]; ok {
+			volumeStatus.LastFailureTime = &metav1.Time{Time: now}
+			volumeStatus.LastFailureMessage = fmt.Sprintf("Failed to create VolumeSnapshot: %v", err)
+		}
+		volumeStatuses = append(volumeStatuses, volumeStatus)
+	}
+
+	instance.Status.Volumes = volumeStatuses
+	return pending
+}
+
+func (r *ScaleSnapshotScheduleReconciler) setCondition(instance *csiv1.ScaleSnapshotSchedule,
+	status metav1.ConditionStatus, reason csiv1.CSIReason, message string) {
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               snapshotScheduleConditionType,
+		Status:             status,
+		Reason:             string(reason),
+		Message:            message,
+		ObservedGeneration: instance.Generation,
+	})
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ScaleSnapshotScheduleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&csiv1.ScaleSnapshotSchedule{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -0,0 +1,454 @@
+/*
+Copyright 2024 IBM Corp.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/connectors"
+	csiv1 "github.com/IBM/ibm-spectrum-scale-csi/operator/api/v1"
+	config "github.com/IBM/ibm-spectrum-scale-csi/operator/controllers/config"
+)
+
+// ScaleVolumeReplicationReconciler reconciles a ScaleVolumeReplication object
+type ScaleVolumeReplicationReconciler struct {
+	Client client.Client
+	// APIReader reads PVCs and PVs directly from the API server as the
+	// manager cache is restricted to the operator namespace.
+	APIReader client.Reader
+	Scheme    *runtime.Scheme
+	Recorder  record.EventRecorder
+}
+
+var volumeReplicationLog = log.Log.WithName("scalevolumereplication_controller")
+
+// replicationActionsRunning holds the ScaleVolumeReplications whose state
+// change is running
+var replicationActionsRunning sync.Map
+
+// Volume attributes of a replicated volume, set from its storageClass
+const (
+	volAttrVolBackendFs            = "volBackendFs"
+	volAttrReplicationClusterID    = "replicationClusterId"
+	volAttrReplicationVolBackendFs = "replicationVolBackendFs"
+)
+
+// afmRPOSnapPrefix is the prefix of the RPO snapshots of an AFM-DR primary fileset
+const afmRPOSnapPrefix = "psnap"
+
+// replicationTarget is the replicated volume of a ScaleVolumeReplication
+type replicationTarget struct {
+	pvc           *corev1.PersistentVolumeClaim
+	clusterID     string
+	fsUUID        string
+	filesetName   string
+	peerClusterID string
+	// peerFilesystem is the filesystem of the secondary fileset on the peer
+	// cluster, empty if it is the filesystem of the volume
+	peerFilesystem string
+}
+
+// Reconcile changes the AFM-DR replication state of a replicated volume to
+// the replicationState of the ScaleVolumeReplication, following the
+// VolumeReplication model of csi-addons: primary promotes the volume,
+// secondary demotes it by failing over to the peer cluster, and resync copies
+// the changes made on the peer back to the volume. The state change runs in
+// the background once per generation of the spec and is retried until it
+// succeeds. The replication lag of a primary volume is then polled every
+// VolumeReplicationPollSeconds.
+func (r *ScaleVolumeReplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := volumeReplicationLog.WithName("Reconcile").WithValues("ScaleVolumeReplication", req.NamespacedName)
+
+	instance := &csiv1.ScaleVolumeReplication{}
+	if err := r.Client.Get(ctx, req.NamespacedName, instance); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("ScaleVolumeReplication resource not found. Ignoring since object must be deleted.")
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to get ScaleVolumeReplication")
+		return ctrl.Result{}, err
+	}
+	if !instance.GetDeletionTimestamp().IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	target, message, err := r.getReplicationTarget(ctx, instance)
+	if err != nil {
+		logger.Error(err, "Failed to get the replicated volume")
+		return ctrl.Result{}, err
+	}
+	if message != "" {
+		return r.wait(ctx, instance, target, corev1.EventTypeWarning, csiv1.VolumeReplicationInvalid, message)
+	}
+
+	conn, connectorExists := getScaleConnector(target.clusterID)
+	peerConn, peerConnectorExists := getScaleConnector(target.peerClusterID)
+	if !connectorExists || !peerConnectorExists {
+		message := fmt.Sprintf("Waiting for the GUI connections of the clusters with ID %s and %s to be initialized", target.clusterID, target.peerClusterID)
+		logger.Info(message)
+		return r.wait(ctx, instance, target, corev1.EventTypeNormal, csiv1.VolumeReplicationWaitingForGUI, message)
+	}
+
+	if instance.Status.ObservedGeneration != instance.Generation {
+		key := client.ObjectKeyFromObject(instance)
+		if _, running := replicationActionsRunning.LoadOrStore(key, struct{}{}); running {
+			return ctrl.Result{RequeueAfter: config.VolumeReplicationRetrySeconds * time.Second}, nil
+		}
+		message := fmt.Sprintf("Changing the replication state of PVC %s/%s to %s", target.pvc.Namespace, target.pvc.Name, instance.Spec.ReplicationState)
+		logger.Info(message)
+		r.event(instance, target, corev1.EventTypeNormal, csiv1.VolumeReplicationStarted, message)
+		go func(instance *csiv1.ScaleVolumeReplication) {
+			defer replicationActionsRunning.Delete(key)
+			r.changeReplicationState(context.Background(), instance, target, conn, peerConn)
+		}(instance.DeepCopy())
+		return ctrl.Result{RequeueAfter: config.VolumeReplicationRetrySeconds * time.Second}, nil
+	}
+
+	if err := r.pollReplicationLag(ctx, instance, target, conn); err != nil {
+		logger.Error(err, "Failed to get the replication lag of the volume")
+	}
+	return ctrl.Result{RequeueAfter: config.VolumeReplicationPollSeconds * time.Second}, nil
+}
+
+// getReplicationTarget returns the replicated volume of the ScaleVolumeReplication.
+// A non empty message is returned if the PVC is not a replicated volume.
+func (r *ScaleVolumeReplicationReconciler) getReplicationTarget(ctx context.Context, instance *csiv1.ScaleVolumeReplication) (*replicationTarget, string, error) {
+	namespace := instance.Spec.Namespace
+	if namespace == "" {
+		namespace = instance.Namespace
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: instance.Spec.PersistentVolumeClaimName}, pvc); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, fmt.Sprintf("PVC %s/%s is not found", namespace, instance.Spec.PersistentVolumeClaimName), nil
+		}
+		return nil, "", err
+	}
+	target := &replicationTarget{pvc: pvc}
+	if pvc.Status.Phase != corev1.ClaimBound || pvc.Spec.VolumeName == "" {
+		return target, fmt.Sprintf("PVC %s/%s is not bound", namespace, pvc.Name), nil
+	}
+
+	pv := &corev1.PersistentVolume{}
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Name: pvc.Spec.VolumeName}, pv); err != nil {
+		if errors.IsNotFound(err) {
+			return target, fmt.Sprintf("PV %s of PVC %s/%s is not found", pvc.Spec.VolumeName, namespace, pvc.Name), nil
+		}
+		return nil, "", err
+	}
+	if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != config.DriverName {
+		return target, fmt.Sprintf("PVC %s/%s is not a volume of the IBM Storage Scale CSI driver", namespace, pvc.Name), nil
+	}
+
+	volMembers := strings.Split(pv.Spec.CSI.VolumeHandle, ";")
+	target.peerClusterID = pv.Spec.CSI.VolumeAttributes[volAttrReplicationClusterID]
+	if len(volMembers) != volIDMembersCount || volMembers[volIDSCTypeIndex] != storageClassClassic ||
+		volMembers[volIDFilesetIndex] == "" || target.peerClusterID == "" {
+		return target, fmt.Sprintf("PVC %s/%s is not a replicated volume, its storageClass has no %s", namespace, pvc.Name, volAttrReplicationClusterID), nil
+	}
+	target.clusterID = volMembers[volIDClusterIndex]
+	target.fsUUID = volMembers[volIDFsUUIDIndex]
+	target.filesetName = volMembers[volIDFilesetIndex]
+	target.peerFilesystem = pv.Spec.CSI.VolumeAttributes[volAttrReplicationVolBackendFs]
+	if target.peerFilesystem == "" {
+		target.peerFilesystem = pv.Spec.CSI.VolumeAttributes[volAttrVolBackendFs]
+	}
+	return target, "", nil
+}
+
+// changeReplicationState changes the replication state of the volume and
+// records the outcome in the status. The generation of the spec is recorded
+// as observed once the state is reached, so that a failed change is retried
+// by the next reconcile.
+func (r *ScaleVolumeReplicationReconciler) changeReplicationState(ctx context.Context, instance *csiv1.ScaleVolumeReplication,
+	target *replicationTarget, conn connectors.SpectrumScaleConnector, peerConn connectors.SpectrumScaleConnector) {
+	logger := volumeReplicationLog.WithName("changeReplicationState").WithValues("ScaleVolumeReplication", instance.Namespace+"/"+instance.Name)
+
+	state, err := setReplicationState(ctx, instance.Spec.ReplicationState, target, conn, peerConn)
+	if err != nil {
+		message := fmt.Sprintf("Failed to change the replication state of PVC %s/%s to %s, it is retried: %v",
+			target.pvc.Namespace, target.pvc.Name, instance.Spec.ReplicationState, err)
+		logger.Error(err, "Failed to change the replication state")
+		r.event(instance, target, corev1.EventTypeWarning, csiv1.VolumeReplicationFailed, message)
+		r.updateStatus(ctx, instance, func(status *csiv1.ScaleVolumeReplicationStatus) {
+			status.Message = message
+			meta.SetStatusCondition(&status.Conditions, metav1.Condition{Type: csiv1.ReplicationConditionCompleted,
+				Status: metav1.ConditionFalse, Reason: string(csiv1.VolumeReplicationFailed), Message: message})
+		})
+		return
+	}
+
+	message := fmt.Sprintf("PVC %s/%s is %s", target.pvc.Namespace, target.pvc.Name, state)
+	logger.Info(message)
+	r.event(instance, target, corev1.EventTypeNormal, csiv1.VolumeReplicationCompleted, message)
+	generation := instance.Generation
+	r.updateStatus(ctx, instance, func(status *csiv1.ScaleVolumeReplicationStatus) {
+		status.State = state
+		status.Message = message
+		status.ObservedGeneration = generation
+		if state != csiv1.ReplicationPrimary {
+			// The volume is not replicated to the peer while the peer is
+			// the acting primary
+			status.LastSyncTime = nil
+			status.ReplicationLag = nil
+			meta.RemoveStatusCondition(&status.Conditions, csiv1.ReplicationConditionDegraded)
+		}
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{Type: csiv1.ReplicationConditionCompleted,
+			Status: metav1.ConditionTrue, Reason: string(csiv1.VolumeReplicationCompleted), Message: message})
+	})
+}
+
+// setReplicationState runs the AFM-DR actions bringing the volume to the
+// requested state and returns the reached state. The AFM modes of the
+// filesets are checked first, so that an action interrupted by a restart of
+// the operator is resumed.
+func setReplicationState(ctx context.Context, requested csiv1.ReplicationState, target *replicationTarget,
+	conn connectors.SpectrumScaleConnector, peerConn connectors.SpectrumScaleConnector) (csiv1.ReplicationStatus, error) {
+	afmConn, err := newAFMConnector(conn)
+	if err != nil {
+		return "", err
+	}
+	peerAFMConn, err := newAFMConnector(peerConn)
+	if err != nil {
+		return "", err
+	}
+
+	// The volume cluster is not needed to fail over, it may be down
+	filesystemName := target.peerFilesystem
+	if requested != csiv1.ReplicationStateSecondary || filesystemName == "" {
+		filesystemName, err = conn.GetFilesystemName(ctx, target.fsUUID)
+		if err != nil {
+			return "", err
+		}
+	}
+	peerFilesystem := target.peerFilesystem
+	if peerFilesystem == "" {
+		peerFilesystem = filesystemName
+	}
+
+	peerFileset, err := peerConn.ListFileset(ctx, peerFilesystem, target.filesetName)
+	if err != nil {
+		return "", err
+	}
+	peerAFMMode := peerFileset.AFM.AFMMode
+	if peerAFMMode != afmModePrimary && peerAFMMode != afmModeSecondary {
+		return "", fmt.Errorf("fileset %s in filesystem %s of cluster %s is not an AFM-DR fileset", target.filesetName, peerFilesystem, target.peerClusterID)
+	}
+
+	if requested == csiv1.ReplicationStateSecondary {
+		if peerAFMMode == afmModeSecondary {
+			if err := peerAFMConn.FailoverToSecondary(ctx, peerFilesystem, target.filesetName); err != nil {
+				return "", err
+			}
+		}
+		return csiv1.ReplicationSecondary, nil
+	}
+
+	fileset, err := conn.ListFileset(ctx, filesystemName, target.filesetName)
+	if err != nil {
+		return "", err
+	}
+	if fileset.AFM.AFMMode != afmModePrimary {
+		return "", fmt.Errorf("fileset %s in filesystem %s of cluster %s is not an AFM-DR primary", target.filesetName, filesystemName, target.clusterID)
+	}
+	if peerAFMMode == afmModeSecondary {
+		// Not failed over, the volume is the primary already
+		if requested == csiv1.ReplicationStateResync {
+			return "", fmt.Errorf("the volume is not failed over to cluster %s, there is nothing to resync", target.peerClusterID)
+		}
+		return csiv1.ReplicationPrimary, nil
+	}
+
+	if err := afmConn.FailbackToPrimary(ctx, filesystemName, target.filesetName, false); err != nil {
+		return "", err
+	}
+	if err := afmConn.ApplyUpdates(ctx, filesystemName, target.filesetName); err != nil {
+		return "", err
+	}
+	if requested == csiv1.ReplicationStateResync {
+		return csiv1.ReplicationResyncing, nil
+	}
+
+	if err := afmConn.FailbackToPrimary(ctx, filesystemName, target.filesetName, true); err != nil {
+		return "", err
+	}
+	if err := peerAFMConn.ConvertToSecondary(ctx, peerFilesystem, target.filesetName, fileset.AFM.AFMPrimaryID); err != nil {
+		return "", err
+	}
+	return csiv1.ReplicationPrimary, nil
+}
+
+// pollReplicationLag records the replication lag of a primary volume, which
+// is the age of the last RPO snapshot of its fileset. The volume is degraded
+// while the lag exceeds twice the RPO, one missed RPO snapshot is tolerated.
+func (r *ScaleVolumeReplicationReconciler) pollReplicationLag(ctx context.Context, instance *csiv1.ScaleVolumeReplication,
+	target *replicationTarget, conn connectors.SpectrumScaleConnector) error {
+	if instance.Status.State != csiv1.ReplicationPrimary {
+		return nil
+	}
+	filesystemName, err := conn.GetFilesystemName(ctx, target.fsUUID)
+	if err != nil {
+		return err
+	}
+	fileset, err := conn.ListFileset(ctx, filesystemName, target.filesetName)
+	if err != nil {
+		return err
+	}
+	rpo := time.Duration(fileset.AFM.AFMRPO) * time.Minute
+
+	lastSync, err := getLastRPOSnapshotTime(ctx, conn, filesystemName, target.filesetName)
+	if err != nil {
+		return err
+	}
+
+	condition := metav1.Condition{Type: csiv1.ReplicationConditionDegraded, Status: metav1.ConditionFalse, Reason: string(csiv1.VolumeReplicationCompleted)}
+	var lag *metav1.Duration
+	switch {
+	case rpo == 0:
+		condition.Message = "The replication lag is unknown as the RPO of the volume is not set"
+	case lastSync == nil:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = string(csiv1.VolumeReplicationLagging)
+		condition.Message = fmt.Sprintf("No RPO snapshot of the volume is taken yet, the RPO is %v", rpo)
+	default:
+		lag = &metav1.Duration{Duration: time.Since(lastSync.Time).Truncate(time.Second)}
+		condition.Message = fmt.Sprintf("The replication lag is %v, the RPO is %v", lag.Duration, rpo)
+		if lag.Duration > 2*rpo {
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = string(csiv1.VolumeReplicationLagging)
+			condition.Message = fmt.Sprintf("The replication lag is %v, which exceeds the RPO of %v", lag.Duration, rpo)
+		}
+	}
+	if condition.Status == metav1.ConditionTrue && !meta.IsStatusConditionTrue(instance.Status.Conditions, csiv1.ReplicationConditionDegraded) {
+		r.event(instance, target, corev1.EventTypeWarning, csiv1.VolumeReplicationLagging, condition.Message)
+	}
+
+	instance.Status.LastSyncTime = lastSync
+	instance.Status.ReplicationLag = lag
+	meta.SetStatusCondition(&instance.Status.Conditions, condition)
+	return r.Client.Status().Update(ctx, instance)
+}
+
+// getLastRPOSnapshotTime returns the creation time of the last RPO snapshot
+// of an AFM-DR primary fileset, or nil if there is none.
+func getLastRPOSnapshotTime(ctx context.Context, conn connectors.SpectrumScaleConnector, filesystemName string, filesetName string) (*metav1.Time, error) {
+	snapshots, err := conn.ListFilesetSnapshots(ctx, filesystemName, filesetName)
+	if err != nil {
+		return nil, err
+	}
+	var lastRPOSnap *connectors.Snapshot_v2
+	for i := range snapshots {
+		if strings.HasPrefix(snapshots[i].SnapshotName, afmRPOSnapPrefix) && (lastRPOSnap == nil || snapshots[i].SnapID > lastRPOSnap.SnapID) {
+			lastRPOSnap = &snapshots[i]
+		}
+	}
+	if lastRPOSnap == nil {
+		return nil, nil
+	}
+
+	timezoneOffset, err := conn.GetTimeZoneOffset(ctx)
+	if err != nil {
+		return nil, err
+	}
+	created, err := parseClusterTimestamp(lastRPOSnap.Created, timezoneOffset)
+	if err != nil {
+		return nil, err
+	}
+	return &metav1.Time{Time: created}, nil
+}
+
+// parseClusterTimestamp parses a timestamp returned by the GUI, which is in
+// the format 2006-01-02 15:04:05,000 in the timezone of the cluster.
+func parseClusterTimestamp(timestamp string, timezoneOffset string) (time.Time, error) {
+	// for GMT, the GUI returns Z instead of +00:00
+	if timezoneOffset == "Z" {
+		timezoneOffset = "+00:00"
+	}
+	return time.Parse("2006-01-02 15:04:05-07:00", strings.Replace(timestamp, ",000", timezoneOffset, 1))
+}
+
+// updateStatus updates the status of a ScaleVolumeReplication from the
+// background, on its latest version.
+func (r *ScaleVolumeReplicationReconciler) updateStatus(ctx context.Context, instance *csiv1.ScaleVolumeReplication,
+	update func(status *csiv1.ScaleVolumeReplicationStatus)) {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &csiv1.ScaleVolumeReplication{}
+		if err := r.Client.Get(ctx, client.ObjectKeyFromObject(instance), latest); err != nil {
+			return err
+		}
+		update(&latest.Status)
+		return r.Client.Status().Update(ctx, latest)
+	})
+	if err != nil && !errors.IsNotFound(err) {
+		volumeReplicationLog.WithName("updateStatus").Error(err, "Failed to update ScaleVolumeReplication status",
+			"ScaleVolumeReplication", instance.Namespace+"/"+instance.Name)
+	}
+}
+
+// wait records the reason the replication state can not be changed and
+// requeues the ScaleVolumeReplication. The event is recorded when the message
+// changes.
+func (r *ScaleVolumeReplicationReconciler) wait(ctx context.Context, instance *csiv1.ScaleVolumeReplication, target *replicationTarget,
+	eventType string, reason csiv1.CSIReason, message string) (ctrl.Result, error) {
+	if instance.Status.State == "" {
+		instance.Status.State = csiv1.ReplicationUnknown
+	}
+	if instance.Status.Message != message {
+		instance.Status.Message = message
+		r.event(instance, target, eventType, reason, message)
+		if err := r.Client.Status().Update(ctx, instance); err != nil {
+			volumeReplicationLog.WithName("wait").Error(err, "Failed to update ScaleVolumeReplication status")
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: config.VolumeReplicationRetrySeconds * time.Second}, nil
+}
+
+// event records an event on the ScaleVolumeReplication and on the replicated PVC.
+func (r *ScaleVolumeReplicationReconciler) event(instance *csiv1.ScaleVolumeReplication, target *replicationTarget,
+	eventType string, reason csiv1.CSIReason, message string) {
+	r.Recorder.Event(instance, eventType, string(reason), message)
+	if target != nil && target.pvc != nil {
+		r.Recorder.Event(target.pvc, eventType, string(reason), message)
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ScaleVolumeReplicationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&csiv1.ScaleVolumeReplication{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
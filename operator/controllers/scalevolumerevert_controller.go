@@ -0,0 +1,693 @@
+/*
+Copyright 2024 IBM Corp.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/IBM/ibm-spectrum-scale-csi/driver/csiplugin/connectors"
+	csiv1 "github.com/IBM/ibm-spectrum-scale-csi/operator/api/v1"
+	config "github.com/IBM/ibm-spectrum-scale-csi/operator/controllers/config"
+)
+
+// ScaleVolumeRevertReconciler reconciles a ScaleVolumeRevert object
+type ScaleVolumeRevertReconciler struct {
+	Client client.Client
+	// APIReader reads PVCs, PVs, VolumeSnapshots and VolumeAttachments directly
+	// from the API server as the manager cache is restricted to the operator namespace.
+	APIReader client.Reader
+	Scheme    *runtime.Scheme
+	Recorder  record.EventRecorder
+
+	// jobsLock protects jobs
+	jobsLock sync.Mutex
+	// copy jobs awaited in the background by cluster ID and job ID
+	jobs map[string]*copyJob
+}
+
+// copyJob is the result of a copy job awaited in the background
+type copyJob struct {
+	done bool
+	err  error
+}
+
+var volumeRevertLog = log.Log.WithName("scalevolumerevert_controller")
+
+// Indexes in a volume ID and a snapshot ID of classic storageClass
+// <storageclass_type>;<type_of_volume>;<cluster_id>;<filesystem_uuid>;<consistency_group>;<fileset_name>;<path>
+// <storageclass_type>;<type_of_volume>;<cluster_id>;<filesystem_uuid>;<consistency_group>;<fileset_name>;<snapshot_name>;<meta_snapshot_name>;<path>
+const (
+	volIDClusterIndex    = 2
+	volIDFsUUIDIndex     = 3
+	volIDFilesetIndex    = 5
+	snapIDMembersCount   = 9
+	snapIDSnapNameIndex  = 6
+	snapIDPathIndex      = 8
+	storageClassClassic  = "0"
+	snapshotPathFsetRoot = "/"
+
+	// prefix of the staging directory of a revert, followed by its UID
+	revertStagingDirPrefix = ".csi-volume-revert-"
+)
+
+// statAccessRegex matches the access line of the stat output, e.g.
+// Access: (0771/drwxrwx--x)  Uid: (    0/    root)   Gid: (    0/    root)
+var statAccessRegex = regexp.MustCompile(`Access: \(0?([0-7]{3,4})/[^)]*\)\s+Uid: \(\s*(\d+)/[^)]*\)\s+Gid: \(\s*(\d+)/`)
+
+var volumeSnapshotContentGVK = volumeSnapshotGVK.GroupVersion().WithKind(config.VolumeSnapshotContentKind)
+
+// revertTarget is the volume and the snapshot of a ScaleVolumeRevert
+type revertTarget struct {
+	pvc          *corev1.PersistentVolumeClaim
+	pvName       string
+	clusterID    string
+	fsUUID       string
+	filesetName  string
+	snapshotName string
+	// path of the volume data within the snapshot, relative to the fileset junction
+	snapshotPath string
+}
+
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshotcontents,verbs=get
+// +kubebuilder:rbac:groups="",resources=persistentvolumes,verbs=get;list;watch;patch
+
+// Reconcile reverts the data of a fileset based volume to one of its
+// snapshots, the PV and its fileset are kept. The revert is not in place: the
+// snapshot data is copied to a staging directory first, and the volume data is
+// replaced by a second copy of the staged data only once the first copy
+// completed, so the volume data is not changed if the snapshot data can not be
+// copied. The staged data takes space outside the quota of the fileset until
+// the revert completes. The copies are run by IBM Storage Scale
+// jobs which are polled by the later reconciles. The PV is labelled for the
+// whole revert so that the driver refuses to publish the volume, and the
+// revert waits while the volume is published to a node. A failed step is
+// retried until it succeeds or the ScaleVolumeRevert is deleted.
+func (r *ScaleVolumeRevertReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := volumeRevertLog.WithName("Reconcile").WithValues("ScaleVolumeRevert", req.NamespacedName)
+
+	instance := &csiv1.ScaleVolumeRevert{}
+	if err := r.Client.Get(ctx, req.NamespacedName, instance); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("ScaleVolumeRevert resource not found. Ignoring since object must be deleted.")
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to get ScaleVolumeRevert")
+		return ctrl.Result{}, err
+	}
+
+	if !instance.GetDeletionTimestamp().IsZero() {
+		return r.finalize(ctx, instance)
+	}
+
+	if instance.Status.Phase == csiv1.VolumeRevertSucceeded || instance.Status.Phase == csiv1.VolumeRevertFailed {
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(instance, config.VolumeRevertFinalizer) {
+		controllerutil.AddFinalizer(instance, config.VolumeRevertFinalizer)
+		if err := r.Client.Update(ctx, instance); err != nil {
+			logger.Error(err, "Failed to add the finalizer to ScaleVolumeRevert")
+			return ctrl.Result{}, err
+		}
+	}
+
+	target, message, err := r.getRevertTarget(ctx, instance)
+	if err != nil {
+		logger.Error(err, "Failed to get the volume and the snapshot to revert to")
+		return ctrl.Result{}, err
+	}
+	if message != "" {
+		if instance.Status.Phase == csiv1.VolumeRevertRunning {
+			// The volume data may be replaced partially, keep the volume
+			// blocked until the PVC and the VolumeSnapshot are back
+			return r.wait(ctx, instance, target, corev1.EventTypeWarning, csiv1.VolumeRevertStepFailed, message)
+		}
+		return r.fail(ctx, instance, target, csiv1.VolumeRevertInvalid, message)
+	}
+
+	if instance.Status.Phase != csiv1.VolumeRevertRunning {
+		// The publishes of the volume are blocked before checking that it is
+		// not published, so that it can not be published after the check
+		holder, err := r.lockVolume(ctx, instance, target.pvName)
+		if err != nil {
+			logger.Error(err, "Failed to label the PV of the volume")
+			return ctrl.Result{}, err
+		}
+		if holder != "" {
+			message := fmt.Sprintf("PVC %s/%s is being reverted by the ScaleVolumeRevert with UID %s", target.pvc.Namespace, target.pvc.Name, holder)
+			return r.wait(ctx, instance, target, corev1.EventTypeWarning, csiv1.VolumeRevertVolumeInUse, message)
+		}
+		message, err := r.checkVolumeNotInUse(ctx, target)
+		if err != nil {
+			logger.Error(err, "Failed to check if the volume is in use")
+			return ctrl.Result{}, err
+		}
+		if message != "" {
+			if err := r.unlockVolume(ctx, instance); err != nil {
+				logger.Error(err, "Failed to remove the label of the PV of the volume")
+				return ctrl.Result{}, err
+			}
+			return r.wait(ctx, instance, target, corev1.EventTypeWarning, csiv1.VolumeRevertVolumeInUse, message)
+		}
+	}
+
+	conn, connectorExists := getScaleConnector(target.clusterID)
+	if !connectorExists {
+		message := fmt.Sprintf("Waiting for the GUI connection of the cluster with ID %s to be initialized", target.clusterID)
+		logger.Info(message)
+		return r.wait(ctx, instance, target, corev1.EventTypeNormal, csiv1.VolumeRevertWaitingForGUI, message)
+	}
+
+	if instance.Status.Phase != csiv1.VolumeRevertRunning {
+		message := fmt.Sprintf("Reverting PVC %s/%s to VolumeSnapshot %s", target.pvc.Namespace, target.pvc.Name, instance.Spec.VolumeSnapshotName)
+		instance.Status.Phase = csiv1.VolumeRevertRunning
+		instance.Status.Message = message
+		instance.Status.StartTime = &metav1.Time{Time: time.Now()}
+		r.event(instance, target, corev1.EventTypeNormal, csiv1.VolumeRevertStarted, message)
+		if err := r.Client.Status().Update(ctx, instance); err != nil {
+			logger.Error(err, "Failed to update ScaleVolumeRevert status")
+			return ctrl.Result{}, err
+		}
+	}
+
+	done, err := r.revert(ctx, instance, conn, target)
+	if err != nil {
+		logger.Error(err, "Failed to revert the volume", "step", instance.Status.Step)
+		message := fmt.Sprintf("Failed to revert PVC %s/%s to VolumeSnapshot %s in step %s, the step is retried: %v",
+			target.pvc.Namespace, target.pvc.Name, instance.Spec.VolumeSnapshotName, instance.Status.Step, err)
+		instance.Status.Message = message
+		r.event(instance, target, corev1.EventTypeWarning, csiv1.VolumeRevertStepFailed, message)
+		if err := r.Client.Status().Update(ctx, instance); err != nil {
+			logger.Error(err, "Failed to update ScaleVolumeRevert status")
+		}
+		return ctrl.Result{}, err
+	}
+	if !done {
+		return ctrl.Result{RequeueAfter: config.VolumeRevertPollSeconds * time.Second}, nil
+	}
+
+	message = fmt.Sprintf("Reverted PVC %s/%s to VolumeSnapshot %s", target.pvc.Namespace, target.pvc.Name, instance.Spec.VolumeSnapshotName)
+	instance.Status.Phase = csiv1.VolumeRevertSucceeded
+	instance.Status.Message = message
+	instance.Status.Step = ""
+	instance.Status.CompletionTime = &metav1.Time{Time: time.Now()}
+	r.event(instance, target, corev1.EventTypeNormal, csiv1.VolumeRevertCompleted, message)
+	if err := r.Client.Status().Update(ctx, instance); err != nil {
+		logger.Error(err, "Failed to update ScaleVolumeRevert status")
+		return ctrl.Result{}, err
+	}
+	if err := r.release(ctx, instance); err != nil {
+		logger.Error(err, "Failed to unblock the volume")
+		return ctrl.Result{}, err
+	}
+
+	logger.Info(message)
+	return ctrl.Result{}, nil
+}
+
+// getScaleConnector returns the connector of the cluster set up by the CSIScaleOperator controller.
+func getScaleConnector(clusterID string) (connectors.SpectrumScaleConnector, bool) {
+	scaleConnMapLock.RLock()
+	defer scaleConnMapLock.RUnlock()
+	conn, ok := scaleConnMap[clusterID]
+	return conn, ok
+}
+
+// getRevertTarget returns the volume and the snapshot of the revert. A non empty
+// message is returned if the PVC or the VolumeSnapshot can not be reverted to.
+func (r *ScaleVolumeRevertReconciler) getRevertTarget(ctx context.Context, instance *csiv1.ScaleVolumeRevert) (*revertTarget, string, error) {
+	namespace := instance.Spec.Namespace
+	if namespace == "" {
+		namespace = instance.Namespace
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: instance.Spec.PersistentVolumeClaimName}, pvc); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, fmt.Sprintf("PVC %s/%s is not found", namespace, instance.Spec.PersistentVolumeClaimName), nil
+		}
+		return nil, "", err
+	}
+	target := &revertTarget{pvc: pvc, pvName: pvc.Spec.VolumeName}
+	if pvc.Status.Phase != corev1.ClaimBound || pvc.Spec.VolumeName == "" {
+		return target, fmt.Sprintf("PVC %s/%s is not bound", namespace, pvc.Name), nil
+	}
+
+	pv := &corev1.PersistentVolume{}
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Name: pvc.Spec.VolumeName}, pv); err != nil {
+		if errors.IsNotFound(err) {
+			return target, fmt.Sprintf("PV %s of PVC %s/%s is not found", pvc.Spec.VolumeName, namespace, pvc.Name), nil
+		}
+		return nil, "", err
+	}
+	if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != config.DriverName {
+		return target, fmt.Sprintf("PVC %s/%s is not a volume of the IBM Storage Scale CSI driver", namespace, pvc.Name), nil
+	}
+
+	volMembers := strings.Split(pv.Spec.CSI.VolumeHandle, ";")
+	if len(volMembers) != volIDMembersCount || volMembers[volIDSCTypeIndex] != storageClassClassic || volMembers[volIDFilesetIndex] == "" {
+		return target, fmt.Sprintf("PVC %s/%s can not be reverted, only fileset based volumes of classic storageClass can be reverted", namespace, pvc.Name), nil
+	}
+	target.clusterID = volMembers[volIDClusterIndex]
+	target.fsUUID = volMembers[volIDFsUUIDIndex]
+	target.filesetName = volMembers[volIDFilesetIndex]
+
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: instance.Spec.VolumeSnapshotName}, snapshot); err != nil {
+		if errors.IsNotFound(err) {
+			return target, fmt.Sprintf("VolumeSnapshot %s/%s is not found", namespace, instance.Spec.VolumeSnapshotName), nil
+		}
+		return nil, "", err
+	}
+	readyToUse, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+	contentName, _, _ := unstructured.NestedString(snapshot.Object, "status", "boundVolumeSnapshotContentName")
+	if !readyToUse || contentName == "" {
+		return target, fmt.Sprintf("VolumeSnapshot %s/%s is not ready to use", namespace, snapshot.GetName()), nil
+	}
+
+	content := &unstructured.Unstructured{}
+	content.SetGroupVersionKind(volumeSnapshotContentGVK)
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Name: contentName}, content); err != nil {
+		if errors.IsNotFound(err) {
+			return target, fmt.Sprintf("VolumeSnapshotContent %s of VolumeSnapshot %s/%s is not found", contentName, namespace, snapshot.GetName()), nil
+		}
+		return nil, "", err
+	}
+	snapshotHandle, _, _ := unstructured.NestedString(content.Object, "status", "snapshotHandle")
+
+	snapMembers := strings.Split(snapshotHandle, ";")
+	if len(snapMembers) != snapIDMembersCount || snapMembers[volIDClusterIndex] != target.clusterID ||
+		snapMembers[volIDFsUUIDIndex] != target.fsUUID || snapMembers[volIDFilesetIndex] != target.filesetName {
+		return target, fmt.Sprintf("VolumeSnapshot %s/%s is not a snapshot of PVC %s/%s", namespace, snapshot.GetName(), namespace, pvc.Name), nil
+	}
+	target.snapshotName = snapMembers[snapIDSnapNameIndex]
+	target.snapshotPath = strings.Trim(snapMembers[snapIDPathIndex], "/")
+	if target.snapshotPath == "" || snapMembers[snapIDPathIndex] == snapshotPathFsetRoot {
+		// The fileset junction of a statically provisioned volume can not be recreated
+		return target, fmt.Sprintf("PVC %s/%s can not be reverted, only dynamically provisioned volumes can be reverted", namespace, pvc.Name), nil
+	}
+
+	return target, "", nil
+}
+
+// checkVolumeNotInUse returns a non empty message if the volume is published to a node.
+func (r *ScaleVolumeRevertReconciler) checkVolumeNotInUse(ctx context.Context, target *revertTarget) (string, error) {
+	attachments := &storagev1.VolumeAttachmentList{}
+	if err := r.APIReader.List(ctx, attachments); err != nil {
+		return "", err
+	}
+	for _, attachment := range attachments.Items {
+		if attachment.Spec.Source.PersistentVolumeName != nil && *attachment.Spec.Source.PersistentVolumeName == target.pvName {
+			return fmt.Sprintf("PVC %s/%s is published to the node %s, stop the pods using the PVC to revert it",
+				target.pvc.Namespace, target.pvc.Name, attachment.Spec.NodeName), nil
+		}
+	}
+	return "", nil
+}
+
+// lockVolume labels the PV of the volume with the UID of the revert, the
+// driver refuses to publish the volume while the label is set. The UID of
+// another revert holding the PV is returned if there is one. The label of a
+// revert which does not exist anymore is taken over.
+func (r *ScaleVolumeRevertReconciler) lockVolume(ctx context.Context, instance *csiv1.ScaleVolumeRevert, pvName string) (string, error) {
+	pv := &corev1.PersistentVolume{}
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Name: pvName}, pv); err != nil {
+		return "", err
+	}
+	holder := pv.Labels[config.LabelVolumeRevert]
+	if holder == string(instance.UID) {
+		return "", nil
+	}
+	if holder != "" {
+		reverts := &csiv1.ScaleVolumeRevertList{}
+		if err := r.Client.List(ctx, reverts); err != nil {
+			return "", err
+		}
+		for _, revert := range reverts.Items {
+			if string(revert.UID) == holder {
+				return holder, nil
+			}
+		}
+	}
+
+	// The optimistic lock makes the label be set by one revert at a time
+	patch := client.MergeFromWithOptions(pv.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	if pv.Labels == nil {
+		pv.Labels = make(map[string]string)
+	}
+	pv.Labels[config.LabelVolumeRevert] = string(instance.UID)
+	return "", r.Client.Patch(ctx, pv, patch)
+}
+
+// unlockVolume removes the label of the revert from the PV of the volume.
+func (r *ScaleVolumeRevertReconciler) unlockVolume(ctx context.Context, instance *csiv1.ScaleVolumeRevert) error {
+	pvs := &corev1.PersistentVolumeList{}
+	if err := r.APIReader.List(ctx, pvs, client.MatchingLabels{config.LabelVolumeRevert: string(instance.UID)}); err != nil {
+		return err
+	}
+	for i := range pvs.Items {
+		pv := &pvs.Items[i]
+		patch := client.MergeFrom(pv.DeepCopy())
+		delete(pv.Labels, config.LabelVolumeRevert)
+		if err := r.Client.Patch(ctx, pv, patch); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// release unblocks the volume and removes the finalizer of a revert which
+// succeeded or failed.
+func (r *ScaleVolumeRevertReconciler) release(ctx context.Context, instance *csiv1.ScaleVolumeRevert) error {
+	if err := r.unlockVolume(ctx, instance); err != nil {
+		return err
+	}
+	if controllerutil.RemoveFinalizer(instance, config.VolumeRevertFinalizer) {
+		return r.Client.Update(ctx, instance)
+	}
+	return nil
+}
+
+// finalize unblocks the volume of a deleted revert. The volume data and the
+// staging directory of a running revert are left as they are.
+func (r *ScaleVolumeRevertReconciler) finalize(ctx context.Context, instance *csiv1.ScaleVolumeRevert) (ctrl.Result, error) {
+	logger := volumeRevertLog.WithName("finalize").WithValues("ScaleVolumeRevert", instance.Namespace+"/"+instance.Name)
+	if !controllerutil.ContainsFinalizer(instance, config.VolumeRevertFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	if instance.Status.Phase == csiv1.VolumeRevertRunning && instance.Status.DataDirectory != nil {
+		dataDir := instance.Status.DataDirectory
+		message := fmt.Sprintf("ScaleVolumeRevert is deleted in step %s, the data of the PVC may be incomplete. "+
+			"Delete the staging directory %s of filesystem %s once it is not needed", instance.Status.Step, dataDir.StagingPath, dataDir.Filesystem)
+		logger.Info(message)
+		r.event(instance, nil, corev1.EventTypeWarning, csiv1.VolumeRevertStepFailed, message)
+	}
+
+	if err := r.release(ctx, instance); err != nil {
+		logger.Error(err, "Failed to unblock the volume")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// revert runs the current step of the revert and returns true once all the
+// steps completed. The step and the ID of its copy job are recorded in the
+// status, so that the revert resumes from the current step if the operator
+// restarts. A step whose copy job failed is started again.
+func (r *ScaleVolumeRevertReconciler) revert(ctx context.Context, instance *csiv1.ScaleVolumeRevert,
+	conn connectors.SpectrumScaleConnector, target *revertTarget) (bool, error) {
+	logger := volumeRevertLog.WithName("revert").WithValues("ScaleVolumeRevert", instance.Namespace+"/"+instance.Name)
+
+	if instance.Status.DataDirectory == nil {
+		dataDir, err := getRevertDataDirectory(ctx, conn, target, instance)
+		if err != nil {
+			return false, err
+		}
+		instance.Status.DataDirectory = dataDir
+		instance.Status.Step = csiv1.VolumeRevertStepStaging
+		if err := r.Client.Status().Update(ctx, instance); err != nil {
+			return false, err
+		}
+	}
+	dataDir := instance.Status.DataDirectory
+
+	mountPoint, err := conn.GetFilesystemMountpoint(ctx, dataDir.Filesystem)
+	if err != nil {
+		return false, err
+	}
+
+	if instance.Status.Step == csiv1.VolumeRevertStepStaging {
+		if instance.Status.CopyJobID == 0 {
+			logger.Info("Copying the snapshot data to the staging directory", "filesystem", dataDir.Filesystem, "path", dataDir.StagingPath)
+			if err := recreateDirectory(ctx, conn, dataDir, dataDir.StagingPath); err != nil {
+				return false, err
+			}
+			stagingPath := fmt.Sprintf("%s/%s", mountPoint, dataDir.StagingPath)
+			_, jobID, err := conn.CopyFsetSnapshotPath(ctx, dataDir.Filesystem, target.filesetName, target.snapshotName, target.snapshotPath, stagingPath, "")
+			if err != nil {
+				return false, err
+			}
+			return false, r.startedCopyJob(ctx, instance, target, jobID, csiv1.VolumeRevertCopyStarted,
+				fmt.Sprintf("Copying the data of snapshot %s of PVC %s/%s to a staging directory, job ID %d", target.snapshotName, target.pvc.Namespace, target.pvc.Name, jobID))
+		}
+		if done, err := r.waitCopyJob(ctx, instance, target.clusterID, conn); !done || err != nil {
+			return false, err
+		}
+		instance.Status.Step = csiv1.VolumeRevertStepReplacing
+		if err := r.Client.Status().Update(ctx, instance); err != nil {
+			return false, err
+		}
+	}
+
+	if instance.Status.Step == csiv1.VolumeRevertStepReplacing {
+		if instance.Status.CopyJobID == 0 {
+			logger.Info("Replacing the volume data by the staged data", "filesystem", dataDir.Filesystem, "path", dataDir.Path)
+			if err := recreateDirectory(ctx, conn, dataDir, dataDir.Path); err != nil {
+				return false, err
+			}
+			dataPath := fmt.Sprintf("%s/%s", mountPoint, dataDir.Path)
+			_, jobID, err := conn.CopyDirectoryPath(ctx, dataDir.Filesystem, dataDir.StagingPath, dataPath, "")
+			if err != nil {
+				return false, err
+			}
+			return false, r.startedCopyJob(ctx, instance, target, jobID, csiv1.VolumeRevertReplacing,
+				fmt.Sprintf("Replacing the data of PVC %s/%s by the data of snapshot %s, job ID %d", target.pvc.Namespace, target.pvc.Name, target.snapshotName, jobID))
+		}
+		if done, err := r.waitCopyJob(ctx, instance, target.clusterID, conn); !done || err != nil {
+			return false, err
+		}
+		instance.Status.Step = csiv1.VolumeRevertStepCleaningUp
+		if err := r.Client.Status().Update(ctx, instance); err != nil {
+			return false, err
+		}
+	}
+
+	logger.Info("Deleting the staging directory", "filesystem", dataDir.Filesystem, "path", dataDir.StagingPath)
+	if err := deleteDirectoryIfPresent(ctx, conn, dataDir.Filesystem, dataDir.StagingPath); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// startedCopyJob records the copy job started by the current step.
+func (r *ScaleVolumeRevertReconciler) startedCopyJob(ctx context.Context, instance *csiv1.ScaleVolumeRevert, target *revertTarget,
+	jobID uint64, reason csiv1.CSIReason, message string) error {
+	instance.Status.CopyJobID = int64(jobID)
+	instance.Status.Message = message
+	r.event(instance, target, corev1.EventTypeNormal, reason, message)
+	return r.Client.Status().Update(ctx, instance)
+}
+
+// waitCopyJob returns true once the copy job of the current step completed.
+// The job ID is cleared from the status if the job failed, so that the step
+// is started again.
+func (r *ScaleVolumeRevertReconciler) waitCopyJob(ctx context.Context, instance *csiv1.ScaleVolumeRevert,
+	clusterID string, conn connectors.SpectrumScaleConnector) (bool, error) {
+	jobID := instance.Status.CopyJobID
+	done, jobErr := r.pollCopyJob(clusterID, conn, uint64(jobID))
+	if !done {
+		return false, nil
+	}
+	instance.Status.CopyJobID = 0
+	if jobErr != nil {
+		if err := r.Client.Status().Update(ctx, instance); err != nil {
+			return false, err
+		}
+		return false, fmt.Errorf("copy job %d failed: %v", jobID, jobErr)
+	}
+	return true, nil
+}
+
+// pollCopyJob returns true once the copy job completed, along with the error
+// of the job. The job is awaited in the background so that the reconciles do
+// not block for the duration of the copy, the first poll of a job started
+// before a restart of the operator awaits it again.
+func (r *ScaleVolumeRevertReconciler) pollCopyJob(clusterID string, conn connectors.SpectrumScaleConnector, jobID uint64) (bool, error) {
+	key := fmt.Sprintf("%s/%d", clusterID, jobID)
+	r.jobsLock.Lock()
+	defer r.jobsLock.Unlock()
+	if r.jobs == nil {
+		r.jobs = make(map[string]*copyJob)
+	}
+
+	job, ok := r.jobs[key]
+	if !ok {
+		job = &copyJob{}
+		r.jobs[key] = job
+		go func() {
+			_, err := conn.WaitForJobCompletionWithResp(context.Background(), http.StatusAccepted, jobID)
+			r.jobsLock.Lock()
+			defer r.jobsLock.Unlock()
+			job.done = true
+			job.err = err
+		}()
+		return false, nil
+	}
+	if !job.done {
+		return false, nil
+	}
+	delete(r.jobs, key)
+	return true, job.err
+}
+
+// recreateDirectory deletes a directory if it is present and creates it
+// empty with the owner and permissions of the volume data directory.
+func recreateDirectory(ctx context.Context, conn connectors.SpectrumScaleConnector, dataDir *csiv1.RevertDataDirectory, path string) error {
+	if err := deleteDirectoryIfPresent(ctx, conn, dataDir.Filesystem, path); err != nil {
+		return err
+	}
+	dirExists, err := conn.CheckIfFileDirPresent(ctx, dataDir.Filesystem, path)
+	if err != nil || dirExists {
+		return err
+	}
+	return conn.MakeDirectoryV2(ctx, dataDir.Filesystem, path, dataDir.UID, dataDir.GID, dataDir.Permissions)
+}
+
+// deleteDirectoryIfPresent deletes a directory along with its content.
+func deleteDirectoryIfPresent(ctx context.Context, conn connectors.SpectrumScaleConnector, filesystemName string, path string) error {
+	dirExists, err := conn.CheckIfFileDirPresent(ctx, filesystemName, path)
+	if err != nil || !dirExists {
+		return err
+	}
+	return conn.DeleteDirectory(ctx, filesystemName, path, false)
+}
+
+// getRevertDataDirectory returns the data directory of the volume along with
+// the attributes needed to recreate it, and the staging directory of the
+// revert. The staging directory is in the root of the filesystem, so that the
+// staged data does not count towards the quota of the fileset of the volume.
+func getRevertDataDirectory(ctx context.Context, conn connectors.SpectrumScaleConnector, target *revertTarget,
+	instance *csiv1.ScaleVolumeRevert) (*csiv1.RevertDataDirectory, error) {
+	filesystemName, err := conn.GetFilesystemName(ctx, target.fsUUID)
+	if err != nil {
+		return nil, err
+	}
+	mountPoint, err := conn.GetFilesystemMountpoint(ctx, filesystemName)
+	if err != nil {
+		return nil, err
+	}
+	fileset, err := conn.ListFileset(ctx, filesystemName, target.filesetName)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(fileset.Config.Path, mountPoint) {
+		return nil, fmt.Errorf("fileset %s is not linked in filesystem %s", target.filesetName, filesystemName)
+	}
+
+	junctionPath := strings.Trim(strings.TrimPrefix(fileset.Config.Path, mountPoint), "/")
+	dataDirPath := target.snapshotPath
+	if junctionPath != "" {
+		dataDirPath = junctionPath + "/" + target.snapshotPath
+	}
+
+	statInfo, err := conn.StatDirectory(ctx, filesystemName, dataDirPath)
+	if err != nil {
+		return nil, err
+	}
+	access := statAccessRegex.FindStringSubmatch(statInfo)
+	if access == nil {
+		return nil, fmt.Errorf("unable to get the owner and permissions of directory %s from stat output: %s", dataDirPath, statInfo)
+	}
+
+	return &csiv1.RevertDataDirectory{
+		Filesystem:  filesystemName,
+		Path:        dataDirPath,
+		StagingPath: fmt.Sprintf("%s%s", revertStagingDirPrefix, instance.UID),
+		Permissions: access[1],
+		UID:         access[2],
+		GID:         access[3],
+	}, nil
+}
+
+// fail marks the revert failed with the given reason and message. It is
+// called only before the volume data is changed.
+func (r *ScaleVolumeRevertReconciler) fail(ctx context.Context, instance *csiv1.ScaleVolumeRevert, target *revertTarget,
+	reason csiv1.CSIReason, message string) (ctrl.Result, error) {
+	logger := volumeRevertLog.WithName("fail").WithValues("ScaleVolumeRevert", instance.Namespace+"/"+instance.Name)
+	logger.Info("ScaleVolumeRevert failed", "reason", reason, "message", message)
+
+	instance.Status.Phase = csiv1.VolumeRevertFailed
+	instance.Status.Message = message
+	instance.Status.CompletionTime = &metav1.Time{Time: time.Now()}
+	r.event(instance, target, corev1.EventTypeWarning, reason, message)
+	if err := r.Client.Status().Update(ctx, instance); err != nil {
+		logger.Error(err, "Failed to update ScaleVolumeRevert status")
+		return ctrl.Result{}, err
+	}
+	if err := r.release(ctx, instance); err != nil {
+		logger.Error(err, "Failed to unblock the volume")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// wait records the reason the revert can not progress and requeues it. The
+// event is recorded when the message changes.
+func (r *ScaleVolumeRevertReconciler) wait(ctx context.Context, instance *csiv1.ScaleVolumeRevert, target *revertTarget,
+	eventType string, reason csiv1.CSIReason, message string) (ctrl.Result, error) {
+	if instance.Status.Phase == "" {
+		instance.Status.Phase = csiv1.VolumeRevertPending
+	}
+	if instance.Status.Message != message {
+		instance.Status.Message = message
+		r.event(instance, target, eventType, reason, message)
+		if err := r.Client.Status().Update(ctx, instance); err != nil {
+			volumeRevertLog.WithName("wait").Error(err, "Failed to update ScaleVolumeRevert status")
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: config.VolumeRevertRetrySeconds * time.Second}, nil
+}
+
+// event records an event on the ScaleVolumeRevert and on the PVC being reverted.
+func (r *ScaleVolumeRevertReconciler) event(instance *csiv1.ScaleVolumeRevert, target *revertTarget,
+	eventType string, reason csiv1.CSIReason, message string) {
+	r.Recorder.Event(instance, eventType, string(reason), message)
+	if target != nil && target.pvc != nil {
+		r.Recorder.Event(target.pvc, eventType, string(reason), message)
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ScaleVolumeRevertReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&csiv1.ScaleVolumeRevert{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -43,6 +43,7 @@ const (
 	nodeLivenessProbeContainerName   = "liveness-probe"
 	nodeContainerHealthPortName      = "healthz"
 	nodeContainerHealthPortNumber    = 9821
+	nodeContainerMetricsPortName     = "metrics"
 	podMountDir                      = "pods-mount-dir"
 	hostDev                          = "host-dev"
 	hostDevPath                      = "/dev"
@@ -105,7 +106,7 @@ func GetCSIDaemonsetSyncer(c client.Client, scheme *runtime.Scheme, driver *csis
 	cmEnvVars = []corev1.EnvVar{}
 	var keys []string
 	for k := range envVars {
-		if !(k == config.DaemonSetUpgradeMaxUnavailableKey || k == config.HostNetworkKey || k == config.DriverCPULimits || k == config.DriverMemoryLimits || k == config.SidecarCPULimits || k == config.SidecarMemoryLimits) {
+		if !(k == config.DaemonSetUpgradeMaxUnavailableKey || k == config.HostNetworkKey || k == config.TaintUnhealthyNodesKey || k == config.DriverCPULimits || k == config.DriverMemoryLimits || k == config.SidecarCPULimits || k == config.SidecarMemoryLimits) {
 			keys = append(keys, k)
 		}
 	}
@@ -191,6 +192,17 @@ func (s *csiNodeSyncer) SyncCSIDaemonsetFn(daemonSetRestartedKey, daemonSetResta
 	return nil
 }
 
+// ensureTolerations returns the tolerations of the node plugin pods, which
+// tolerate the unhealthy taint so that they keep serving the node.
+func (s *csiNodeSyncer) ensureTolerations() []corev1.Toleration {
+	tolerations := append([]corev1.Toleration{}, s.driver.Spec.Tolerations...)
+	return append(tolerations, corev1.Toleration{
+		Key:      config.UnhealthyNodeTaintKey,
+		Operator: corev1.TolerationOpExists,
+		Effect:   corev1.TaintEffectNoSchedule,
+	})
+}
+
 // ensurePodSpec creates and returns pod specs for CSI driver pod.
 func (s *csiNodeSyncer) ensurePodSpec(secrets []corev1.LocalObjectReference, cpuLimits string, memoryLimits string, sidecarCPULimits string, sidecarMemoryLimits string) corev1.PodSpec {
 
@@ -200,7 +212,7 @@ func (s *csiNodeSyncer) ensurePodSpec(secrets []corev1.LocalObjectReference, cpu
 		HostIPC:            false,
 		DNSPolicy:          config.ClusterFirstWithHostNet,
 		ServiceAccountName: config.GetNameForResource(config.CSINodeServiceAccount, s.driver.Name),
-		Tolerations:        s.driver.Spec.Tolerations,
+		Tolerations:        s.ensureTolerations(),
 		ImagePullSecrets:   secrets,
 		Affinity:           s.driver.GetAffinity(config.NodePlugin.String()),
 		PriorityClassName:  "system-node-critical",
@@ -216,13 +228,19 @@ func (s *csiNodeSyncer) ensureContainersSpec(cpuLimits string, memoryLimits stri
 	logger := csiLog.WithName("ensureContainersSpec")
 
 	// node plugin container
+	nodePluginArgs := []string{
+		"--nodeid=$(NODE_ID)",
+		"--endpoint=$(CSI_ENDPOINT)",
+		"--kubeletRootDirPath=$(KUBELET_ROOT_DIR_PATH)",
+	}
+	// The metrics endpoint is not authenticated, serve it only if enabled
+	metricsPort := s.driver.Spec.MetricsPort
+	if metricsPort != 0 {
+		nodePluginArgs = append(nodePluginArgs, "--metricsAddress=:"+strconv.Itoa(int(metricsPort)))
+	}
 	nodePlugin := s.ensureContainer(nodeContainerName,
 		s.getImage(config.GetNameForResource(config.CSINode, s.driver.Name)),
-		[]string{
-			"--nodeid=$(NODE_ID)",
-			"--endpoint=$(CSI_ENDPOINT)",
-			"--kubeletRootDirPath=$(KUBELET_ROOT_DIR_PATH)",
-		},
+		nodePluginArgs,
 	)
 
 	nodePlugin.Resources = ensureDriverResources(cpuLimits, memoryLimits)
@@ -231,6 +249,13 @@ func (s *csiNodeSyncer) ensureContainersSpec(cpuLimits string, memoryLimits stri
 	//	Name:          nodeContainerHealthPortName,
 	//	ContainerPort: nodeContainerHealthPortNumber,
 	//})
+	if metricsPort != 0 {
+		nodePlugin.Ports = ensurePorts(corev1.ContainerPort{
+			Name:          nodeContainerMetricsPortName,
+			ContainerPort: metricsPort,
+			Protocol:      corev1.ProtocolTCP,
+		})
+	}
 
 	nodePlugin.ImagePullPolicy = config.CSIDriverImagePullPolicy
 
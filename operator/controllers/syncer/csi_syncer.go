@@ -914,9 +914,9 @@ func (s *csiControllerSyncer) ensurePodTolerations(tolerations []corev1.Tolerati
 	return podTolerations
 }
 
-/*func ensurePorts(ports ...corev1.ContainerPort) []corev1.ContainerPort {
+func ensurePorts(ports ...corev1.ContainerPort) []corev1.ContainerPort {
 	return ports
-}*/
+}
 
 func ensureProbe(delay, timeout, period int32, handler corev1.ProbeHandler) *corev1.Probe {
 	return &corev1.Probe{
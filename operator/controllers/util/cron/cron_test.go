@@ -0,0 +1,243 @@
+/**
+ * Copyright 2024 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{spec: "* * * * *"},
+		{spec: "0 0 1 1 *"},
+		{spec: "*/15 0-23/2 1,15 1-12 1-5"},
+		{spec: "5/10 * * * *"},
+		{spec: "0 12 ? * 7"},
+		{spec: "  30 2 * * 0  "},
+		{spec: "@yearly"},
+		{spec: "@annually"},
+		{spec: "@monthly"},
+		{spec: "@weekly"},
+		{spec: "@daily"},
+		{spec: "@midnight"},
+		{spec: "@hourly"},
+		{spec: "", wantErr: true},
+		{spec: "* * * *", wantErr: true},
+		{spec: "* * * * * *", wantErr: true},
+		{spec: "@every 1h", wantErr: true},
+		{spec: "60 * * * *", wantErr: true},
+		{spec: "* 24 * * *", wantErr: true},
+		{spec: "* * 0 * *", wantErr: true},
+		{spec: "* * 32 * *", wantErr: true},
+		{spec: "* * * 0 *", wantErr: true},
+		{spec: "* * * 13 *", wantErr: true},
+		{spec: "* * * * 8", wantErr: true},
+		{spec: "10-5 * * * *", wantErr: true},
+		{spec: "*/0 * * * *", wantErr: true},
+		{spec: "*/-1 * * * *", wantErr: true},
+		{spec: "*/x * * * *", wantErr: true},
+		{spec: "a-5 * * * *", wantErr: true},
+		{spec: "1-b * * * *", wantErr: true},
+		{spec: "x * * * *", wantErr: true},
+		{spec: "1,,2 * * * *", wantErr: true},
+		{spec: "* * * JAN *", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		_, err := Parse(tc.spec)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("Parse(%q) error = %v, want error %v", tc.spec, err, tc.wantErr)
+		}
+	}
+}
+
+func TestParseField(t *testing.T) {
+	tests := []struct {
+		field string
+		b     bounds
+		want  uint64
+	}{
+		{field: "*", b: hourBounds, want: 1<<24 - 1},
+		{field: "?", b: dowBounds, want: 1<<8 - 1},
+		{field: "5", b: minuteBounds, want: 1 << 5},
+		{field: "1,3,5", b: minuteBounds, want: 1<<1 | 1<<3 | 1<<5},
+		{field: "2-4", b: hourBounds, want: 1<<2 | 1<<3 | 1<<4},
+		{field: "*/6", b: hourBounds, want: 1<<0 | 1<<6 | 1<<12 | 1<<18},
+		{field: "1-10/3", b: domBounds, want: 1<<1 | 1<<4 | 1<<7 | 1<<10},
+		{field: "20/2", b: hourBounds, want: 1<<20 | 1<<22},
+		{field: "1,10-11,*/30", b: minuteBounds, want: 1<<0 | 1<<1 | 1<<10 | 1<<11 | 1<<30},
+	}
+
+	for _, tc := range tests {
+		got, err := parseField(tc.field, tc.b)
+		if err != nil {
+			t.Errorf("parseField(%q) failed: %v", tc.field, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("parseField(%q) = %b, want %b", tc.field, got, tc.want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	date := func(year int, month time.Month, day, hour, min, sec int) time.Time {
+		return time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "every minute",
+			spec: "* * * * *",
+			from: date(2024, time.March, 10, 14, 25, 0),
+			want: date(2024, time.March, 10, 14, 26, 0),
+		},
+		{
+			name: "seconds are rounded up to the next minute",
+			spec: "* * * * *",
+			from: date(2024, time.March, 10, 14, 25, 59),
+			want: date(2024, time.March, 10, 14, 26, 0),
+		},
+		{
+			name: "strictly after the activation time",
+			spec: "30 2 * * *",
+			from: date(2024, time.March, 10, 2, 30, 0),
+			want: date(2024, time.March, 11, 2, 30, 0),
+		},
+		{
+			name: "later the same day",
+			spec: "30 2 * * *",
+			from: date(2024, time.March, 10, 1, 0, 0),
+			want: date(2024, time.March, 10, 2, 30, 0),
+		},
+		{
+			name: "step of minutes",
+			spec: "*/15 * * * *",
+			from: date(2024, time.March, 10, 14, 46, 0),
+			want: date(2024, time.March, 10, 15, 0, 0),
+		},
+		{
+			name: "hourly",
+			spec: "@hourly",
+			from: date(2024, time.December, 31, 23, 0, 0),
+			want: date(2025, time.January, 1, 0, 0, 0),
+		},
+		{
+			name: "weekly on Sunday",
+			spec: "@weekly",
+			// Wednesday
+			from: date(2024, time.March, 13, 8, 0, 0),
+			want: date(2024, time.March, 17, 0, 0, 0),
+		},
+		{
+			name: "Sunday as 7",
+			spec: "0 0 * * 7",
+			from: date(2024, time.March, 13, 8, 0, 0),
+			want: date(2024, time.March, 17, 0, 0, 0),
+		},
+		{
+			name: "week days",
+			spec: "0 9 * * 1-5",
+			// Friday after 9:00
+			from: date(2024, time.March, 15, 10, 0, 0),
+			want: date(2024, time.March, 18, 9, 0, 0),
+		},
+		{
+			name: "monthly",
+			spec: "@monthly",
+			from: date(2024, time.January, 15, 0, 0, 0),
+			want: date(2024, time.February, 1, 0, 0, 0),
+		},
+		{
+			name: "yearly",
+			spec: "@yearly",
+			from: date(2024, time.January, 1, 0, 0, 0),
+			want: date(2025, time.January, 1, 0, 0, 0),
+		},
+		{
+			name: "day of month skips the short months",
+			spec: "0 0 31 * *",
+			from: date(2024, time.April, 1, 0, 0, 0),
+			want: date(2024, time.May, 31, 0, 0, 0),
+		},
+		{
+			name: "29th of February in a leap year",
+			spec: "0 0 29 2 *",
+			from: date(2025, time.January, 1, 0, 0, 0),
+			want: date(2028, time.February, 29, 0, 0, 0),
+		},
+		{
+			name: "day of month or day of week when both are restricted",
+			spec: "0 0 15 * 1",
+			// Tuesday the 5th, the next Monday is the 11th
+			from: date(2024, time.March, 5, 0, 0, 0),
+			want: date(2024, time.March, 11, 0, 0, 0),
+		},
+		{
+			name: "day of month when day of week is a wildcard",
+			spec: "0 0 15 * *",
+			from: date(2024, time.March, 5, 0, 0, 0),
+			want: date(2024, time.March, 15, 0, 0, 0),
+		},
+		{
+			name: "day of week when day of month is a wildcard",
+			spec: "0 0 ? * 1",
+			from: date(2024, time.March, 5, 0, 0, 0),
+			want: date(2024, time.March, 11, 0, 0, 0),
+		},
+		{
+			name: "impossible date",
+			spec: "0 0 30 2 *",
+			from: date(2024, time.January, 1, 0, 0, 0),
+			want: time.Time{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := Parse(tc.spec)
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tc.spec, err)
+			}
+			if got := s.Next(tc.from); !got.Equal(tc.want) {
+				t.Errorf("Next(%v) of %q = %v, want %v", tc.from, tc.spec, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNextInLocation(t *testing.T) {
+	location := time.FixedZone("UTC+2", 2*60*60)
+	s, err := Parse("0 3 * * *")
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	from := time.Date(2024, time.March, 10, 2, 0, 0, 0, location)
+	want := time.Date(2024, time.March, 10, 3, 0, 0, 0, location)
+	if got := s.Next(from); !got.Equal(want) || got.Location() != location {
+		t.Errorf("Next(%v) = %v, want %v", from, got, want)
+	}
+}